@@ -20,11 +20,14 @@ import (
 	"log"
 	"os"
 	fp "path/filepath"
+	"strings"
 
 	"github.com/harrybrwn/apizza/cmd/cli"
 	"github.com/harrybrwn/apizza/cmd/commands"
+	"github.com/harrybrwn/apizza/cmd/opts"
 	"github.com/harrybrwn/apizza/pkg/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -53,13 +56,21 @@ func AllCommands(builder cli.Builder) []*cobra.Command {
 		NewMenuCmd(builder).Cmd(),
 		commands.NewOrderCmd(builder).Cmd(),
 		commands.NewAddAddressCmd(builder, os.Stdin).Cmd(),
+		commands.NewStoreCmd(builder).Cmd(),
+		commands.NewDBCmd(builder).Cmd(),
+		commands.NewServeCmd(builder).Cmd(),
+		commands.NewScheduleCmd(builder).Cmd(),
+		commands.NewDaemonCmd(builder).Cmd(),
+		commands.NewHistoryCmd(builder).Cmd(),
 		commands.NewCompletionCmd(builder),
+		NewShellCmd(builder).Cmd(),
 	}
 }
 
 // Execute runs the root command
 func Execute(args []string, dir string) (msg *ErrMsg) {
 	app := NewApp(os.Stdout)
+	app.skipMigrations = isMigrateCmd(args)
 	err := app.Init(dir)
 	if err != nil {
 		return senderr(err, "Internal Error", 1)
@@ -86,6 +97,47 @@ func Execute(args []string, dir string) (msg *ErrMsg) {
 	return senderr(cmd.Execute(), "Error", 1)
 }
 
+// isMigrateCmd returns true if the arguments are for the 'db migrate'
+// command. It runs before the commands are created so the root command's
+// flags are used to skip the values given to flags.
+func isMigrateCmd(args []string) bool {
+	flags := pflag.NewFlagSet("apizza", pflag.ContinueOnError)
+	(&opts.CliFlags{}).Install(flags)
+	(&opts.ApizzaFlags{}).Install(flags)
+
+	var cmds []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			cmds = append(cmds, arg)
+			continue
+		}
+		if strings.Contains(arg, "=") {
+			continue
+		}
+		if strings.HasPrefix(arg, "--") {
+			if f := flags.Lookup(arg[2:]); f != nil && f.NoOptDefVal == "" {
+				i++ // skip the flag's value
+			}
+			continue
+		}
+		// a group of short flags ends at the first one that takes a value,
+		// which uses the rest of the group or the next argument
+		for j := 1; j < len(arg); j++ {
+			if f := flags.ShorthandLookup(arg[j : j+1]); f != nil && f.NoOptDefVal == "" {
+				if j == len(arg)-1 {
+					i++
+				}
+				break
+			}
+		}
+	}
+	return len(cmds) >= 2 && cmds[0] == "db" && cmds[1] == "migrate"
+}
+
 // ErrMsg is not actually an error but it is my way of
 // containing an error with a message and an exit code.
 type ErrMsg struct {
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -9,14 +10,17 @@ import (
 	fp "path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/harrybrwn/apizza/cmd/cli"
-	"github.com/harrybrwn/apizza/cmd/commands"
 	"github.com/harrybrwn/apizza/cmd/internal"
 	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/pkg/cache"
 	"github.com/harrybrwn/apizza/pkg/config"
 	"github.com/harrybrwn/apizza/pkg/errs"
 	"github.com/harrybrwn/apizza/pkg/tests"
+	"github.com/spf13/cobra"
 )
 
 func TestRunner(t *testing.T) {
@@ -88,7 +92,24 @@ func TestAppResetFlag(t *testing.T) {
 	r.ClearBuf()
 }
 
+func TestAppWarnConfig(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	a := CreateApp(r.ToApp())
+	tests.Check(r.ConfigSetup([]byte(cmdtest.TestConfigjson)))
+	r.Conf.Email = "not an email"
+
+	stderr := new(bytes.Buffer)
+	a.Cmd().SetErr(stderr)
+	a.warnConfig(a.Cmd())
+	if !strings.Contains(stderr.String(), "Warning: email: 'not an email' is not a valid email address") {
+		t.Errorf("warnings should go to the command's stderr, got %q", stderr.String())
+	}
+}
+
 func TestAppStoreFinder(t *testing.T) {
+	defer cmdtest.UseCassette(t, "app-store-finder.json")()
 	r := cmdtest.NewRecorder()
 	defer r.CleanUp()
 	a := CreateApp(r.ToApp())
@@ -127,7 +148,9 @@ func check(e error, msg string) {
 
 func TestExecute(t *testing.T) {
 	tests.InitHelpers(t)
-	commands.Color = false
+	defer cmdtest.UseCassette(t, "execute.json")()
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
 	var (
 		exp    string
 		err    error
@@ -237,3 +260,68 @@ func TestYesOrNo(t *testing.T) {
 	}
 	tests.Check(f.Close())
 }
+
+func TestIsMigrateCmd(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		exp  bool
+	}{
+		{[]string{"db", "migrate"}, true},
+		{[]string{"db", "migrate", "--dry-run"}, true},
+		{[]string{"--offline", "db", "migrate"}, true},
+		{[]string{"--address", "work", "db", "migrate", "--dry-run"}, true},
+		{[]string{"--service=Carryout", "db", "migrate"}, true},
+		{[]string{"-A", "work", "db", "migrate"}, true},
+		{[]string{"-Awork", "db", "migrate"}, true},
+		{[]string{"--address", "db", "migrate"}, false},
+		{[]string{"db"}, false},
+		{[]string{"menu", "db", "migrate"}, false},
+		{[]string{}, false},
+	} {
+		if isMigrateCmd(tc.args) != tc.exp {
+			t.Errorf("isMigrateCmd(%v) should be %v", tc.args, tc.exp)
+		}
+	}
+}
+
+func TestLazyDatabase(t *testing.T) {
+	tests.InitHelpers(t)
+	file := tests.TempFile()
+	db, err := cache.GetDB(file)
+	tests.Fatal(err)
+	tests.Check(db.Put(data.OrderPrefix+"testorder", []byte("{}")))
+	tests.Check(db.Close())
+	defer os.Remove(file)
+
+	opts := &cache.Options{Timeout: 50 * time.Millisecond}
+	app := CreateApp(cache.Lazy(file, opts), &cli.Config{}, nil)
+	defer func() { tests.Check(app.DB().Close()) }()
+	buf := &bytes.Buffer{}
+	cmd := app.Cmd()
+	cmd.SetOut(buf)
+	cmd.AddCommand(AllCommands(app)...)
+
+	cmd.SetArgs([]string{"completion", "bash"})
+	tests.Check(cmd.Execute())
+	// the database is not locked if completion did not open it
+	writer, err := cache.Open(file, &cache.Options{Timeout: opts.Timeout})
+	if errors.Is(err, cache.ErrLocked) {
+		t.Fatal("completion should not open the database")
+	}
+	tests.Fatal(err)
+	tests.Check(writer.Close())
+
+	// another process reading the database at the same time
+	other, err := cache.Open(file, &cache.Options{Timeout: opts.Timeout, ReadOnly: true})
+	tests.Fatal(err)
+	defer other.Close()
+	buf.Reset()
+	cmd.SetArgs([]string{cobra.ShellCompNoDescRequestCmd, "cart", ""})
+	tests.Check(cmd.Execute())
+	if !strings.Contains(buf.String(), "testorder") {
+		t.Errorf("expected order names in completion, got %q", buf.String())
+	}
+	if !app.DB().ReadOnly() {
+		t.Error("completions should open the database in read-only mode")
+	}
+}
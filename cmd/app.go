@@ -13,6 +13,7 @@ import (
 	"github.com/harrybrwn/apizza/cmd/internal"
 	"github.com/harrybrwn/apizza/cmd/internal/data"
 	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
 	"github.com/harrybrwn/apizza/cmd/opts"
 	"github.com/harrybrwn/apizza/dawg"
 	"github.com/harrybrwn/apizza/pkg/cache"
@@ -26,7 +27,7 @@ type App struct {
 	cli.CliCommand     // this is also the root command
 	client.StoreFinder // for app.store()
 
-	db   *cache.DataBase
+	db   cache.Backend
 	conf *cli.Config
 	addr *obj.Address
 	logf *os.File
@@ -36,8 +37,26 @@ type App struct {
 
 	// root specific options
 	opts opts.ApizzaFlags
+
+	// skipMigrations is set when the database should be opened without being
+	// migrated so that the 'db migrate' command can show what will change.
+	skipMigrations bool
+
+	// shell is set while 'apizza shell' is running.
+	shell bool
 }
 
+const (
+	// SystemConfigFile is the config file shared by every user. It is loaded
+	// before the user's config file.
+	SystemConfigFile = "/etc/apizza/config.yml"
+
+	// ProjectConfigFile is the name of a config file that is loaded from the
+	// current directory or one of its parents. It overrides the user's config
+	// file.
+	ProjectConfigFile = ".apizza.yml"
+)
+
 // NewApp creates a new app for the main cli.
 func NewApp(out io.Writer) *App {
 	app := &App{
@@ -47,7 +66,7 @@ func NewApp(out io.Writer) *App {
 		opts:  opts.ApizzaFlags{},
 	}
 	app.CliCommand = cli.NewCommand("apizza", "Dominos pizza from the command line.", app.Run)
-	app.StoreFinder = client.NewStoreGetterFunc(app.getService, app.Address)
+	app.StoreFinder = client.NewStoreGetterFunc(app.getService, app.Address, app)
 	cmd := app.Cmd()
 	cmd.PersistentPreRunE = app.prerun
 	cmd.PostRunE = app.postrun
@@ -56,7 +75,7 @@ func NewApp(out io.Writer) *App {
 }
 
 // CreateApp from a pre-created database and config.
-func CreateApp(db *cache.DataBase, conf *cli.Config, out io.Writer) *App {
+func CreateApp(db cache.Backend, conf *cli.Config, out io.Writer) *App {
 	app := NewApp(out)
 	app.db = db
 	app.conf = conf
@@ -72,25 +91,32 @@ func (a *App) Init(dir string) error {
 	return errs.Pair(a.SetConfig(dir), a.InitDB())
 }
 
-// SetConfig for the the app
+// SetConfig for the the app. Values in the config file can be overridden by
+// a system config file, a project config file, and environment variables.
 func (a *App) SetConfig(dir string) error {
+	config.EnvPrefix = "APIZZA"
+	config.SystemFile = SystemConfigFile
+	config.ProjectFileName = ProjectConfigFile
 	return config.SetConfig(dir, a.conf)
 }
 
-// InitDB for the app.
+// InitDB for the app. The storage backend is chosen in the config file and
+// the default database is not opened until a command uses it.
 func (a *App) InitDB() (err error) {
-	a.db, err = data.OpenDatabase()
-	return
+	a.db, err = data.NewDatabase(a.conf.Storage.Backend, a.conf.Storage.Path, !a.skipMigrations)
+	return err
 }
 
 // DB returns the database
-func (a *App) DB() *cache.DataBase {
+func (a *App) DB() cache.Backend {
 	return a.db
 }
 
-// Build builds commands.
+// Build builds commands that write to the app's output.
 func (a *App) Build(use, short string, r cli.Runner) *cli.Command {
-	return cli.NewCommand(use, short, r.Run)
+	c := cli.NewCommand(use, short, r.Run)
+	c.SetOutput(a.Output())
+	return c
 }
 
 // Config returns the config struct.
@@ -154,6 +180,10 @@ func (a *App) Run(cmd *cobra.Command, args []string) (err error) {
 	}
 	if a.opts.StoreLocation {
 		store := a.Store()
+		if a.gOpts.Output.Structured() {
+			pinned, _ := data.PinnedStore(a.db, a.Address())
+			return out.Encode(a.Output(), a.gOpts.Output, out.NewStore(store, store.ID == pinned))
+		}
 		a.Println(store.Address)
 		a.Println("\nStore id:", store.ID)
 		a.Printf("Coordinates: %s, %s\n",
@@ -163,23 +193,18 @@ func (a *App) Run(cmd *cobra.Command, args []string) (err error) {
 		return nil
 	}
 	if a.opts.Dumpdb {
-		data, err := a.db.Map()
-		if err != nil {
-			return err
-		}
 		log.Println("dumping database to stdout")
-		fmt.Print("{")
-		for k, v := range data {
-			fmt.Printf("\"%s\":%v,", k, string(v))
-		}
-		fmt.Print("}")
-		return nil
+		return data.Dump(a.db, a.Output())
 	}
 	return cmd.Usage()
 }
 
 func (a *App) initflags() {
-	cmd := a.Cmd()
+	a.installFlags(a.Cmd())
+}
+
+// installFlags adds the root command's flags to a command.
+func (a *App) installFlags(cmd *cobra.Command) {
 	flags := cmd.Flags()
 	persistflags := cmd.PersistentFlags()
 
@@ -190,9 +215,18 @@ func (a *App) initflags() {
 	persistflags.MarkHidden("test")
 }
 
-func (a *App) prerun(*cobra.Command, []string) (err error) {
+func (a *App) prerun(cmd *cobra.Command, args []string) (err error) {
+	if cli.IsReadOnly(cmd) && !a.shell {
+		// a database that is already open will keep the mode it was opened with
+		a.db.SetReadOnly(true)
+	}
+	a.warnConfig(cmd)
+	if err = a.useProfile(); err != nil {
+		return err
+	}
+	a.setColor()
 	if a.gOpts.ResetMenu {
-		err = a.DB().Delete("menu")
+		err = data.DeleteMenus(a.DB())
 	}
 	var e error
 	if a.gOpts.Address != "" {
@@ -243,6 +277,78 @@ func (a *App) getDBAddress(key string) (*obj.Address, error) {
 	return obj.FromGob(raw)
 }
 
+// warnConfig prints the problems found in the config. Missing values are only
+// reported by 'apizza config validate' and the config command does not print
+// warnings because it is used to fix them. The shell only prints the warnings
+// when it starts.
+func (a *App) warnConfig(cmd *cobra.Command) {
+	if a.shell {
+		return
+	}
+	switch cmd.Name() {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "config" {
+			return
+		}
+	}
+	issues, err := config.Validate()
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Warning: could not check the config: %v\n", err)
+	}
+	for _, issue := range issues {
+		if !issue.Missing {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", issue)
+		}
+	}
+}
+
+// setColor turns color on or off using the --color flag and sets the theme
+// from the colors in the config. Colors that cannot be parsed are reported
+// and the default color is used instead.
+func (a *App) setColor() {
+	stdout, _ := a.Output().(*os.File)
+	out.SetColor(out.ColorEnabled(a.gOpts.Color, stdout))
+
+	theme := out.DefaultTheme
+	for _, c := range []struct {
+		key   string
+		name  string
+		color *string
+	}{
+		{"heading", a.conf.Colors.Heading, &theme.Heading},
+		{"key", a.conf.Colors.Key, &theme.Key},
+		{"name", a.conf.Colors.Name, &theme.Name},
+		{"price", a.conf.Colors.Price, &theme.Price},
+	} {
+		if c.name == "" {
+			continue
+		}
+		color, err := out.ParseColor(c.name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: colors.%s: %v\n", c.key, err)
+			continue
+		}
+		*c.color = color
+	}
+	out.SetTheme(theme)
+}
+
+// useProfile will use the profile from the --profile flag or the profile
+// set in the config.
+func (a *App) useProfile() error {
+	name := a.gOpts.Profile
+	if name == "" {
+		name = a.conf.Profile
+	}
+	if name == "" {
+		return nil
+	}
+	return a.conf.UseProfile(name)
+}
+
 func (a *App) postrun(*cobra.Command, []string) (err error) {
 	if a.logf != nil {
 		return a.logf.Close()
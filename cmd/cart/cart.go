@@ -28,7 +28,7 @@ func New(b cartBuilder) *Cart {
 			return opts.Service
 		}
 		return b.Config().Service
-	}, b.Address)
+	}, b.Address, b)
 
 	return &Cart{
 		db:     b.DB(),
@@ -37,7 +37,7 @@ func New(b cartBuilder) *Cart {
 		MenuCacher: data.NewMenuCacher(
 			opts.MenuUpdateTime,
 			b.DB(),
-			storefinder.Store,
+			storefinder.FindStore,
 		),
 	}
 }
@@ -60,7 +60,7 @@ var (
 	DefaultOutput io.Writer = os.Stdout
 )
 
-// Cart is an abstraction on the cache.DataBase struct
+// Cart is an abstraction on the database
 // representing the user's cart for persistant orders
 type Cart struct {
 	data.MenuCacher
@@ -68,7 +68,7 @@ type Cart struct {
 	// Most functions that the cart has will fail if this is nil.
 	CurrentOrder *dawg.Order
 
-	db     *cache.DataBase
+	db     cache.Backend
 	finder client.StoreFinder
 	out    io.Writer
 }
@@ -166,16 +166,23 @@ ValidOrder:
 }
 
 // PrintCurrentOrder will print out the current order.
-func (c *Cart) PrintCurrentOrder(full, color, price bool) error {
+func (c *Cart) PrintCurrentOrder(full, price bool) error {
 	out.SetOutput(c.out)
-	return out.PrintOrder(c.CurrentOrder, full, color, price)
+	return out.PrintOrder(c.CurrentOrder, full, price)
+}
+
+// PrintPricedOrder will print out the current order with a price that has
+// already been checked.
+func (c *Cart) PrintPricedOrder(full bool, price float64) error {
+	out.SetOutput(c.out)
+	return out.PrintPricedOrder(c.CurrentOrder, full, price)
 }
 
 // UpdateAddressAndOrderID will update the current order's address and then update
-// the current order's StoreID by finding the nearest store for that address.
+// the current order's StoreID by finding the pinned or nearest store for that address.
 func (c *Cart) UpdateAddressAndOrderID(currentAddr dawg.Address) error {
 	c.CurrentOrder.Address = dawg.StreetAddrFromAddress(currentAddr)
-	s, err := dawg.NearestStore(currentAddr, c.CurrentOrder.ServiceMethod)
+	s, err := client.LocateStore(c.db, currentAddr, c.CurrentOrder.ServiceMethod)
 	if err != nil {
 		return err
 	}
@@ -209,15 +216,20 @@ func (c *Cart) AddProducts(products []string) error {
 	if c.CurrentOrder == nil {
 		return ErrNoCurrentOrder
 	}
-	if err := c.db.UpdateTS("menu", c); err != nil {
+	if err := c.UpdateMenu(); err != nil {
 		return err
 	}
 	return addProducts(c.CurrentOrder, c.Menu(), products)
 }
 
+// Orders returns all the orders saved in the database.
+func (c *Cart) Orders() ([]*dawg.Order, error) {
+	return data.Orders(c.db)
+}
+
 // PrintOrders will print out all the orders saved in the database
-func (c *Cart) PrintOrders(verbose bool, color string) error {
-	return data.PrintOrders(c.db, c.out, verbose, color)
+func (c *Cart) PrintOrders(verbose bool) error {
+	return data.PrintOrders(c.db, c.out, verbose)
 }
 
 func addToppingsToOrder(o *dawg.Order, product string, toppings []string) (err error) {
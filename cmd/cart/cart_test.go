@@ -23,6 +23,7 @@ var testProduct = &dawg.OrderProduct{ItemCommon: dawg.ItemCommon{
 func TestToppings(t *testing.T) {
 	r, cart, order := setup(t)
 	defer r.CleanUp()
+	defer cmdtest.UseCassette(t, "cart-toppings.json")()
 
 	tests.Exp(internal.AddTopping("", testProduct))
 	order.Products = []*dawg.OrderProduct{testProduct}
@@ -106,6 +107,7 @@ func TestToppings(t *testing.T) {
 func TestValidate_Err(t *testing.T) {
 	r, cart, o := setup(t)
 	defer r.CleanUp()
+	defer cmdtest.UseCassette(t, "cart-validate-errors.json")()
 
 	o.Address = &dawg.StreetAddr{}
 	b, err := json.Marshal(o)
@@ -144,6 +146,7 @@ func TestValidate_Err(t *testing.T) {
 func TestProducts(t *testing.T) {
 	r, cart, order := setup(t)
 	defer r.CleanUp()
+	defer cmdtest.UseCassette(t, "cart-products.json")()
 
 	order.Products = []*dawg.OrderProduct{}
 	b, err := json.Marshal(order)
@@ -166,12 +169,13 @@ func TestProducts(t *testing.T) {
 	for i, c := range codes {
 		tests.StrEq(o.Products[i].Code, c, "stored wrong code")
 	}
-	tests.Check(cart.PrintOrders(false, ""))
+	tests.Check(cart.PrintOrders(false))
 }
 
 func TestHelpers_Err(t *testing.T) {
 	r, cart, o := setup(t)
 	defer r.CleanUp()
+	defer cmdtest.UseCassette(t, "cart-helpers-errors.json")()
 	m, err := cart.finder.Store().Menu()
 	tests.Check(err)
 	if m == nil {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/harrybrwn/apizza/cmd/opts"
 	"github.com/harrybrwn/apizza/pkg/config"
 	"github.com/harrybrwn/apizza/pkg/errs"
 	"github.com/harrybrwn/apizza/pkg/tests"
@@ -73,6 +74,20 @@ func TestRunFunction(t *testing.T) {
 	}
 }
 
+func TestReadOnly(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	if IsReadOnly(cmd) {
+		t.Error("commands should not be read-only by default")
+	}
+	ReadOnly(cmd)
+	if !IsReadOnly(cmd) {
+		t.Error("command should be read-only")
+	}
+	if !IsReadOnly(&cobra.Command{Use: cobra.ShellCompRequestCmd}) {
+		t.Error("completion requests should be read-only")
+	}
+}
+
 func testCmd(t *testing.T, buf *bytes.Buffer, cmds ...CliCommand) {
 	c := cmds[0]
 	if c == nil {
@@ -120,3 +135,22 @@ func TestConfigStruct(t *testing.T) {
 		t.Error("expected error")
 	}
 }
+
+type testState struct {
+	conf  *Config
+	gOpts *opts.CliFlags
+}
+
+func (s *testState) Config() *Config               { return s.conf }
+func (s *testState) GlobalOptions() *opts.CliFlags { return s.gOpts }
+
+func TestService(t *testing.T) {
+	s := &testState{conf: &Config{Service: "Delivery"}, gOpts: &opts.CliFlags{}}
+	if Service(s) != "Delivery" {
+		t.Error("should use the config's service without a flag")
+	}
+	s.gOpts.Service = "Carryout"
+	if Service(s) != "Carryout" {
+		t.Error("the --service flag should override the config")
+	}
+}
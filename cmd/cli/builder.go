@@ -24,7 +24,7 @@ type CommandBuilder interface {
 
 // DBBuilder is a cli builder that can give away a database.
 type DBBuilder interface {
-	DB() *cache.DataBase
+	DB() cache.Backend
 }
 
 // ConfigBuilder is a cli builder that can give away a config struct.
@@ -46,6 +46,15 @@ type StateBuilder interface {
 	GlobalOptions() *opts.CliFlags
 }
 
+// Service returns the service method from the --service flag or the config
+// file if the flag was not given.
+func Service(b StateBuilder) string {
+	if service := b.GlobalOptions().Service; service != "" {
+		return service
+	}
+	return b.Config().Service
+}
+
 // AddrDBBuilder is an anddress-builder and a db-builder.
 type AddrDBBuilder interface {
 	CommandBuilder
@@ -106,6 +106,29 @@ func (c *Command) Println(a ...interface{}) {
 	fmt.Fprintln(c.output, a...)
 }
 
+// readOnlyAnnotation is the cobra annotation for commands that never write to
+// the database.
+const readOnlyAnnotation = "apizza_read_only"
+
+// ReadOnly marks a command as one that only reads from the database so that
+// the database can be opened in read-only mode while it runs.
+func ReadOnly(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[readOnlyAnnotation] = "true"
+}
+
+// IsReadOnly returns true if a command only reads from the database. Shell
+// completion requests are always read-only.
+func IsReadOnly(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return cmd.Annotations[readOnlyAnnotation] == "true"
+}
+
 // WithCmds returns a general test given a more specific test function.
 //
 // This wrapper function is meant for testing only.
@@ -2,24 +2,124 @@ package cli
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/internal/webhook"
 	"github.com/harrybrwn/apizza/dawg"
 	"github.com/harrybrwn/apizza/pkg/config"
 )
 
 // Config is the configuration struct
 type Config struct {
-	Name               string      `config:"name" json:"name"`
-	Email              string      `config:"email" json:"email"`
-	Phone              string      `config:"phone" json:"phone"`
-	Address            obj.Address `config:"address" json:"address" yaml:"address,omitempty"`
+	Name               string      `config:"name" json:"name" validate:"required"`
+	Email              string      `config:"email" json:"email" validate:"required,email"`
+	Phone              string      `config:"phone" json:"phone" validate:"required,phone"`
+	Address            obj.Address `config:"address" json:"address" yaml:"address,omitempty" validate:"complete"`
 	DefaultAddressName string      `config:"default-address-name" json:"default-address-name" yaml:"default-address-name"`
 	Card               struct {
 		Number     string `config:"number" json:"number"`
-		Expiration string `config:"expiration" json:"expiration"`
+		Expiration string `config:"expiration" json:"expiration" validate:"expiration"`
 	} `config:"card" json:"card"`
-	Service string `config:"service" default:"Delivery" json:"service"`
+	Service string `config:"service" default:"Delivery" json:"service" validate:"required,oneof=Delivery|Carryout"`
+
+	// Storage sets where apizza keeps its database. The backend can be "bolt"
+	// (the default), "memory" for a database that is thrown away when apizza
+	// exits, or "dir" to store every key in its own file.
+	Storage struct {
+		Backend string `config:"backend" json:"backend" validate:"oneof=bolt|memory|dir"`
+		Path    string `config:"path" json:"path"`
+	} `config:"storage" json:"storage"`
+
+	// Colors sets the colors of the output. A color can be a name like "blue"
+	// or "bold red", the parameters of an escape code like "01;34", or "none".
+	// Colors that are not set use the default theme.
+	Colors struct {
+		Heading string `config:"heading" json:"heading"`
+		Key     string `config:"key" json:"key"`
+		Name    string `config:"name" json:"name"`
+		Price   string `config:"price" json:"price"`
+	} `config:"colors" json:"colors"`
+
+	// Webhooks are named urls that order events are sent to.
+	Webhooks map[string]*webhook.Hook `config:"webhooks" json:"webhooks" yaml:"webhooks,omitempty"`
+
+	// Profile is the name of the profile being used.
+	Profile  string              `config:"profile" json:"profile"`
+	Profiles map[string]*Profile `config:"profiles" json:"profiles" yaml:"profiles,omitempty"`
+}
+
+// Profile is a named set of config values that are used instead of the
+// base config values. Fields that are not set in a profile fall back to the
+// base values.
+type Profile struct {
+	Name               string `config:"name" json:"name,omitempty" yaml:"name,omitempty"`
+	Email              string `config:"email" json:"email,omitempty" yaml:"email,omitempty" validate:"email"`
+	Phone              string `config:"phone" json:"phone,omitempty" yaml:"phone,omitempty" validate:"phone"`
+	DefaultAddressName string `config:"default-address-name" json:"default-address-name,omitempty" yaml:"default-address-name,omitempty"`
+	Card               struct {
+		Number     string `config:"number" json:"number,omitempty" yaml:"number,omitempty"`
+		Expiration string `config:"expiration" json:"expiration,omitempty" yaml:"expiration,omitempty" validate:"expiration"`
+	} `config:"card" json:"card" yaml:"card,omitempty"`
+	Service string `config:"service" json:"service,omitempty" yaml:"service,omitempty" validate:"oneof=Delivery|Carryout"`
+}
+
+// Get a profile variable
+func (p *Profile) Get(key string) interface{} {
+	return config.GetField(p, key)
+}
+
+// Set a profile variable
+func (p *Profile) Set(key string, val interface{}) error {
+	if config.FieldName(p, key) == "Service" {
+		if val != dawg.Delivery && val != dawg.Carryout {
+			return errors.New("service must be either 'Delivery' or 'Carryout'")
+		}
+	}
+	return config.SetField(p, key, val)
+}
+
+// values returns the config keys and values that are set in the profile.
+func (p *Profile) values() map[string]string {
+	all := map[string]string{
+		"name":                 p.Name,
+		"email":                p.Email,
+		"phone":                p.Phone,
+		"default-address-name": p.DefaultAddressName,
+		"card.number":          p.Card.Number,
+		"card.expiration":      p.Card.Expiration,
+		"service":              p.Service,
+	}
+	for k, v := range all {
+		if v == "" {
+			delete(all, k)
+		}
+	}
+	return all
+}
+
+// UseProfile will replace the base config values with the values that are set
+// in a profile. The base values are still the ones saved to the config file
+// and values set with environment variables are not replaced.
+func (c *Config) UseProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("no profile named '%s'", name)
+	}
+	for key, val := range p.values() {
+		var err error
+		if config.Object() != c {
+			// not the config being saved so there is nothing to protect
+			err = config.SetField(c, key, val)
+		} else if !strings.HasPrefix(config.Origin(key), "env:") {
+			err = config.Override(key, val, "profile:"+name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Get a config variable
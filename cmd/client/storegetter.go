@@ -1,10 +1,16 @@
 package client
 
 import (
+	"log"
+	"strings"
+
 	"github.com/harrybrwn/apizza/cmd/cli"
 	"github.com/harrybrwn/apizza/cmd/internal"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
 	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/opts"
 	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/cache"
 	"github.com/harrybrwn/apizza/pkg/errs"
 )
 
@@ -14,6 +20,10 @@ type StoreFinder interface {
 	// Store will return a dominos store
 	Store() *dawg.Store
 
+	// FindStore is the same as Store but it returns an error instead
+	// of exiting the program.
+	FindStore() (*dawg.Store, error)
+
 	// Address() will return the address of the delivery location NOT the store address.
 	cli.AddressBuilder
 }
@@ -23,44 +33,91 @@ type StoreFinder interface {
 type storegetter struct {
 	getaddr   func() dawg.Address
 	getmethod func() string
+	dbuilder  cli.DBBuilder
 	dstore    *dawg.Store
+	// key is the address and service that dstore was found for.
+	key string
 }
 
 // NewStoreGetter will create a new storefinder.
 func NewStoreGetter(builder cli.Builder) StoreFinder {
 	return &storegetter{
 		getmethod: func() string {
-			return builder.Config().Service
+			return cli.Service(builder)
 		},
-		getaddr: builder.Address,
-		dstore:  nil,
+		getaddr:  builder.Address,
+		dbuilder: builder,
+		dstore:   nil,
 	}
 }
 
-// NewStoreGetterFunc creates a new store getter from two funcs
-func NewStoreGetterFunc(service func() string, addr func() dawg.Address) StoreFinder {
+// NewStoreGetterFunc creates a new store getter from two funcs. The DBBuilder
+// is used to look up pinned stores and can be nil.
+func NewStoreGetterFunc(service func() string, addr func() dawg.Address, db cli.DBBuilder) StoreFinder {
 	return &storegetter{
 		getmethod: service,
 		getaddr:   addr,
+		dbuilder:  db,
 		dstore:    nil,
 	}
 }
 
 func (s *storegetter) Store() *dawg.Store {
-	if s.dstore == nil {
-		var err error
-		var address = s.getaddr()
-		if obj.AddrIsEmpty(address) {
-			errs.StopNow(errs.New(internal.ErrNoAddress), "Error", 1)
-		}
-		s.dstore, err = dawg.NearestStore(address, s.getmethod())
+	store, err := s.FindStore()
+	if err != nil {
+		errs.StopNow(err, "Store Find Error", 1) // will exit
+	}
+	return store
+}
+
+// FindStore finds the store for the current address and service. The store
+// is kept until the address or service changes.
+func (s *storegetter) FindStore() (*dawg.Store, error) {
+	var address = s.getaddr()
+	if obj.AddrIsEmpty(address) {
+		return nil, internal.ErrNoAddress
+	}
+	method := s.getmethod()
+	key := strings.Join([]string{
+		address.LineOne(), address.City(), address.StateCode(), address.Zip(), method,
+	}, "|")
+	if s.dstore == nil || s.key != key {
+		store, err := LocateStore(s.db(), address, method)
 		if err != nil {
-			errs.StopNow(err, "Store Find Error", 1) // will exit
+			return nil, err
 		}
+		s.dstore, s.key = store, key
 	}
-	return s.dstore
+	return s.dstore, nil
 }
 
 func (s *storegetter) Address() dawg.Address {
 	return s.getaddr()
 }
+
+func (s *storegetter) db() cache.Backend {
+	if s.dbuilder == nil {
+		return nil
+	}
+	return s.dbuilder.DB()
+}
+
+// LocateStore will find the store for an address. If the user has pinned a
+// store to the address then that store is used, otherwise the nearest store
+// is used. Stores are cached in the database which can be nil.
+func LocateStore(db cache.Backend, addr dawg.Address, service string) (*dawg.Store, error) {
+	if db == nil {
+		return dawg.NearestStore(addr, service)
+	}
+	id, err := data.PinnedStore(db, addr)
+	if err != nil {
+		log.Println("could not read pinned store:", err)
+	}
+	stores := data.NewStoreCache(db, opts.StoreUpdateTime, opts.StoreStatusUpdateTime)
+	return stores.Store(addr, service, id, func() (*dawg.Store, error) {
+		if id != "" {
+			return dawg.NewStore(id, service, addr)
+		}
+		return dawg.NearestStore(addr, service)
+	})
+}
@@ -4,10 +4,13 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/harrybrwn/apizza/cmd/cli"
 	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/cmd/opts"
 	"github.com/harrybrwn/apizza/pkg/cache"
 	"github.com/spf13/cobra"
 )
@@ -15,9 +18,10 @@ import (
 // NewAddAddressCmd creates the 'add-address' command.
 func NewAddAddressCmd(b cli.Builder, in io.Reader) cli.CliCommand {
 	c := &addAddressCmd{
-		db:  b.DB(),
-		in:  in,
-		new: false,
+		db:    b.DB(),
+		gOpts: b.GlobalOptions(),
+		in:    in,
+		new:   false,
 	}
 	c.CliCommand = b.Build("address", "Add a new named address to the internal storage.", c)
 	cmd := c.Cmd()
@@ -33,7 +37,8 @@ addresses as the program default set the appropriate config file option (default
 type addAddressCmd struct {
 	cli.CliCommand
 
-	db     *cache.DataBase
+	db     cache.Backend
+	gOpts  *opts.CliFlags
 	in     io.Reader
 	new    bool
 	delete string
@@ -52,6 +57,9 @@ func (a *addAddressCmd) Run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if a.gOpts.Output.Structured() {
+		return a.encodeAddresses(m)
+	}
 
 	if len(m) == 0 {
 		a.Println("No addresses stored (see '--new' flag)")
@@ -70,6 +78,25 @@ func (a *addAddressCmd) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// encodeAddresses writes the addresses sorted by name using a structured output
+// format.
+func (a *addAddressCmd) encodeAddresses(m map[string][]byte) error {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]*out.Address, 0, len(names))
+	for _, name := range names {
+		addr, err := obj.FromGob(m[name])
+		if err != nil {
+			return err
+		}
+		list = append(list, out.NewAddress(name, addr))
+	}
+	return out.Encode(a.Output(), a.gOpts.Output, list)
+}
+
 type reader struct {
 	scanner *bufio.Reader
 }
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/harrybrwn/apizza/cmd/cart"
 	"github.com/harrybrwn/apizza/cmd/cli"
@@ -14,6 +16,9 @@ import (
 	"github.com/harrybrwn/apizza/cmd/internal"
 	"github.com/harrybrwn/apizza/cmd/internal/data"
 	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/cmd/internal/webhook"
+	"github.com/harrybrwn/apizza/cmd/opts"
 	"github.com/harrybrwn/apizza/dawg"
 	"github.com/harrybrwn/apizza/pkg/cache"
 	"github.com/harrybrwn/apizza/pkg/config"
@@ -28,8 +33,9 @@ func NewCartCmd(b cli.Builder) cli.CliCommand {
 		delete:     false,
 		verbose:    false,
 		topping:    false,
-		color:      Color,
 		getaddress: b.Address,
+		gOpts:      b.GlobalOptions(),
+		conf:       b.Config(),
 	}
 
 	c.CliCommand = b.Build("cart <order name>", "Manage user created orders", c)
@@ -55,6 +61,7 @@ created orders.`
 	c.Flags().StringVarP(&c.product, "product", "p", "", "Give the product that will be effected by --add or --remove")
 
 	c.Flags().BoolVarP(&c.verbose, "verbose", "v", c.verbose, "Print cart verbosely")
+	c.Flags().StringVar(&c.format, "format", "", "format each order with a go template or a named template")
 
 	c.Addcmd(newAddOrderCmd(b))
 	return c
@@ -69,24 +76,30 @@ type cartCmd struct {
 	price    bool
 	delete   bool
 	verbose  bool
-	color    bool
 
 	add     []string
 	remove  string // yes, you can only remove one thing at a time
 	product string
+	format  string
 
 	topping    bool // not actually a flag anymore
 	getaddress func() dawg.Address
+	gOpts      *opts.CliFlags
+	conf       *cli.Config
 }
 
 func (c *cartCmd) Run(cmd *cobra.Command, args []string) (err error) {
 	c.cart.SetOutput(c.Output())
+	format := c.gOpts.Output
 	if len(args) < 1 {
-		var colstr string
-		if c.color {
-			colstr = "\033[01;34m"
+		if out.Structured(format, c.format) {
+			orders, err := c.cart.Orders()
+			if err != nil {
+				return err
+			}
+			return writeOrders(c.Output(), format, c.format, orders)
 		}
-		return c.cart.PrintOrders(c.verbose, colstr)
+		return c.cart.PrintOrders(c.verbose)
 	}
 
 	if c.topping && c.product == "" {
@@ -109,15 +122,25 @@ func (c *cartCmd) Run(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	var order *dawg.Order = c.cart.CurrentOrder
+	var (
+		order   *dawg.Order = c.cart.CurrentOrder
+		offline             = c.gOpts.Offline
+		stderr              = cmd.ErrOrStderr()
+	)
 
-	if !order.Address.Equal(c.getaddress()) {
+	if !offline && !order.Address.Equal(c.getaddress()) {
 		if err = c.cart.UpdateAddressAndOrderID(c.getaddress()); err != nil {
-			return err
+			if !internal.IsNetworkErr(err) {
+				return err
+			}
+			offline = c.goOffline(stderr, err)
 		}
 	}
 
 	if c.validate {
+		if offline {
+			return internal.ErrOffline
+		}
 		// validate the current order and stop
 		return c.cart.Validate()
 	}
@@ -150,7 +173,68 @@ func (c *cartCmd) Run(cmd *cobra.Command, args []string) (err error) {
 		// save order and return early before order is printed out
 		return c.cart.SaveAndReset()
 	}
-	return c.cart.PrintCurrentOrder(true, c.color, c.price)
+
+	var (
+		price = c.price
+		p     float64
+	)
+	if price && !offline {
+		if p, err = order.Price(); err != nil && internal.IsNetworkErr(err) {
+			offline = c.goOffline(stderr, err)
+		} else {
+			notify(c.conf, stderr, webhook.OrderEvent(webhook.OrderPriced, order, p, err))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if offline {
+		price = false
+		fmt.Fprintf(stderr, "offline: showing the saved order '%s', the store and price were not checked\n", name)
+	}
+	if out.Structured(format, c.format) {
+		return out.Write(c.Output(), format, c.format, out.NewOrder(order, p, price))
+	}
+	if price {
+		return c.cart.PrintPricedOrder(true, p)
+	}
+	return c.cart.PrintCurrentOrder(true, false)
+}
+
+// writeOrders writes a list of orders using a template or a structured output
+// format.
+func writeOrders(w io.Writer, format opts.Format, template string, orders []*dawg.Order) error {
+	list := make([]*out.Order, len(orders))
+	for i, o := range orders {
+		list[i] = out.NewOrder(o, 0, false)
+	}
+	return out.Write(w, format, template, list)
+}
+
+// notify sends an event to the webhooks in the config file. Webhooks that
+// fail are only a warning.
+func notify(conf *cli.Config, stderr io.Writer, e *webhook.Event) {
+	if err := webhook.New(conf.Webhooks).Notify(e); err != nil {
+		log.Println(err)
+		fmt.Fprintln(stderr, "Warning:", err)
+	}
+}
+
+// recordOrder adds an order that was sent to dominos to the order history.
+// The order is already placed so failing to record it is only a warning.
+func recordOrder(db cache.Backend, stderr io.Writer, o *dawg.Order, price float64, source string, err error) {
+	if _, err = data.RecordOrder(db, o, price, source, err); err != nil {
+		log.Println("could not record order:", err)
+		fmt.Fprintln(stderr, "Warning: could not add the order to the history:", err)
+	}
+}
+
+// goOffline logs a network error and warns the user that cached data is
+// being used. Always returns true.
+func (c *cartCmd) goOffline(stderr io.Writer, err error) bool {
+	log.Println("cart network error:", err)
+	fmt.Fprintln(stderr, "Warning: could not connect to dominos, using cached data")
+	return true
 }
 
 func newAddOrderCmd(b cli.Builder) cli.CliCommand {
@@ -163,6 +247,7 @@ func newAddOrderCmd(b cli.Builder) cli.CliCommand {
 	c.Flags().StringVarP(&c.name, "name", "n", c.name, "set the name of a new order")
 	c.Flags().StringVarP(&c.product, "product", "p", c.product, "product codes for the new order")
 	c.Flags().StringSliceVarP(&c.toppings, "toppings", "t", c.toppings, "toppings for the products being added")
+	c.Flags().BoolVarP(&c.interactive, "interactive", "i", false, "pick products and toppings from the menu step by step")
 	return c
 }
 
@@ -170,17 +255,21 @@ func newAddOrderCmd(b cli.Builder) cli.CliCommand {
 type addOrderCmd struct {
 	cli.CliCommand
 	client.StoreFinder
-	db *cache.DataBase
+	db cache.Backend
 
-	name     string
-	product  string
-	toppings []string
+	name        string
+	product     string
+	toppings    []string
+	interactive bool
 }
 
 func (c *addOrderCmd) Run(cmd *cobra.Command, args []string) (err error) {
 	if c.name == "" && len(args) < 1 {
 		return internal.ErrNoOrderName
 	}
+	if c.interactive && (c.product != "" || len(c.toppings) > 0) {
+		return errors.New("cannot use --product or --toppings with --interactive")
+	}
 	order := c.Store().NewOrder()
 
 	if c.name == "" {
@@ -189,6 +278,10 @@ func (c *addOrderCmd) Run(cmd *cobra.Command, args []string) (err error) {
 		order.SetName(c.name)
 	}
 
+	if c.interactive {
+		return c.runWizard(cmd, order)
+	}
+
 	// User interface options:
 	// - only add one product but a list of toppings
 	// - add a list of products in parallel with a list of toppings (vectorized approach)
@@ -212,12 +305,32 @@ func (c *addOrderCmd) Run(cmd *cobra.Command, args []string) (err error) {
 	return data.SaveOrder(order, &bytes.Buffer{}, c.db)
 }
 
+// runWizard builds the order with the interactive order wizard and saves it.
+func (c *addOrderCmd) runWizard(cmd *cobra.Command, order *dawg.Order) error {
+	menu, err := c.Store().Menu()
+	if err != nil {
+		return err
+	}
+	if err = newOrderWizard(menu, order, cmd.InOrStdin(), c.Output()).Run(); err != nil {
+		return err
+	}
+	if err = data.SaveOrder(order, &bytes.Buffer{}, c.db); err != nil {
+		return err
+	}
+	c.Printf("\nSaved '%s' with %d products. See the final price with 'apizza cart %[1]s --price'.\n",
+		order.Name(), len(order.Products))
+	return nil
+}
+
 // NewOrderCmd creates a new order command.
 func NewOrderCmd(b cli.Builder) cli.CliCommand {
 	c := &orderCmd{
+		gOpts:      b.GlobalOptions(),
 		verbose:    false,
-		color:      Color,
+		trackEvery: 30 * time.Second,
+		trackFor:   2 * time.Hour,
 		getaddress: b.Address,
+		conf:       b.Config(),
 	}
 	c.CliCommand = b.Build("order", "Send an order from the cart to dominos.", c)
 	c.db = b.DB()
@@ -247,6 +360,7 @@ stored the program cache with orders.
 	flags.StringVar(&c.number, "number", "", "the card number used for orderings")
 	flags.StringVar(&c.expiration, "expiration", "", "the card's expiration date")
 
+	flags.BoolVar(&c.track, "track", false, "follow the order in the dominos tracker until it is complete")
 	flags.BoolVarP(&c.yes, "yes", "y", c.yes, "do not prompt the user with a question")
 	flags.BoolVar(&c.logonly, "log-only", false, "")
 	flags.MarkHidden("log-only")
@@ -256,7 +370,7 @@ stored the program cache with orders.
 // `apizza order`
 type orderCmd struct {
 	cli.CliCommand
-	db *cache.DataBase
+	db cache.Backend
 
 	verbose bool
 	track   bool
@@ -267,19 +381,25 @@ type orderCmd struct {
 	number       string
 	expiration   string
 	yes          bool
-	color        bool
 
 	logonly    bool
+	trackEvery time.Duration
+	trackFor   time.Duration
 	getaddress func() dawg.Address
+	gOpts      *opts.CliFlags
+	conf       *cli.Config
 }
 
 func (c *orderCmd) Run(cmd *cobra.Command, args []string) (err error) {
 	if len(args) < 1 {
-		var colorstr string
-		if c.color {
-			colorstr = "\033[01;34m"
+		if c.gOpts.Output.Structured() {
+			orders, err := data.Orders(c.db)
+			if err != nil {
+				return err
+			}
+			return writeOrders(c.Output(), c.gOpts.Output, "", orders)
 		}
-		return data.PrintOrders(c.db, c.Output(), c.verbose, colorstr)
+		return data.PrintOrders(c.db, c.Output(), c.verbose)
 	} else if len(args) > 1 {
 		return errors.New("cannot handle multiple orders")
 	}
@@ -292,34 +412,25 @@ func (c *orderCmd) Run(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
-	num := eitherOr(c.number, config.GetString("card.number"))
-	exp := eitherOr(c.expiration, config.GetString("card.expiration"))
-	if num == "" {
-		return errors.New("no card number given")
-	}
-	if exp == "" {
-		return errors.New("no card expiration date given")
-	}
-
-	card := dawg.NewCard(num, exp, c.cvv)
-	if err = dawg.ValidateCard(card); err != nil {
+	err = payment{
+		number:     c.number,
+		expiration: c.expiration,
+		cvv:        c.cvv,
+		fname:      c.fname,
+		lname:      c.lname,
+		email:      c.email,
+		phone:      c.phone,
+	}.addTo(order)
+	if err != nil {
 		return err
 	}
-	order.AddCard(card)
-
-	names := strings.Split(config.GetString("name"), " ")
-	if len(names) >= 1 {
-		order.FirstName = eitherOr(c.fname, names[0])
+	if c.track && order.Phone == "" {
+		return errors.New("need a phone number to track the order (see --phone)")
 	}
-	if len(names) >= 2 {
-		order.LastName = eitherOr(c.lname, strings.Join(names[1:], " "))
-	}
-	order.Email = eitherOr(c.email, config.GetString("email"))
-	order.Phone = eitherOr(c.phone, config.GetString("phone"))
 
 	if !order.Address.Equal(c.getaddress()) {
 		order.Address = dawg.StreetAddrFromAddress(c.getaddress())
-		s, err := dawg.NearestStore(c.getaddress(), order.ServiceMethod)
+		s, err := client.LocateStore(c.db, c.getaddress(), order.ServiceMethod)
 		if err != nil {
 			return err
 		}
@@ -343,9 +454,14 @@ func (c *orderCmd) Run(cmd *cobra.Command, args []string) (err error) {
 	err = order.PlaceOrder()
 	// logging happens after so any data from placeorder is included
 	log.Println("sending order:", dawg.OrderToJSON(order))
+	var price float64
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+	} else {
+		price, _ = order.Price() // already priced by PlaceOrder
 	}
+	recordOrder(c.db, os.Stderr, order, price, "order", err)
+	notify(c.conf, os.Stderr, webhook.OrderEvent(webhook.OrderPlaced, order, price, err))
 	c.Printf("sent to %s %s\n", order.Address.LineOne(), order.Address.City())
 
 	if c.verbose {
@@ -357,6 +473,74 @@ func (c *orderCmd) Run(cmd *cobra.Command, args []string) (err error) {
 		}
 		c.Printf("%+v\n", order)
 	}
+	if c.track && err == nil {
+		c.trackOrder(order, price)
+	}
+	return nil
+}
+
+// trackOrder follows a placed order in the dominos order tracker until it is
+// complete. Every new status is printed and sent to the webhooks. The order
+// has already been placed so tracking errors are only logged.
+func (c *orderCmd) trackOrder(o *dawg.Order, price float64) {
+	stderr := c.Cmd().ErrOrStderr()
+	deadline := time.Now().Add(c.trackFor)
+	var last string
+	for {
+		status, err := o.Track()
+		if err == nil && status.Status != last {
+			last = status.Status
+			c.Printf("order status: %s\n", last)
+			notify(c.conf, stderr, webhook.TrackingEvent(o, price, status))
+		}
+		if err == nil && status.Done() {
+			return
+		} else if err != nil && err != dawg.ErrNotTracked {
+			log.Println("order tracker:", err)
+		}
+		if time.Now().After(deadline) {
+			fmt.Fprintf(stderr, "Warning: stopped tracking '%s' after %s\n", o.Name(), c.trackFor)
+			return
+		}
+		time.Sleep(c.trackEvery)
+	}
+}
+
+// payment is the card and contact information sent with an order. Anything
+// that is not set is taken from the config.
+type payment struct {
+	number, expiration string
+	cvv                int
+	fname, lname       string
+	email, phone       string
+}
+
+// addTo adds the card and contact information to an order.
+func (p payment) addTo(order *dawg.Order) error {
+	num := eitherOr(p.number, config.GetString("card.number"))
+	exp := eitherOr(p.expiration, config.GetString("card.expiration"))
+	if num == "" {
+		return errors.New("no card number given")
+	}
+	if exp == "" {
+		return errors.New("no card expiration date given")
+	}
+
+	card := dawg.NewCard(num, exp, p.cvv)
+	if err := dawg.ValidateCard(card); err != nil {
+		return err
+	}
+	order.AddCard(card)
+
+	names := strings.Split(config.GetString("name"), " ")
+	if len(names) >= 1 {
+		order.FirstName = eitherOr(p.fname, names[0])
+	}
+	if len(names) >= 2 {
+		order.LastName = eitherOr(p.lname, strings.Join(names[1:], " "))
+	}
+	order.Email = eitherOr(p.email, config.GetString("email"))
+	order.Phone = eitherOr(p.phone, config.GetString("phone"))
 	return nil
 }
 
@@ -2,21 +2,31 @@ package commands
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/harrybrwn/apizza/cmd/cli"
+	"github.com/harrybrwn/apizza/cmd/internal"
 	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
 	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/cmd/internal/webhook"
+	"github.com/harrybrwn/apizza/cmd/opts"
+	"github.com/harrybrwn/apizza/dawg"
 	"github.com/harrybrwn/apizza/pkg/errs"
 	"github.com/harrybrwn/apizza/pkg/tests"
 )
 
-func init() {
-	Color = false
-}
-
 func addTestOrder(b cli.Builder) {
 	new := newAddOrderCmd(b).Cmd()
 	if err := errs.Pair(
@@ -34,6 +44,7 @@ func newTestCart(b cli.Builder) *cartCmd {
 }
 
 func TestCartCommand(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-cart.json")()
 	b := cmdtest.NewTestRecorder(t)
 	defer b.CleanUp()
 	cart := newTestCart(b)
@@ -66,6 +77,7 @@ func TestCartCommand(t *testing.T) {
 }
 
 func TestCartToppings(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-cart-toppings.json")()
 	b := cmdtest.NewTestRecorder(t)
 	defer b.CleanUp()
 	cart := newTestCart(b)
@@ -74,6 +86,7 @@ func TestCartToppings(t *testing.T) {
 }
 
 func TestCartToppings_Err(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-cart-toppings-errors.json")()
 	b := cmdtest.NewTestRecorder(t)
 	defer b.CleanUp()
 	cart := newTestCart(b)
@@ -115,6 +128,7 @@ func testOrderNew(t *testing.T, buf *bytes.Buffer, cmds ...cli.CliCommand) {
 
 // func testAddOrder(t *testing.T, buf *bytes.Buffer, cmds ...cli.CliCommand) {
 func TestAddOrder(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-add-order.json")()
 	// cart, add := cmds[0], cmds[1]
 
 	b := cmdtest.NewTestRecorder(t)
@@ -139,10 +153,11 @@ func testOrderNewErr(t *testing.T, buf *bytes.Buffer, cmds ...cli.CliCommand) {
 }
 
 func TestOrderRunAdd(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-order-run-add.json")()
 	b := cmdtest.NewTestRecorder(t)
 	defer b.CleanUp()
 	cart := newTestCart(b)
-	cart.color = false
+	out.SetColor(false)
 	tests.Check(cart.Run(cart.Cmd(), []string{}))
 	tests.Compare(t, b.Out.String(), "Your Orders:\n  testorder\n")
 	b.Out.Reset()
@@ -152,6 +167,7 @@ func TestOrderRunAdd(t *testing.T) {
 }
 
 func TestOrderPriceOutput(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-order-price.json")()
 	b := cmdtest.NewTestRecorder(t)
 	defer b.CleanUp()
 	cart := newTestCart(b)
@@ -168,6 +184,7 @@ func TestOrderPriceOutput(t *testing.T) {
 
 // func testOrderRunDelete(cart *cartCmd, buf *bytes.Buffer, t *testing.T) {
 func TestOrderRunDelete(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-order-run-delete.json")()
 	b := cmdtest.NewTestRecorder(t)
 	defer b.CleanUp()
 	cart := newTestCart(b)
@@ -189,6 +206,7 @@ func TestOrderRunDelete(t *testing.T) {
 
 // func testAddToppings(cart *cartCmd, buf *bytes.Buffer, t *testing.T) {
 func TestAddToppings(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-add-toppings.json")()
 	b := cmdtest.NewTestRecorder(t)
 	defer b.CleanUp()
 	cart := newTestCart(b)
@@ -259,6 +277,7 @@ func TestAddToppings(t *testing.T) {
 }
 
 func TestOrder(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-order.json")()
 	r := cmdtest.NewTestRecorder(t)
 	defer r.CleanUp()
 	cmd := NewOrderCmd(r).(*orderCmd)
@@ -282,6 +301,7 @@ func TestOrder(t *testing.T) {
 }
 
 func TestOrder_Err(t *testing.T) {
+	defer cmdtest.UseCassette(t, "commands-order-errors.json")()
 	r := cmdtest.NewTestRecorder(t)
 	defer r.CleanUp()
 	addTestOrder(r)
@@ -315,3 +335,223 @@ func TestEitherOr(t *testing.T) {
 		t.Error("wrong result from 'eitherOr'")
 	}
 }
+
+func TestCartOffline(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	cart := NewCartCmd(r).(*cartCmd)
+	cart.gOpts.Offline = true
+	stderr := new(bytes.Buffer)
+	cart.Cmd().SetErr(stderr)
+
+	o := cmdtest.NewTestOrder()
+	raw, err := json.Marshal(o)
+	tests.Fatal(err)
+	tests.Fatal(r.DB().Put(data.OrderPrefix+"offline", raw))
+
+	tests.Check(cart.Run(cart.Cmd(), []string{}))
+	r.Compare(t, "Your Orders:\n  offline\n")
+	r.ClearBuf()
+
+	cart.price = true
+	tests.Check(cart.Run(cart.Cmd(), []string{"offline"}))
+	if !r.Contains("storeID: 4336") {
+		t.Error("should have printed the cached order")
+	}
+	if r.Contains("price:") {
+		t.Error("should not show a price while offline")
+	}
+	tests.Compare(t, stderr.String(), "offline: showing the saved order 'offline', the store and price were not checked\n")
+
+	cart.validate = true
+	if err = cart.Run(cart.Cmd(), []string{"offline"}); err != internal.ErrOffline {
+		t.Errorf("expected offline error, got %v", err)
+	}
+}
+
+// failedPrice responds to every request like dominos does when it cannot price
+// an order.
+type failedPrice struct{}
+
+func (failedPrice) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := `{"Status":-1,"StatusItems":[{"Code":"Failure"}],"Order":{"Status":-1,"StatusItems":[{"Code":"Failure"},{"Code":"StoreClosed"}]}}`
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestCartPriceErr(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	defer dawg.SetTransport(dawg.SetTransport(failedPrice{}))
+	cart := NewCartCmd(r).(*cartCmd)
+	cart.Cmd().SetErr(new(bytes.Buffer))
+	raw, err := json.Marshal(cmdtest.NewTestOrder())
+	tests.Fatal(err)
+	tests.Fatal(r.DB().Put(data.OrderPrefix+"closed", raw))
+
+	cart.price = true
+	if err = cart.Run(cart.Cmd(), []string{"closed"}); err == nil || internal.IsNetworkErr(err) {
+		t.Errorf("expected the price error, got %v", err)
+	}
+	if r.Out.Len() != 0 {
+		t.Errorf("should not print the order when it cannot be priced:\n%s", r.Out.String())
+	}
+}
+
+// tracker responds like the dominos order tracker with a new status for
+// every request.
+type tracker struct {
+	statuses []string
+	requests int
+}
+
+func (tr *tracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := "[]"
+	if tr.requests < len(tr.statuses) && tr.statuses[tr.requests] != "" {
+		body = fmt.Sprintf(`[{"OrderID":"abc","StoreID":"4336","OrderStatus":%q}]`, tr.statuses[tr.requests])
+	}
+	tr.requests++
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestOrderTrack(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	tr := &tracker{statuses: []string{"", "Prep", "Prep", "Bake", "Complete", "Complete"}}
+	defer dawg.SetTransport(dawg.SetTransport(tr))
+
+	var events []webhook.Event
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var e webhook.Event
+		if err := json.NewDecoder(req.Body).Decode(&e); err != nil {
+			t.Error(err)
+		}
+		events = append(events, e)
+	}))
+	defer hook.Close()
+	r.Conf.Webhooks = map[string]*webhook.Hook{"test": {URL: hook.URL}}
+
+	cmd := NewOrderCmd(r).(*orderCmd)
+	cmd.trackEvery = 0
+	stderr := new(bytes.Buffer)
+	cmd.Cmd().SetErr(stderr)
+	o := cmdtest.NewTestOrder()
+	o.OrderID, o.Phone = "abc", "202-555-1234"
+
+	cmd.trackOrder(o, 20)
+	r.Compare(t, "order status: Prep\norder status: Bake\norder status: Complete\n")
+	if tr.requests != 5 {
+		t.Errorf("should stop tracking when the order is complete, got %d requests", tr.requests)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected an event for every new status, got %d", len(events))
+	}
+	for i, status := range []string{"Prep", "Bake", "Complete"} {
+		if events[i].Type != webhook.OrderTracking || events[i].Status != status {
+			t.Errorf("wrong event: %+v", events[i])
+		}
+	}
+
+	r.Out.Reset()
+	tr.requests, tr.statuses = 0, nil
+	cmd.trackFor = 0
+	cmd.trackOrder(o, 20)
+	if r.Out.Len() != 0 || len(events) != 3 {
+		t.Error("orders that are not in the tracker should not have a status")
+	}
+	if !strings.Contains(stderr.String(), "stopped tracking") {
+		t.Errorf("should warn when it stops tracking, got %q", stderr.String())
+	}
+
+	raw, err := json.Marshal(cmdtest.NewTestOrder())
+	tests.Fatal(err)
+	tests.Fatal(r.DB().Put(data.OrderPrefix+"tracked", raw))
+	tests.Check(cmd.Cmd().ParseFlags([]string{"--track", "--cvv=123", "--number=38790546741937", "--expiration=01/01"}))
+	err = cmd.Run(cmd.Cmd(), []string{"tracked"})
+	if err == nil || !strings.Contains(err.Error(), "phone number") {
+		t.Errorf("should not track an order without a phone number, got %v", err)
+	}
+}
+
+func TestCartOutputFormat(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	cart := NewCartCmd(r).(*cartCmd)
+	cart.gOpts.Offline = true
+	cart.gOpts.Output = opts.JSON
+	cart.Cmd().SetErr(new(bytes.Buffer))
+
+	tests.Check(cart.Run(cart.Cmd(), []string{}))
+	r.Compare(t, "[]\n")
+	r.ClearBuf()
+
+	for _, name := range []string{"b-order", "a-order"} {
+		raw, err := json.Marshal(cmdtest.NewTestOrder())
+		tests.Fatal(err)
+		tests.Fatal(r.DB().Put(data.OrderPrefix+name, raw))
+	}
+	tests.Check(cart.Run(cart.Cmd(), []string{}))
+	var orders []out.Order
+	tests.Fatal(json.Unmarshal(r.Out.Bytes(), &orders))
+	if len(orders) != 2 || orders[0].Name != "a-order" || orders[1].StoreID != "4336" {
+		t.Errorf("wrong orders: %+v", orders)
+	}
+	r.ClearBuf()
+
+	cart.gOpts.Output = opts.YAML
+	cart.price = true
+	tests.Check(cart.Run(cart.Cmd(), []string{"a-order"}))
+	order := &out.Order{}
+	tests.Fatal(yaml.Unmarshal(r.Out.Bytes(), order))
+	if order.Name != "a-order" || order.Service != dawg.Delivery || order.Price != nil {
+		t.Errorf("wrong order: %+v", order)
+	}
+	r.ClearBuf()
+
+	o := NewOrderCmd(r).(*orderCmd)
+	o.gOpts.Output = opts.JSON
+	tests.Check(o.Run(o.Cmd(), []string{}))
+	orders = nil
+	tests.Fatal(json.Unmarshal(r.Out.Bytes(), &orders))
+	if len(orders) != 2 {
+		t.Errorf("expected 2 orders, got %+v", orders)
+	}
+}
+
+func TestCartTemplateFormat(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	cart := NewCartCmd(r).(*cartCmd)
+	cart.gOpts.Offline = true
+	cart.Cmd().SetErr(new(bytes.Buffer))
+	for _, name := range []string{"b-order", "a-order"} {
+		raw, err := json.Marshal(cmdtest.NewTestOrder())
+		tests.Fatal(err)
+		tests.Fatal(r.DB().Put(data.OrderPrefix+name, raw))
+	}
+
+	cart.format = "{{.Name}} {{.StoreID}} {{len .Products}}"
+	tests.Check(cart.Run(cart.Cmd(), []string{}))
+	r.Compare(t, "a-order 4336 0\nb-order 4336 0\n")
+	r.ClearBuf()
+
+	dir := out.TemplateDir()
+	tests.Fatal(os.MkdirAll(dir, 0755))
+	defer os.RemoveAll(dir)
+	tests.Fatal(ioutil.WriteFile(filepath.Join(dir, "short.tmpl"),
+		[]byte("{{pad 8 .Name}}|{{money .Price}}|\n"), 0644))
+	cart.format = "short"
+	cart.price = true
+	tests.Check(cart.Run(cart.Cmd(), []string{"a-order"}))
+	r.Compare(t, "a-order ||\n")
+	r.ClearBuf()
+}
@@ -15,9 +15,11 @@
 package commands
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -30,17 +32,19 @@ import (
 type configCmd struct {
 	cli.CliCommand
 	cli.AddressBuilder
-	db   *cache.DataBase
+	db   cache.Backend
 	conf *cli.Config
+	in   io.Reader
 
-	file bool
-	dir  bool
-	edit bool
+	file       bool
+	dir        bool
+	edit       bool
+	showOrigin bool
 }
 
 func (c *configCmd) Run(cmd *cobra.Command, args []string) error {
 	if c.edit {
-		return config.Edit()
+		return c.editConfig()
 	}
 	if c.file {
 		c.Println(config.File())
@@ -50,6 +54,12 @@ func (c *configCmd) Run(cmd *cobra.Command, args []string) error {
 		c.Println(config.Folder())
 		return nil
 	}
+	if c.showOrigin {
+		for _, o := range config.Origins() {
+			c.Printf("%s\t%s: %v\n", o.Origin, o.Key, o.Value)
+		}
+		return nil
+	}
 	return config.FprintAll(cmd.OutOrStdout(), config.Object())
 }
 
@@ -59,6 +69,7 @@ func NewConfigCmd(b cli.Builder) cli.CliCommand {
 		AddressBuilder: b,
 		db:             b.DB(),
 		conf:           b.Config(),
+		in:             os.Stdin,
 		file:           false,
 		dir:            false,
 	}
@@ -68,16 +79,87 @@ func NewConfigCmd(b cli.Builder) cli.CliCommand {
 	cmd.Aliases = []string{"conf"}
 	cmd.Long = `The 'config' command is used for accessing the apizza config file
 in your home directory. Feel free to edit the apizza config.json file
-by hand or use the 'config' command.`
+by hand or use the 'config' command.
+
+Values are loaded from /etc/apizza/config.yml, then the file in your
+home directory, then a .apizza.yml file in the current directory or one
+of its parents. Any value can also be set with an environment variable,
+for example APIZZA_SERVICE or APIZZA_ADDRESS_STREET.`
 
 	c.Flags().BoolVarP(&c.file, "file", "f", c.file, "show the path to the config.json file")
 	c.Flags().BoolVarP(&c.dir, "dir", "d", c.dir, "show the apizza config directory path")
 	c.Flags().BoolVarP(&c.edit, "edit", "e", false, "open the config file with the text editor set by $EDITOR")
+	c.Flags().BoolVar(&c.showOrigin, "show-origin", false, "show the file or environment variable that each value came from")
 
 	cmd.AddCommand(configSetCmd, configGetCmd)
+	c.Addcmd(newProfileCmd(b), newConfigValidateCmd(b))
+	return c
+}
+
+// editConfig opens the config file in an editor and checks the file once the
+// editor is closed. If the file has problems, the editor can be reopened to fix
+// them.
+func (c *configCmd) editConfig() error {
+	r := reader{bufio.NewReader(c.in)}
+	for {
+		if err := config.Edit(); err != nil {
+			return err
+		}
+		issues, err := config.ValidateFile(config.File())
+		if err != nil {
+			c.Printf("%s: %v\n", config.File(), err)
+		} else if len(issues) == 0 {
+			return nil
+		}
+		for _, issue := range issues {
+			c.Println(issue)
+		}
+		c.Printf("Reopen the editor? [Y/n] ")
+		answer, err := r.readline()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == io.EOF || strings.HasPrefix(strings.ToLower(answer), "n") {
+			return errors.New("the config file has problems, see 'apizza config validate'")
+		}
+	}
+}
+
+func newConfigValidateCmd(b cli.Builder) cli.CliCommand {
+	c := &configValidateCmd{}
+	c.CliCommand = b.Build("validate", "Check the config for problems.", c)
+	c.Cmd().Long = `The validate command checks every config file that was loaded for
+keys that do not exist and values with the wrong type. Then the config
+values are checked for missing fields and values that are not valid, like
+a malformed email or a service that is not Delivery or Carryout.`
+	c.Cmd().Args = cobra.NoArgs
+	cli.ReadOnly(c.Cmd())
 	return c
 }
 
+// `apizza config validate`
+type configValidateCmd struct {
+	cli.CliCommand
+}
+
+func (c *configValidateCmd) Run(cmd *cobra.Command, args []string) error {
+	issues, err := config.Validate()
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		c.Println("The config is valid.")
+		return nil
+	}
+	for _, issue := range issues {
+		c.Println(issue)
+	}
+	if len(issues) == 1 {
+		return errors.New("found 1 problem in the config")
+	}
+	return fmt.Errorf("found %d problems in the config", len(issues))
+}
+
 var configSetCmd = &cobra.Command{
 	Use:   "set",
 	Short: "change variables in the config file",
@@ -3,10 +3,13 @@ package commands
 import (
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/harrybrwn/apizza/cmd/cli"
 	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
 	"github.com/harrybrwn/apizza/cmd/internal/obj"
@@ -41,6 +44,17 @@ card:
   number: ""
   expiration: ""
 service: "Carryout"
+storage:
+  backend: ""
+  path: ""
+colors:
+  heading: ""
+  key: ""
+  name: ""
+  price: ""
+webhooks:
+profile: ""
+profiles:
 `
 
 func TestConfigStruct(t *testing.T) {
@@ -80,6 +94,100 @@ func TestConfigCmd(t *testing.T) {
 	r.ClearBuf()
 }
 
+func TestConfigShowOrigin(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	c := NewConfigCmd(r).(*configCmd)
+	c.showOrigin = true
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	for _, key := range []string{"address.street", "service", "storage.backend"} {
+		if !r.Contains("\t" + key + ": ") {
+			t.Errorf("expected an origin for %s in %q", key, r.Out.String())
+		}
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	conf := r.Config()
+	conf.Email = "me@home.com"
+	tests.Check(config.Save())
+
+	list := newProfileCmd(r)
+	tests.Check(list.Run(list.Cmd(), []string{}))
+	r.Compare(t, "No profiles.\n")
+	r.ClearBuf()
+
+	create := newProfileCreateCmd(r)
+	tests.Check(create.Run(create.Cmd(), []string{"office", "email=me@work.com", "service=Delivery"}))
+	tests.Exp(create.Run(create.Cmd(), []string{"office"}), "profile already exists")
+	tests.Exp(create.Run(create.Cmd(), []string{"bad", "service=Pickup"}), "invalid service")
+	tests.Exp(create.Run(create.Cmd(), []string{"bad", "email"}), "bad format")
+	tests.Check(create.Run(create.Cmd(), []string{"weekend"}))
+
+	use := newProfileUseCmd(r)
+	tests.Exp(use.Run(use.Cmd(), []string{"nothere"}), "profile does not exist")
+	tests.Check(use.Run(use.Cmd(), []string{"office"}))
+	tests.Check(list.Run(list.Cmd(), []string{}))
+	r.Compare(t, "* office\n  weekend\n")
+	r.ClearBuf()
+
+	tests.Check(conf.UseProfile("office"))
+	tests.StrEq(conf.Email, "me@work.com", "profile email should be used")
+	tests.StrEq(conf.Service, "Delivery", "profile service should be used")
+	tests.StrEq(conf.Name, "Apizza TestRecorder", "unset profile fields should use the base config")
+	tests.StrEq(config.Origin("email"), "profile:office", "wrong origin")
+
+	// profile values should never be saved over the base config
+	tests.Check(config.Save())
+	raw, err := ioutil.ReadFile(config.File())
+	tests.Fatal(err)
+	saved := &cli.Config{}
+	tests.Fatal(yaml.Unmarshal(raw, saved))
+	tests.StrEq(saved.Email, "me@home.com", "profile value was saved to the base config")
+	tests.StrEq(saved.Profile, "office", "current profile was not saved")
+	if p := saved.Profiles["office"]; p == nil || p.Email != "me@work.com" {
+		t.Error("profile was not saved")
+	}
+
+	del := newProfileDeleteCmd(r)
+	tests.Exp(del.Run(del.Cmd(), []string{"nothere"}), "profile does not exist")
+	tests.Check(del.Run(del.Cmd(), []string{"office"}))
+	tests.StrEq(conf.Profile, "", "deleting the current profile should unset it")
+	if len(conf.Profiles) != 1 {
+		t.Error("profile was not deleted")
+	}
+	tests.Exp(conf.UseProfile("office"), "profile was deleted")
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	conf := r.Config()
+	conf.Email = "me@home.com"
+	conf.Phone = "123-456-7890"
+	conf.Card.Expiration = ""
+
+	c := newConfigValidateCmd(r)
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	r.Compare(t, "The config is valid.\n")
+	r.ClearBuf()
+
+	conf.Email = "me"
+	conf.Service = "Pickup"
+	tests.Exp(c.Run(c.Cmd(), []string{}), "expected invalid config")
+	for _, key := range []string{"email: ", "service: "} {
+		if !r.Contains(key) {
+			t.Errorf("expected an issue with %s in %q", key, r.Out.String())
+		}
+	}
+	conf.Service = "Carryout"
+}
+
 func TestConfigEdit(t *testing.T) {
 	tests.InitHelpers(t)
 	r := cmdtest.NewRecorder()
@@ -136,6 +244,36 @@ func TestConfigEdit(t *testing.T) {
 	}
 }
 
+func TestConfigEditValidate(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	c := NewConfigCmd(r).(*configCmd)
+	tests.Check(config.SetConfig(".config/apizza/tests-edit", r.Conf))
+	defer func() {
+		tests.Check(errs.Pair(r.DB().Destroy(), os.RemoveAll(config.Folder())))
+	}()
+	editor := os.Getenv("EDITOR")
+	defer os.Setenv("EDITOR", editor)
+	os.Setenv("EDITOR", "true")
+	c.edit = true
+
+	tests.Check(ioutil.WriteFile(config.File(), []byte("name: joe\nnot-a-key: 1\n"), 0644))
+	c.in = strings.NewReader("y\nn\n")
+	tests.Exp(c.Run(c.Cmd(), []string{}), "expected an error for an invalid config file")
+	if strings.Count(r.Out.String(), "Reopen the editor?") != 2 {
+		t.Errorf("the editor should have been reopened once: %q", r.Out.String())
+	}
+	if !r.Contains("not-a-key: unknown key") {
+		t.Errorf("expected the unknown key to be reported: %q", r.Out.String())
+	}
+	r.ClearBuf()
+
+	tests.Check(ioutil.WriteFile(config.File(), []byte("name: joe\nservice: Carryout\n"), 0644))
+	c.in = strings.NewReader("")
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	r.Compare(t, "")
+}
+
 func TestConfigGet(t *testing.T) {
 	tests.InitHelpers(t)
 	conf := &cli.Config{}
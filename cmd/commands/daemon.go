@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harrybrwn/apizza/cmd/cli"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/webhook"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/cache"
+	"github.com/harrybrwn/apizza/pkg/cron"
+)
+
+// CVVEnv is the environment variable that can hold the card's cvv for
+// 'apizza daemon'.
+const CVVEnv = "APIZZA_CVV"
+
+// NewDaemonCmd creates the 'daemon' command.
+func NewDaemonCmd(b cli.Builder) cli.CliCommand {
+	c := &daemonCmd{db: b.DB(), conf: b.Config(), now: time.Now}
+	c.place = c.placeOrder
+	c.CliCommand = b.Build("daemon", "Place scheduled orders when they are due.", c)
+	c.SetOutput(b.Output())
+	c.Cmd().Long = `The daemon command runs in the foreground and places the orders added
+with 'apizza schedule add' when they are due. Before an order is placed the
+store is checked to make sure that it is open, then the order is priced and
+sent to dominos without asking. The result of every run can be seen with
+'apizza schedule list'.
+
+The cvv is never saved so it has to be given with --cvv or the APIZZA_CVV
+environment variable. Runs that were missed by more than --max-late, like
+when the computer was asleep, are skipped.`
+	c.Cmd().Args = cobra.NoArgs
+
+	flags := c.Flags()
+	flags.IntVar(&c.cvv, "cvv", 0, "the card's cvv number (default $"+CVVEnv+")")
+	flags.DurationVar(&c.interval, "interval", time.Minute, "how often to check for orders that are due")
+	flags.DurationVar(&c.maxLate, "max-late", 15*time.Minute, "skip runs that are later than this")
+	flags.BoolVar(&c.once, "once", false, "check for orders that are due once and exit")
+	flags.BoolVar(&c.dryRun, "dry-run", false, "check the store and price orders without placing them")
+	return c
+}
+
+// `apizza daemon`
+type daemonCmd struct {
+	cli.CliCommand
+	db   cache.Backend
+	conf *cli.Config
+
+	cvv      int
+	interval time.Duration
+	maxLate  time.Duration
+	once     bool
+	dryRun   bool
+
+	now   func() time.Time
+	place func(*dawg.Order) data.ScheduleRun
+}
+
+func (c *daemonCmd) Run(cmd *cobra.Command, args []string) error {
+	if c.cvv == 0 {
+		if env := os.Getenv(CVVEnv); env != "" {
+			cvv, err := strconv.Atoi(env)
+			if err != nil {
+				return fmt.Errorf("bad cvv in $%s", CVVEnv)
+			}
+			c.cvv = cvv
+		}
+	}
+	if c.cvv == 0 && !c.dryRun {
+		return errors.New("must have cvv number. (see --cvv)")
+	}
+	if c.once {
+		return c.runDue()
+	}
+	if c.interval <= 0 {
+		return errors.New("the interval must be positive")
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.Printf("checking for scheduled orders every %s\n", c.interval)
+	for {
+		if err := c.runDue(); err != nil {
+			// keep going, the database might only be busy
+			log.Println("daemon:", err)
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+		}
+		select {
+		case <-sig:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue runs every schedule that is due. A run is claimed by saving the
+// schedule's next run time before the order is placed so that an order is
+// never placed twice, even if the daemon dies before the result is saved.
+func (c *daemonCmd) runDue() error {
+	defer releaseDB(c.db)
+	schedules, err := data.Schedules(c.db)
+	if err != nil {
+		return err
+	}
+	now := c.now()
+	for _, s := range schedules {
+		if s.Next.IsZero() || s.Next.After(now) {
+			continue
+		}
+		sched, err := cron.Parse(s.Cron)
+		if err != nil {
+			return fmt.Errorf("order '%s': %v", s.Order, err)
+		}
+		due := s.Next
+		s.Next = sched.Next(now)
+		if err = data.SaveSchedule(c.db, s); err != nil {
+			return err
+		}
+
+		var run data.ScheduleRun
+		if late := now.Sub(due); late > c.maxLate {
+			run = data.ScheduleRun{
+				Result:  data.RunSkipped,
+				Message: "missed the run at " + due.Format(scheduleTimeFmt),
+			}
+		} else if order, err := data.GetOrder(s.Order, c.db); err != nil {
+			run = data.ScheduleRun{Result: data.RunFailed, Message: err.Error()}
+		} else {
+			// don't hold the database while talking to dominos
+			releaseDB(c.db)
+			run = c.place(order)
+		}
+		run.Time = now
+		if err = c.record(s.Order, run); err != nil {
+			return err
+		}
+		c.Printf("%s %s: %s\n", now.Format(scheduleTimeFmt), s.Order, describeRun(&run))
+	}
+	return nil
+}
+
+// record saves the result of a run. The schedule is read again because it
+// may have been changed while the order was being placed.
+func (c *daemonCmd) record(order string, run data.ScheduleRun) error {
+	if !c.db.WithBucket(data.SchedulesBucket).Exists(order) {
+		return nil // the schedule was removed
+	}
+	s, err := data.GetSchedule(c.db, order)
+	if err != nil {
+		return err
+	}
+	s.Record(run)
+	return data.SaveSchedule(c.db, s)
+}
+
+// placeOrder checks that the store is open then prices and places an order.
+func (c *daemonCmd) placeOrder(order *dawg.Order) data.ScheduleRun {
+	store, err := dawg.NewStore(order.StoreID, order.ServiceMethod, order.Address)
+	if err != nil {
+		return data.ScheduleRun{Result: data.RunFailed, Message: err.Error()}
+	}
+	open := store.IsOpen && store.IsOnlineNow
+	if store.ServiceIsOpen != nil && !store.ServiceIsOpen[order.ServiceMethod] {
+		open = false
+	}
+	if !open {
+		return data.ScheduleRun{
+			Result:  data.RunSkipped,
+			Message: fmt.Sprintf("store %s is closed for %s", store.ID, order.ServiceMethod),
+		}
+	}
+
+	price, err := order.Price()
+	if err != nil {
+		return data.ScheduleRun{Result: data.RunFailed, Message: err.Error()}
+	}
+	if c.dryRun {
+		return data.ScheduleRun{Result: data.RunDryRun, Total: price}
+	}
+	if err = (payment{cvv: c.cvv}).addTo(order); err != nil {
+		return data.ScheduleRun{Result: data.RunFailed, Message: err.Error(), Total: price}
+	}
+
+	err = order.PlaceOrder()
+	log.Println("sending scheduled order:", dawg.OrderToJSON(order))
+	recordOrder(c.db, c.Cmd().ErrOrStderr(), order, price, "daemon", err)
+	notify(c.conf, c.Cmd().ErrOrStderr(), webhook.OrderEvent(webhook.OrderPlaced, order, price, err))
+	if err != nil {
+		return data.ScheduleRun{Result: data.RunFailed, Message: err.Error(), Total: price}
+	}
+	return data.ScheduleRun{Result: data.RunPlaced, Total: price, OrderID: order.OrderID}
+}
+
+// releaseDB closes the database if it can be reopened later so that other
+// apizza commands can use it between runs.
+func releaseDB(db cache.Backend) {
+	if r, ok := db.(interface{ Release() error }); ok {
+		r.Release()
+	}
+}
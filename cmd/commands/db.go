@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harrybrwn/apizza/cmd/cli"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/pkg/cache"
+)
+
+// NewDBCmd creates the 'db' command.
+func NewDBCmd(b cli.Builder) cli.CliCommand {
+	c := &dbCmd{db: b.DB()}
+	c.CliCommand = b.Build("db", "Manage the local database.", c)
+	c.SetOutput(b.Output())
+	c.Cmd().Long = `The db command manages the database that apizza uses to store
+orders, addresses, and cached data from dominos.`
+	cli.ReadOnly(c.Cmd())
+
+	c.Addcmd(
+		newDBMigrateCmd(b),
+		newDBDumpCmd(b),
+		newDBRestoreCmd(b),
+		newDBStatsCmd(b),
+		newDBSweepCmd(b),
+	)
+	return c
+}
+
+// `apizza db`
+type dbCmd struct {
+	cli.CliCommand
+	db cache.Backend
+}
+
+func (c *dbCmd) Run(cmd *cobra.Command, args []string) error {
+	version, err := data.SchemaVersion(c.db)
+	if err != nil {
+		return err
+	}
+	c.Println("path:          ", c.db.Path())
+	c.Println("schema version:", version)
+	return nil
+}
+
+func newDBMigrateCmd(b cli.Builder) cli.CliCommand {
+	c := &dbMigrateCmd{db: b.DB()}
+	c.CliCommand = b.Build("migrate", "Update the database to the latest schema.", c)
+	c.Cmd().Long = `The migrate command runs the database migrations that have not been
+run yet. Migrations are also run automatically when apizza starts.`
+	c.Cmd().Args = cobra.NoArgs
+	c.Flags().BoolVar(&c.dryRun, "dry-run", false, "show the migrations that would run without running them")
+	return c
+}
+
+// `apizza db migrate`
+type dbMigrateCmd struct {
+	cli.CliCommand
+	db     cache.Backend
+	dryRun bool
+}
+
+func (c *dbMigrateCmd) Run(cmd *cobra.Command, args []string) error {
+	version, err := data.SchemaVersion(c.db)
+	if err != nil {
+		return err
+	}
+	pending, err := data.PendingMigrations(c.db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		c.Printf("database is up to date (schema version %d)\n", version)
+		return nil
+	}
+
+	if c.dryRun {
+		c.Printf("database is at schema version %d, migrations that would run:\n", version)
+		for _, m := range pending {
+			c.Printf("  %d  %s\n", m.Version, m.Description)
+		}
+		return nil
+	}
+	ran, err := data.Migrate(c.db)
+	for _, m := range ran {
+		c.Printf("ran migration %d: %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+	c.Printf("database is now at schema version %d\n", data.LatestSchemaVersion())
+	return nil
+}
+
+func newDBDumpCmd(b cli.Builder) cli.CliCommand {
+	c := &dbDumpCmd{db: b.DB()}
+	c.CliCommand = b.Build("dump", "Write the database to stdout as json.", c)
+	c.Cmd().Long = `The dump command writes every bucket in the database as a json archive.
+Values that are not json or plain text are base64 encoded. The archive can
+be loaded again with 'apizza db restore'.`
+	c.Cmd().Args = cobra.NoArgs
+	cli.ReadOnly(c.Cmd())
+	c.Flags().StringVarP(&c.file, "file", "f", "", "write the archive to a file instead of stdout")
+	return c
+}
+
+// `apizza db dump`
+type dbDumpCmd struct {
+	cli.CliCommand
+	db   cache.Backend
+	file string
+}
+
+func (c *dbDumpCmd) Run(cmd *cobra.Command, args []string) error {
+	if c.file == "" {
+		return data.Dump(c.db, c.Output())
+	}
+	f, err := os.Create(c.file)
+	if err != nil {
+		return err
+	}
+	if err = data.Dump(c.db, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func newDBRestoreCmd(b cli.Builder) cli.CliCommand {
+	c := &dbRestoreCmd{db: b.DB()}
+	c.CliCommand = b.Build("restore <file>", "Load a database archive.", c)
+	c.Cmd().Long = `The restore command loads an archive written by 'apizza db dump'.
+
+In merge mode the values in the archive replace the values in the database
+and everything else is kept. In overwrite mode the database is cleared
+before the archive is loaded.`
+	c.Cmd().Args = cobra.ExactArgs(1)
+	c.Flags().StringVar(&c.mode, "mode", "merge", "how to restore the archive (merge or overwrite)")
+	return c
+}
+
+// `apizza db restore`
+type dbRestoreCmd struct {
+	cli.CliCommand
+	db   cache.Backend
+	mode string
+}
+
+func (c *dbRestoreCmd) Run(cmd *cobra.Command, args []string) error {
+	var overwrite bool
+	switch c.mode {
+	case "merge":
+	case "overwrite":
+		overwrite = true
+	default:
+		return fmt.Errorf("unknown restore mode %q (use merge or overwrite)", c.mode)
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	archive, err := data.ReadArchive(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", args[0], err)
+	}
+	if err = data.Restore(c.db, archive, overwrite); err != nil {
+		return err
+	}
+	var keys int
+	for _, bucket := range archive.Buckets {
+		keys += len(bucket)
+	}
+	c.Printf("restored %d keys in %d buckets from %s\n", keys, len(archive.Buckets), args[0])
+	return nil
+}
+
+func newDBStatsCmd(b cli.Builder) cli.CliCommand {
+	c := &dbStatsCmd{db: b.DB()}
+	c.CliCommand = b.Build("stats", "Show the size of the database.", c)
+	c.Cmd().Args = cobra.NoArgs
+	cli.ReadOnly(c.Cmd())
+	return c
+}
+
+// `apizza db stats`
+type dbStatsCmd struct {
+	cli.CliCommand
+	db cache.Backend
+}
+
+func (c *dbStatsCmd) Run(cmd *cobra.Command, args []string) error {
+	stats, err := data.Stats(c.db)
+	if err != nil {
+		return err
+	}
+	if stats.Path == "" {
+		c.Printf("in-memory database\n\n")
+	} else {
+		c.Printf("%s (%d bytes)\n\n", stats.Path, stats.FileSize)
+	}
+	c.Printf("%-12s %6s %10s\n", "bucket", "keys", "bytes")
+	for _, b := range stats.Buckets {
+		c.Printf("%-12s %6d %10d\n", b.Name, b.Keys, b.Size)
+	}
+	return nil
+}
+
+func newDBSweepCmd(b cli.Builder) cli.CliCommand {
+	c := &dbSweepCmd{db: b.DB()}
+	c.CliCommand = b.Build("sweep", "Delete expired data from the database.", c)
+	c.Cmd().Long = `The sweep command deletes cached data that has expired, like old menus.
+Expired menus are kept until then so they can be used when apizza is offline.`
+	c.Cmd().Args = cobra.NoArgs
+	return c
+}
+
+// `apizza db sweep`
+type dbSweepCmd struct {
+	cli.CliCommand
+	db cache.Backend
+}
+
+func (c *dbSweepCmd) Run(cmd *cobra.Command, args []string) error {
+	n, err := data.Sweep(c.db)
+	if err != nil {
+		return err
+	}
+	c.Printf("deleted %d expired entries\n", n)
+	return nil
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestDBMigrate(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	c := newDBMigrateCmd(r).(*dbMigrateCmd)
+
+	c.dryRun = true
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	exp := "database is at schema version 0, migrations that would run:\n"
+	for _, m := range data.Migrations() {
+		exp += fmt.Sprintf("  %d  %s\n", m.Version, m.Description)
+	}
+	r.Compare(t, exp)
+	r.ClearBuf()
+	version, err := data.SchemaVersion(r.DB())
+	tests.Check(err)
+	if version != 0 {
+		t.Error("a dry run should not change the database")
+	}
+
+	c.dryRun = false
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	latest := data.LatestSchemaVersion()
+	if !r.Contains(fmt.Sprintf("database is now at schema version %d\n", latest)) {
+		t.Errorf("wrong output: %q", r.Out.String())
+	}
+	r.ClearBuf()
+
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	r.Compare(t, fmt.Sprintf("database is up to date (schema version %d)\n", latest))
+}
+
+func TestDBDumpRestore(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	tests.Check(r.DB().WithBucket("orders").Put("friday", []byte(`{"StoreID":"4336"}`)))
+
+	file := tests.NamedTempFile("apizza", "dump.json")
+	defer os.Remove(file)
+	dump := newDBDumpCmd(r).(*dbDumpCmd)
+	dump.file = file
+	tests.Check(dump.Run(dump.Cmd(), []string{}))
+	tests.Check(r.DB().WithBucket("orders").Put("saturday", []byte(`{}`)))
+
+	restore := newDBRestoreCmd(r).(*dbRestoreCmd)
+	restore.mode = "replace"
+	tests.Exp(restore.Run(restore.Cmd(), []string{file}), "expected an error for an unknown mode")
+	restore.mode = "overwrite"
+	tests.Check(restore.Run(restore.Cmd(), []string{file}))
+	if !r.Contains("restored ") {
+		t.Errorf("wrong output: %q", r.Out.String())
+	}
+	if r.DB().WithBucket("orders").Exists("saturday") {
+		t.Error("overwrite should remove keys that were not dumped")
+	}
+	r.ClearBuf()
+
+	stats := newDBStatsCmd(r).(*dbStatsCmd)
+	tests.Check(stats.Run(stats.Cmd(), []string{}))
+	if !r.Contains(fmt.Sprintf("%-12s %6d", "orders", 1)) {
+		t.Errorf("wrong output: %q", r.Out.String())
+	}
+	r.ClearBuf()
+
+	tests.Check(r.DB().PutWithTTL("expired", []byte("x"), -time.Minute))
+	sweep := newDBSweepCmd(r).(*dbSweepCmd)
+	tests.Check(sweep.Run(sweep.Cmd(), []string{}))
+	r.Compare(t, "deleted 1 expired entries\n")
+	if r.DB().Exists("expired") {
+		t.Error("sweep should delete expired values")
+	}
+}
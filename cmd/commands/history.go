@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harrybrwn/apizza/cmd/cli"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/cmd/opts"
+	"github.com/harrybrwn/apizza/pkg/cache"
+)
+
+// historyDateFmt is the format of the dates given to 'apizza history'.
+const historyDateFmt = "2006-01-02"
+
+// NewHistoryCmd creates the 'history' command.
+func NewHistoryCmd(b cli.Builder) cli.CliCommand {
+	c := &historyCmd{db: b.DB(), gOpts: b.GlobalOptions()}
+	c.CliCommand = b.Build("history", "Show the orders that were sent to dominos.", c)
+	c.SetOutput(b.Output())
+	c.Cmd().Long = `The history command lists every order that was sent to dominos by the
+order, serve, and daemon commands, including the orders that failed. Use
+'apizza history show <id>' to see everything about one of them.
+
+Dates given to --from and --to look like 2020-05-08, and both days are
+included. The --stats flag shows how much was spent on the orders that were
+placed for each week or month instead of listing them.`
+	c.Cmd().Args = cobra.NoArgs
+	cli.ReadOnly(c.Cmd())
+
+	flags := c.Flags()
+	flags.StringVar(&c.from, "from", "", "only show orders on or after this date")
+	flags.StringVar(&c.to, "to", "", "only show orders on or before this date")
+	flags.StringVar(&c.store, "store", "", "only show orders from this store id")
+	flags.StringVar(&c.stats, "stats", "", "show the money spent per week or month")
+
+	c.Addcmd(newHistoryShowCmd(b))
+	return c
+}
+
+// `apizza history`
+type historyCmd struct {
+	cli.CliCommand
+	db    cache.Backend
+	gOpts *opts.CliFlags
+
+	from, to string
+	store    string
+	stats    string
+}
+
+func (c *historyCmd) Run(cmd *cobra.Command, args []string) error {
+	filter, err := c.filter()
+	if err != nil {
+		return err
+	}
+	history, err := data.History(c.db, filter)
+	if err != nil {
+		return err
+	}
+
+	if c.stats != "" {
+		spending, err := data.SpendingPer(c.stats, history)
+		if err != nil {
+			return err
+		}
+		if c.gOpts.Output.Structured() {
+			return out.Encode(c.Output(), c.gOpts.Output, spending)
+		}
+		return printSpending(c.Output(), c.stats, spending)
+	}
+	if c.gOpts.Output.Structured() {
+		return out.Encode(c.Output(), c.gOpts.Output, history)
+	}
+	return printHistory(c.Output(), history)
+}
+
+// filter creates the history filter from the command's flags.
+func (c *historyCmd) filter() (f data.HistoryFilter, err error) {
+	f.StoreID = c.store
+	if c.from != "" {
+		if f.From, err = time.ParseInLocation(historyDateFmt, c.from, time.Local); err != nil {
+			return f, fmt.Errorf("bad date for --from: %v", err)
+		}
+	}
+	if c.to != "" {
+		if f.To, err = time.ParseInLocation(historyDateFmt, c.to, time.Local); err != nil {
+			return f, fmt.Errorf("bad date for --to: %v", err)
+		}
+		f.To = f.To.AddDate(0, 0, 1) // include the whole day
+	}
+	return f, nil
+}
+
+func printHistory(w io.Writer, history []*data.HistoryEntry) error {
+	if len(history) == 0 {
+		fmt.Fprintln(w, "No orders in the history.")
+		return nil
+	}
+	fmt.Fprintf(w, "%s:\n", out.Heading("Order History"))
+	for _, h := range history {
+		fmt.Fprintf(w, "  %s  %s  %s  %s from store %s  %s  %s\n",
+			out.Key(h.ID), h.Time.Local().Format(scheduleTimeFmt), out.Name(h.Order),
+			h.Service, h.StoreID, out.Price(fmt.Sprintf("$%.2f", h.Total)), h.Outcome)
+	}
+	return nil
+}
+
+func printSpending(w io.Writer, period string, spending []data.Spending) error {
+	if len(spending) == 0 {
+		fmt.Fprintln(w, "No orders in the history.")
+		return nil
+	}
+	var (
+		orders int
+		total  float64
+	)
+	fmt.Fprintf(w, "%s:\n", out.Heading("Spending per "+period))
+	for _, s := range spending {
+		fmt.Fprintf(w, "  %-12s %3d orders  %s\n", s.Start.Format("Jan 2 2006"), s.Orders,
+			out.Price(fmt.Sprintf("$%.2f", s.Total)))
+		orders += s.Orders
+		total += s.Total
+	}
+	fmt.Fprintf(w, "  %-12s %3d orders  %s\n", "total", orders, out.Price(fmt.Sprintf("$%.2f", total)))
+	return nil
+}
+
+func newHistoryShowCmd(b cli.Builder) cli.CliCommand {
+	c := &historyShowCmd{db: b.DB(), gOpts: b.GlobalOptions()}
+	c.CliCommand = b.Build("show <id>", "Show an order from the history.", c)
+	c.Cmd().Args = cobra.ExactArgs(1)
+	c.Cmd().ValidArgsFunction = c.complete
+	cli.ReadOnly(c.Cmd())
+	return c
+}
+
+// `apizza history show`
+type historyShowCmd struct {
+	cli.CliCommand
+	db    cache.Backend
+	gOpts *opts.CliFlags
+}
+
+func (c *historyShowCmd) Run(cmd *cobra.Command, args []string) error {
+	h, err := data.GetHistory(c.db, args[0])
+	if err != nil {
+		return err
+	}
+	if c.gOpts.Output.Structured() {
+		return out.Encode(c.Output(), c.gOpts.Output, h)
+	}
+
+	w := c.Output()
+	fmt.Fprintf(w, "%s\n", out.Name(h.Order))
+	fmt.Fprintf(w, "  %s     %s\n", out.Key("time"), h.Time.Local().Format(time.RFC1123))
+	fmt.Fprintf(w, "  %s  %s\n", out.Key("outcome"), h.Outcome)
+	if h.Error != "" {
+		fmt.Fprintf(w, "  %s    %s\n", out.Key("error"), h.Error)
+	}
+	if h.OrderID != "" {
+		fmt.Fprintf(w, "  %s  %s\n", out.Key("orderID"), h.OrderID)
+	}
+	if h.Source != "" {
+		fmt.Fprintf(w, "  %s   %s\n", out.Key("source"), h.Source)
+	}
+	fmt.Fprintf(w, "  %s  %s\n", out.Key("storeID"), h.StoreID)
+	fmt.Fprintf(w, "  %s   %s\n", out.Key("method"), h.Service)
+	if a := h.Address; a != nil {
+		fmt.Fprintf(w, "  %s  %s, %s, %s %s\n", out.Key("address"), a.Street, a.City, a.State, a.Zipcode)
+	}
+	fmt.Fprintf(w, "  %s:\n", out.Key("products"))
+	for _, p := range h.Products {
+		name := p.Name
+		if name == "" {
+			name = p.Code
+		}
+		fmt.Fprintf(w, "    %s: %s\n", out.Key("name"), name)
+		fmt.Fprintf(w, "      %s:     %s\n", out.Key("code"), p.Code)
+		if len(p.Options) > 0 {
+			fmt.Fprintf(w, "      %s:\n", out.Key("options"))
+			keys := make([]string, 0, len(p.Options))
+			for k := range p.Options {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(w, "         %s: %s\n", out.Key(k), p.Options[k])
+			}
+		}
+		fmt.Fprintf(w, "      %s: %d\n", out.Key("quantity"), p.Quantity)
+	}
+	fmt.Fprintf(w, "  %s    %s\n", out.Key("total"), out.Price(fmt.Sprintf("$%.2f", h.Total)))
+	return nil
+}
+
+func (c *historyShowCmd) complete(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	history, err := data.History(c.db, data.HistoryFilter{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	ids := make([]string, len(history))
+	for i, h := range history {
+		ids[i] = h.ID
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/cmd/opts"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func addTestHistory(t *testing.T, r *cmdtest.TestRecorder) {
+	for _, h := range []*data.HistoryEntry{
+		{
+			ID: "first", Time: scheduleTime("2020-05-04 12:00"), Order: "lunch", StoreID: "4336",
+			Service: "Delivery", Total: 10, Outcome: data.OrderSucceeded, OrderID: "abc123",
+			Products: []out.OrderProduct{{Code: "14SCREEN", Name: "Large Pizza", Quantity: 1,
+				Options: map[string]string{"P": "full 1.0"}}},
+		},
+		{ID: "second", Time: scheduleTime("2020-05-08 12:00"), Order: "lunch", StoreID: "4336",
+			Service: "Delivery", Total: 20, Outcome: data.OrderSucceeded},
+		{ID: "failed", Time: scheduleTime("2020-05-08 13:00"), Order: "dinner", StoreID: "4336",
+			Service: "Carryout", Total: 0, Outcome: data.OrderFailed, Error: "store closed"},
+		{ID: "other", Time: scheduleTime("2020-06-01 12:00"), Order: "lunch", StoreID: "4344",
+			Service: "Carryout", Total: 5, Outcome: data.OrderSucceeded},
+	} {
+		if err := data.SaveHistory(r.DB(), h); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHistoryCmd(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+
+	c := NewHistoryCmd(r).(*historyCmd)
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	tests.StrEq(r.Out.String(), "No orders in the history.\n", "wrong output with no history")
+	addTestHistory(t, r)
+
+	r.Out.Reset()
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	lines := strings.Split(strings.TrimSpace(r.Out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected a heading and 4 orders:\n%s", r.Out.String())
+	}
+	if !strings.Contains(lines[1], "first") || !strings.Contains(lines[4], "other") {
+		t.Error("history should be oldest first")
+	}
+	if !strings.Contains(lines[3], "$0.00  failed") {
+		t.Errorf("wrong line for a failed order: %q", lines[3])
+	}
+
+	r.Out.Reset()
+	c.from, c.to, c.store = "2020-05-05", "2020-05-08", "4336"
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	if strings.Contains(r.Out.String(), "first") || strings.Contains(r.Out.String(), "other") ||
+		!strings.Contains(r.Out.String(), "second") || !strings.Contains(r.Out.String(), "failed") {
+		t.Errorf("wrong filtered history:\n%s", r.Out.String())
+	}
+	c.to = "may 8th"
+	tests.Exp(c.Run(c.Cmd(), []string{}), "should fail with a bad date")
+	c.from, c.to, c.store = "", "", ""
+
+	r.Out.Reset()
+	c.stats = data.Monthly
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	for _, exp := range []string{"Spending per month", "May 1 2020", "2 orders  $30.00", "Jun 1 2020", "3 orders  $35.00"} {
+		if !strings.Contains(r.Out.String(), exp) {
+			t.Errorf("stats should have %q:\n%s", exp, r.Out.String())
+		}
+	}
+	c.stats = "day"
+	tests.Exp(c.Run(c.Cmd(), []string{}), "should not have daily stats")
+	c.stats = ""
+
+	r.Out.Reset()
+	c.gOpts.Output = opts.JSON
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	var history []*data.HistoryEntry
+	tests.Fatal(json.Unmarshal(r.Out.Bytes(), &history))
+	if len(history) != 4 || history[0].OrderID != "abc123" {
+		t.Errorf("wrong json history: %+v", history)
+	}
+	c.gOpts.Output = opts.Table
+}
+
+func TestHistoryShowCmd(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	addTestHistory(t, r)
+
+	c := newHistoryShowCmd(r).(*historyShowCmd)
+	tests.Exp(c.Run(c.Cmd(), []string{"missing"}))
+	tests.Check(c.Run(c.Cmd(), []string{"first"}))
+	for _, exp := range []string{"lunch", "abc123", "4336", "Large Pizza", "14SCREEN", "full 1.0", "$10.00"} {
+		if !strings.Contains(r.Out.String(), exp) {
+			t.Errorf("output should have %q:\n%s", exp, r.Out.String())
+		}
+	}
+	r.Out.Reset()
+	tests.Check(c.Run(c.Cmd(), []string{"failed"}))
+	if !strings.Contains(r.Out.String(), "store closed") {
+		t.Errorf("should show the error:\n%s", r.Out.String())
+	}
+
+	ids, _ := c.complete(c.Cmd(), []string{}, "")
+	if strings.Join(ids, " ") != "first second failed other" {
+		t.Errorf("wrong completion: %v", ids)
+	}
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harrybrwn/apizza/cmd/cli"
+)
+
+func newProfileCmd(b cli.Builder) cli.CliCommand {
+	c := &profileCmd{conf: b.Config()}
+	c.CliCommand = b.Build("profile", "Manage config profiles.", c)
+	c.Cmd().Long = `Profiles are named sets of config values that replace the base config
+values. Any field that a profile does not set will use the base value.
+
+Use a profile with the --profile flag, the APIZZA_PROFILE environment
+variable, or 'apizza config profile use'.`
+	c.Cmd().Args = cobra.NoArgs
+	c.Addcmd(
+		newProfileCreateCmd(b),
+		newProfileUseCmd(b),
+		newProfileDeleteCmd(b),
+	)
+	c.AddCobraCmd(&cobra.Command{
+		Use:   "list",
+		Short: "List all the profiles.",
+		Args:  cobra.NoArgs,
+		RunE:  c.Run,
+	})
+	return c
+}
+
+// `apizza config profile` and `apizza config profile list`
+type profileCmd struct {
+	cli.CliCommand
+	conf *cli.Config
+}
+
+func (c *profileCmd) Run(cmd *cobra.Command, args []string) error {
+	names := make([]string, 0, len(c.conf.Profiles))
+	for name := range c.conf.Profiles {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		c.Println("No profiles.")
+		return nil
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == c.conf.Profile {
+			c.Printf("* %s\n", name)
+		} else {
+			c.Printf("  %s\n", name)
+		}
+	}
+	return nil
+}
+
+func newProfileCreateCmd(b cli.Builder) cli.CliCommand {
+	c := &profileCreateCmd{conf: b.Config()}
+	c.CliCommand = b.Build("create <name> [key=value...]", "Create a new profile.", c)
+	c.Cmd().Long = `The create command makes a new profile. Profile values are given in
+the same format as 'apizza config set'. The name, email, phone, card,
+service, and default-address-name fields can be set in a profile.
+
+    $ apizza config profile create office email=bob@work.com service=Carryout`
+	c.Cmd().Args = cobra.MinimumNArgs(1)
+	return c
+}
+
+// `apizza config profile create`
+type profileCreateCmd struct {
+	cli.CliCommand
+	conf *cli.Config
+}
+
+func (c *profileCreateCmd) Run(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if _, ok := c.conf.Profiles[name]; ok {
+		return fmt.Errorf("profile '%s' already exists", name)
+	}
+	p := &cli.Profile{}
+	for _, arg := range args[1:] {
+		keys := strings.SplitN(arg, "=", 2)
+		if len(keys) < 2 || keys[0] == "" {
+			return errors.New("use '<key>=<value>' format (no spaces)")
+		}
+		if err := p.Set(keys[0], keys[1]); err != nil {
+			return err
+		}
+	}
+	if c.conf.Profiles == nil {
+		c.conf.Profiles = make(map[string]*cli.Profile)
+	}
+	c.conf.Profiles[name] = p
+	return nil
+}
+
+func newProfileUseCmd(b cli.Builder) cli.CliCommand {
+	c := &profileUseCmd{conf: b.Config()}
+	c.CliCommand = b.Build("use <name>", "Set the profile that is used by default.", c)
+	c.Cmd().Args = cobra.ExactArgs(1)
+	return c
+}
+
+// `apizza config profile use`
+type profileUseCmd struct {
+	cli.CliCommand
+	conf *cli.Config
+}
+
+func (c *profileUseCmd) Run(cmd *cobra.Command, args []string) error {
+	if _, ok := c.conf.Profiles[args[0]]; !ok {
+		return fmt.Errorf("no profile named '%s'", args[0])
+	}
+	c.conf.Profile = args[0]
+	return nil
+}
+
+func newProfileDeleteCmd(b cli.Builder) cli.CliCommand {
+	c := &profileDeleteCmd{conf: b.Config()}
+	c.CliCommand = b.Build("delete <name>", "Delete a profile.", c)
+	c.Cmd().Args = cobra.ExactArgs(1)
+	return c
+}
+
+// `apizza config profile delete`
+type profileDeleteCmd struct {
+	cli.CliCommand
+	conf *cli.Config
+}
+
+func (c *profileDeleteCmd) Run(cmd *cobra.Command, args []string) error {
+	if _, ok := c.conf.Profiles[args[0]]; !ok {
+		return fmt.Errorf("no profile named '%s'", args[0])
+	}
+	delete(c.conf.Profiles, args[0])
+	if c.conf.Profile == args[0] {
+		c.conf.Profile = ""
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harrybrwn/apizza/cmd/cart"
+	"github.com/harrybrwn/apizza/cmd/cli"
+	"github.com/harrybrwn/apizza/cmd/internal"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/pkg/cache"
+	"github.com/harrybrwn/apizza/pkg/cron"
+)
+
+// scheduleTimeFmt is how the times of scheduled runs are printed.
+const scheduleTimeFmt = "Mon Jan 2 2006 15:04"
+
+// NewScheduleCmd creates the 'schedule' command.
+func NewScheduleCmd(b cli.Builder) cli.CliCommand {
+	c := &scheduleListCmd{db: b.DB()}
+	c.CliCommand = b.Build("schedule", "Place orders from the cart on a schedule.", c)
+	c.SetOutput(b.Output())
+	c.Cmd().Long = `The schedule command manages orders that are placed again and again on a
+schedule. Schedules use cron expressions, so an order placed every friday at
+noon would be scheduled like this.
+
+    apizza schedule add lunch --cron "0 12 * * FRI"
+
+Scheduled orders are only placed while 'apizza daemon' is running.`
+	c.Cmd().Args = cobra.NoArgs
+	cli.ReadOnly(c.Cmd())
+
+	list := &scheduleListCmd{db: b.DB()}
+	list.CliCommand = b.Build("list", "List the scheduled orders.", list)
+	list.Cmd().Args = cobra.NoArgs
+	cli.ReadOnly(list.Cmd())
+
+	c.Addcmd(newScheduleAddCmd(b), list, newScheduleRemoveCmd(b))
+	return c
+}
+
+// `apizza schedule list`
+type scheduleListCmd struct {
+	cli.CliCommand
+	db cache.Backend
+}
+
+func (c *scheduleListCmd) Run(cmd *cobra.Command, args []string) error {
+	schedules, err := data.Schedules(c.db)
+	if err != nil {
+		return err
+	}
+	return printSchedules(c.Output(), schedules)
+}
+
+func printSchedules(w io.Writer, schedules []*data.Schedule) error {
+	if len(schedules) == 0 {
+		fmt.Fprintln(w, "No orders scheduled.")
+		return nil
+	}
+	fmt.Fprintf(w, "%s:\n", out.Heading("Scheduled Orders"))
+	for _, s := range schedules {
+		fmt.Fprintf(w, "  %s  %s\n", out.Name(s.Order), s.Cron)
+		if s.Next.IsZero() {
+			fmt.Fprintf(w, "    %s never\n", out.Key("next run:"))
+		} else {
+			fmt.Fprintf(w, "    %s %s\n", out.Key("next run:"), s.Next.Format(scheduleTimeFmt))
+		}
+		if run := s.LastRun(); run != nil {
+			fmt.Fprintf(w, "    %s %s, %s\n", out.Key("last run:"), describeRun(run), internal.Since(run.Time))
+		}
+	}
+	return nil
+}
+
+// describeRun returns a short description of the result of a run.
+func describeRun(run *data.ScheduleRun) string {
+	desc := run.Result
+	if run.Total > 0 {
+		desc += fmt.Sprintf(" $%.2f", run.Total)
+	}
+	if run.Message != "" {
+		desc += ": " + run.Message
+	}
+	return desc
+}
+
+func newScheduleAddCmd(b cli.Builder) cli.CliCommand {
+	c := &scheduleAddCmd{db: b.DB(), now: time.Now}
+	c.CliCommand = b.Build("add <order>", "Schedule an order from the cart.", c)
+	c.Cmd().Long = `The add command schedules an order from the cart with a cron expression.
+If the order is already scheduled then its schedule is replaced.
+
+A cron expression has five fields: minute, hour, day of month, month, and day
+of week. Months and days can be names like JAN or FRI, and each field can be
+'*', a range like MON-FRI, a step like */15, or a list like 1,15. The
+descriptors @daily, @weekly, @monthly, @yearly, and @hourly can also be used.`
+	c.Cmd().Args = cobra.ExactArgs(1)
+	c.Cmd().ValidArgsFunction = cart.New(b).OrdersCompletion
+	c.Flags().StringVar(&c.cron, "cron", "", "the cron expression for when the order is placed")
+	return c
+}
+
+// `apizza schedule add`
+type scheduleAddCmd struct {
+	cli.CliCommand
+	db   cache.Backend
+	cron string
+	now  func() time.Time
+}
+
+func (c *scheduleAddCmd) Run(cmd *cobra.Command, args []string) error {
+	if c.cron == "" {
+		return errors.New("no schedule given (see --cron)")
+	}
+	sched, err := cron.Parse(c.cron)
+	if err != nil {
+		return err
+	}
+	name := args[0]
+	if _, err = data.GetOrder(name, c.db); err != nil {
+		return err
+	}
+	now := c.now()
+	s, err := data.GetSchedule(c.db, name)
+	if err != nil {
+		s = &data.Schedule{Order: name, Created: now}
+	}
+	s.Cron = sched.String()
+	s.Next = sched.Next(now)
+	if err = data.SaveSchedule(c.db, s); err != nil {
+		return err
+	}
+	if s.Next.IsZero() {
+		c.Printf("scheduled '%s' with '%s', but it will never run\n", name, s.Cron)
+		return nil
+	}
+	c.Printf("scheduled '%s' with '%s', the next run is %s\n", name, s.Cron, s.Next.Format(scheduleTimeFmt))
+	return nil
+}
+
+func newScheduleRemoveCmd(b cli.Builder) cli.CliCommand {
+	c := &scheduleRemoveCmd{db: b.DB()}
+	c.CliCommand = b.Build("remove <order>", "Stop placing an order on a schedule.", c)
+	c.Cmd().Aliases = []string{"rm"}
+	c.Cmd().Args = cobra.ExactArgs(1)
+	c.Cmd().ValidArgsFunction = c.complete
+	return c
+}
+
+// `apizza schedule remove`
+type scheduleRemoveCmd struct {
+	cli.CliCommand
+	db cache.Backend
+}
+
+func (c *scheduleRemoveCmd) Run(cmd *cobra.Command, args []string) error {
+	if err := data.DeleteSchedule(c.db, args[0]); err != nil {
+		return err
+	}
+	c.Printf("'%s' is no longer scheduled\n", args[0])
+	return nil
+}
+
+func (c *scheduleRemoveCmd) complete(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	schedules, err := data.Schedules(c.db)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := make([]string, len(schedules))
+	for i, s := range schedules {
+		names[i] = s.Order
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func scheduleTime(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestScheduleCmd(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	tests.Fatal(data.PutOrder(cmdtest.NewTestOrder(), r.DB()))
+
+	sched := NewScheduleCmd(r)
+	tests.Check(sched.Run(sched.Cmd(), []string{}))
+	tests.StrEq(r.Out.String(), "No orders scheduled.\n", "wrong output with no schedules")
+
+	add := newScheduleAddCmd(r).(*scheduleAddCmd)
+	add.now = func() time.Time { return scheduleTime("2020-05-04 09:00") }
+	tests.Exp(add.Run(add.Cmd(), []string{cmdtest.OrderName}), "should need --cron")
+	add.cron = "0 12 * * FRIDAY"
+	tests.Exp(add.Run(add.Cmd(), []string{cmdtest.OrderName}), "should fail with a bad cron expression")
+	add.cron = "0 12 * * FRI"
+	tests.Exp(add.Run(add.Cmd(), []string{"not-an-order"}), "should not schedule missing orders")
+
+	r.Out.Reset()
+	tests.Check(add.Run(add.Cmd(), []string{cmdtest.OrderName}))
+	if !strings.Contains(r.Out.String(), "Fri May 8 2020 12:00") {
+		t.Errorf("wrong next run: %q", r.Out.String())
+	}
+	s, err := data.GetSchedule(r.DB(), cmdtest.OrderName)
+	tests.Fatal(err)
+	if !s.Next.Equal(scheduleTime("2020-05-08 12:00")) || s.Cron != "0 12 * * FRI" {
+		t.Errorf("wrong schedule: %+v", s)
+	}
+
+	// rescheduling keeps the old runs
+	s.Record(data.ScheduleRun{Time: s.Next, Result: data.RunPlaced, Total: 21.5})
+	tests.Check(data.SaveSchedule(r.DB(), s))
+	add.cron = "@daily"
+	tests.Check(add.Run(add.Cmd(), []string{cmdtest.OrderName}))
+	s, err = data.GetSchedule(r.DB(), cmdtest.OrderName)
+	tests.Fatal(err)
+	if len(s.Runs) != 1 || s.Cron != "@daily" || !s.Next.Equal(scheduleTime("2020-05-05 00:00")) {
+		t.Errorf("wrong schedule after rescheduling: %+v", s)
+	}
+
+	r.Out.Reset()
+	tests.Check(sched.Run(sched.Cmd(), []string{}))
+	for _, exp := range []string{cmdtest.OrderName, "@daily", "Tue May 5 2020 00:00", "placed $21.50"} {
+		if !strings.Contains(r.Out.String(), exp) {
+			t.Errorf("list output should have %q:\n%s", exp, r.Out.String())
+		}
+	}
+
+	rm := newScheduleRemoveCmd(r).(*scheduleRemoveCmd)
+	names, _ := rm.complete(rm.Cmd(), []string{}, "")
+	if len(names) != 1 || names[0] != cmdtest.OrderName {
+		t.Errorf("wrong completion: %v", names)
+	}
+	tests.Check(rm.Run(rm.Cmd(), []string{cmdtest.OrderName}))
+	tests.Exp(rm.Run(rm.Cmd(), []string{cmdtest.OrderName}), "should not remove it twice")
+	if _, err = data.GetSchedule(r.DB(), cmdtest.OrderName); err == nil {
+		t.Error("the schedule should be removed")
+	}
+}
+
+func TestDaemonRunDue(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	order := cmdtest.NewTestOrder()
+	tests.Fatal(data.PutOrder(order, r.DB()))
+
+	for _, s := range []*data.Schedule{
+		{Order: order.Name(), Cron: "0 12 * * FRI", Next: scheduleTime("2020-05-08 12:00")},
+		{Order: "late", Cron: "0 11 * * FRI", Next: scheduleTime("2020-05-08 11:00")},
+		{Order: "missing", Cron: "59 11 * * FRI", Next: scheduleTime("2020-05-08 11:59")},
+		{Order: "later", Cron: "0 13 * * FRI", Next: scheduleTime("2020-05-08 13:00")},
+	} {
+		tests.Fatal(data.SaveSchedule(r.DB(), s))
+	}
+
+	d := NewDaemonCmd(r).(*daemonCmd)
+	d.maxLate = 15 * time.Minute
+	d.now = func() time.Time { return scheduleTime("2020-05-08 12:01") }
+	var placed []string
+	d.place = func(o *dawg.Order) data.ScheduleRun {
+		placed = append(placed, o.Name())
+		// the run has to be claimed before the order is placed
+		s, err := data.GetSchedule(r.DB(), o.Name())
+		tests.Fatal(err)
+		if !s.Next.After(d.now()) {
+			t.Error("the next run should be saved before the order is placed")
+		}
+		return data.ScheduleRun{Result: data.RunPlaced, Total: 20, OrderID: "abc"}
+	}
+	tests.Check(d.runDue())
+	if len(placed) != 1 || placed[0] != order.Name() {
+		t.Fatalf("wrong orders placed: %v", placed)
+	}
+
+	for name, result := range map[string]string{
+		order.Name(): data.RunPlaced,
+		"late":       data.RunSkipped,
+		"missing":    data.RunFailed,
+	} {
+		s, err := data.GetSchedule(r.DB(), name)
+		tests.Fatal(err)
+		run := s.LastRun()
+		if run == nil || run.Result != result {
+			t.Errorf("%s: wrong last run %+v, want %s", name, run, result)
+			continue
+		}
+		if !run.Time.Equal(d.now()) {
+			t.Errorf("%s: wrong run time %s", name, run.Time)
+		}
+		if !s.Next.After(d.now()) {
+			t.Errorf("%s: next run %s should be in the future", name, s.Next)
+		}
+	}
+	s, err := data.GetSchedule(r.DB(), "later")
+	tests.Fatal(err)
+	if s.LastRun() != nil {
+		t.Error("schedules that are not due should not run")
+	}
+	s, err = data.GetSchedule(r.DB(), order.Name())
+	tests.Fatal(err)
+	if s.LastRun().OrderID != "abc" || !s.Next.Equal(scheduleTime("2020-05-15 12:00")) {
+		t.Errorf("wrong schedule after running: %+v", s)
+	}
+
+	// running again right away does nothing
+	placed = nil
+	tests.Check(d.runDue())
+	if len(placed) != 0 {
+		t.Error("should not place an order twice")
+	}
+
+	d.dryRun = false
+	d.cvv = 0
+	d.once = true
+	tests.Exp(d.Run(d.Cmd(), []string{}), "should need a cvv")
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harrybrwn/apizza/cmd/cli"
+	"github.com/harrybrwn/apizza/cmd/client"
+	"github.com/harrybrwn/apizza/cmd/internal/webhook"
+	"github.com/harrybrwn/apizza/cmd/opts"
+	"github.com/harrybrwn/apizza/cmd/server"
+	"github.com/harrybrwn/apizza/pkg/config"
+)
+
+// TokenEnv is the environment variable that can hold the token for
+// 'apizza serve'.
+const TokenEnv = "APIZZA_TOKEN"
+
+// NewServeCmd creates the 'serve' command.
+func NewServeCmd(b cli.Builder) cli.CliCommand {
+	c := &serveCmd{builder: b}
+	c.CliCommand = b.Build("serve", "Serve the menu and cart as a JSON api.", c)
+	c.SetOutput(b.Output())
+	c.Cmd().Long = `The serve command starts an http server with a JSON api for the menu
+and the orders in the cart. The orders are stored in the same database as
+the cart command, so orders made with the api can be used by apizza and the
+other way around.
+
+Every request needs the header 'Authorization: Bearer <token>'. The token is
+set with --token or the APIZZA_TOKEN environment variable, and a random token
+is made and printed if neither is set. The api is described by the OpenAPI
+document at /openapi.json.
+
+Orders are only sent to dominos when the server is started with
+--allow-orders.`
+	c.Cmd().Args = cobra.NoArgs
+
+	flags := c.Flags()
+	flags.StringVar(&c.listen, "listen", "127.0.0.1:8080", "the address that the server listens on")
+	flags.StringVar(&c.token, "token", "", "the token that clients must send (default $"+TokenEnv+")")
+	flags.BoolVar(&c.allowOrders, "allow-orders", false, "let clients send orders to dominos")
+	return c
+}
+
+// `apizza serve`
+type serveCmd struct {
+	cli.CliCommand
+	builder cli.Builder
+
+	listen      string
+	token       string
+	allowOrders bool
+}
+
+func (c *serveCmd) Run(cmd *cobra.Command, args []string) error {
+	token := eitherOr(c.token, os.Getenv(TokenEnv))
+	if token == "" {
+		var err error
+		if token, err = newToken(); err != nil {
+			return err
+		}
+		c.Printf("token: %s\n", token)
+	}
+
+	names := strings.SplitN(config.GetString("name"), " ", 2)
+	customer := server.Customer{
+		FirstName:  names[0],
+		Email:      config.GetString("email"),
+		Phone:      config.GetString("phone"),
+		Number:     config.GetString("card.number"),
+		Expiration: config.GetString("card.expiration"),
+	}
+	if len(names) == 2 {
+		customer.LastName = names[1]
+	}
+
+	handler, err := server.New(server.Options{
+		DB:          c.builder.DB(),
+		Client:      client.FromBuilder(c.builder, opts.MenuUpdateTime),
+		Service:     cli.Service(c.builder),
+		Token:       token,
+		AllowOrders: c.allowOrders,
+		Customer:    customer,
+		Webhooks:    webhook.New(c.builder.Config().Webhooks),
+	})
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", c.listen)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: handler}
+	done := make(chan error, 1)
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		defer signal.Stop(sig)
+		<-sig
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(ctx)
+	}()
+
+	c.Printf("serving on http://%s\n", l.Addr())
+	if c.allowOrders {
+		c.Printf("orders can be sent to dominos\n")
+	}
+	if err = srv.Serve(l); err != http.ErrServerClosed {
+		return err
+	}
+	return <-done
+}
+
+// newToken makes a random token.
+func newToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not make a token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harrybrwn/apizza/cmd/cli"
+	"github.com/harrybrwn/apizza/cmd/client"
+	"github.com/harrybrwn/apizza/cmd/internal"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/cmd/opts"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/cache"
+)
+
+// NewStoreCmd creates the 'store' command.
+func NewStoreCmd(b cli.Builder) cli.CliCommand {
+	c := &storeCmd{storeBase: newStoreBase(b)}
+	c.CliCommand = b.Build("store", "Find and manage Dominos stores.", c)
+	c.SetOutput(b.Output())
+	c.Cmd().Long = `The store command shows the store that apizza will use for the
+current address and service method.
+
+A store can be pinned to an address with 'apizza store pin <id>' so that menus
+and orders use that store instead of the nearest one.`
+	c.formatFlag(c)
+
+	c.Addcmd(
+		newStoreListCmd(b),
+		newStoreShowCmd(b),
+		newStorePinCmd(b),
+		newStoreUnpinCmd(b),
+	)
+	return c
+}
+
+// storeBase holds everything that the store sub-commands need.
+type storeBase struct {
+	db      cache.Backend
+	gOpts   *opts.CliFlags
+	addr    func() dawg.Address
+	service func() string
+	format  string
+}
+
+func newStoreBase(b cli.Builder) storeBase {
+	return storeBase{
+		db:    b.DB(),
+		gOpts: b.GlobalOptions(),
+		addr:  b.Address,
+		service: func() string {
+			return cli.Service(b)
+		},
+	}
+}
+
+// formatFlag adds the --format flag to a store command.
+func (s *storeBase) formatFlag(c cli.CliCommand) {
+	c.Flags().StringVar(&s.format, "format", "", "format each store with a go template or a named template")
+}
+
+// printStore prints the details of a store in the output format.
+func (s *storeBase) printStore(w io.Writer, store *dawg.Store, pinned bool) error {
+	if out.Structured(s.gOpts.Output, s.format) {
+		return out.Write(w, s.gOpts.Output, s.format, out.NewStore(store, pinned))
+	}
+	out.SetOutput(w)
+	defer out.ResetOutput()
+	return out.PrintStore(store, s.service())
+}
+
+// pinned returns the id of the store pinned to the current address.
+func (s *storeBase) pinned() string {
+	addr := s.addr()
+	if obj.AddrIsEmpty(addr) {
+		return ""
+	}
+	id, _ := data.PinnedStore(s.db, addr)
+	return id
+}
+
+func (s *storeBase) address() (dawg.Address, error) {
+	addr := s.addr()
+	if obj.AddrIsEmpty(addr) {
+		return nil, internal.ErrNoAddress
+	}
+	return addr, nil
+}
+
+// `apizza store`
+type storeCmd struct {
+	cli.CliCommand
+	storeBase
+}
+
+func (c *storeCmd) Run(cmd *cobra.Command, args []string) error {
+	addr, err := c.address()
+	if err != nil {
+		return err
+	}
+	store, err := client.LocateStore(c.db, addr, c.service())
+	if err != nil {
+		return err
+	}
+	return c.printStore(c.Output(), store, store.ID == c.pinned())
+}
+
+func newStoreListCmd(b cli.Builder) cli.CliCommand {
+	c := &storeListCmd{storeBase: newStoreBase(b)}
+	c.CliCommand = b.Build("list", "List the stores near the current address.", c)
+	c.Cmd().Aliases = []string{"ls"}
+	c.formatFlag(c)
+	return c
+}
+
+// `apizza store list`
+type storeListCmd struct {
+	cli.CliCommand
+	storeBase
+}
+
+func (c *storeListCmd) Run(cmd *cobra.Command, args []string) error {
+	addr, err := c.address()
+	if err != nil {
+		return err
+	}
+	pinned, err := data.PinnedStore(c.db, addr)
+	if err != nil {
+		return err
+	}
+	stores, err := dawg.GetNearbyStores(addr, c.service())
+	if _, ok := err.(dawg.StoreErrors); ok {
+		// still show the stores that were loaded
+		fmt.Fprintln(cmd.ErrOrStderr(), err)
+	} else if err != nil {
+		return err
+	}
+	if out.Structured(c.gOpts.Output, c.format) {
+		list := make([]*out.Store, len(stores))
+		for i, s := range stores {
+			list[i] = out.NewStore(s, s.ID == pinned)
+		}
+		return out.Write(c.Output(), c.gOpts.Output, c.format, list)
+	}
+	if len(stores) == 0 {
+		c.Println("No stores found.")
+		return nil
+	}
+
+	out.SetOutput(c.Output())
+	defer out.ResetOutput()
+	c.Printf("Stores near %s:\n", oneLineAddr(addr))
+	for _, s := range stores {
+		out.PrintStoreLine(s, c.service(), s.ID == pinned)
+	}
+	return nil
+}
+
+func newStoreShowCmd(b cli.Builder) cli.CliCommand {
+	c := &storeShowCmd{storeBase: newStoreBase(b)}
+	c.CliCommand = b.Build("show <id>", "Show the details of a store.", c)
+	c.Cmd().Args = cobra.ExactArgs(1)
+	c.formatFlag(c)
+	return c
+}
+
+// `apizza store show`
+type storeShowCmd struct {
+	cli.CliCommand
+	storeBase
+}
+
+func (c *storeShowCmd) Run(cmd *cobra.Command, args []string) error {
+	store, err := dawg.NewStore(args[0], c.service(), c.addr())
+	if err != nil {
+		return err
+	}
+	return c.printStore(c.Output(), store, store.ID == c.pinned())
+}
+
+func newStorePinCmd(b cli.Builder) cli.CliCommand {
+	c := &storePinCmd{storeBase: newStoreBase(b)}
+	c.CliCommand = b.Build("pin <id>", "Use a store for the current address instead of the nearest one.", c)
+	c.Cmd().Args = cobra.ExactArgs(1)
+	return c
+}
+
+// `apizza store pin`
+type storePinCmd struct {
+	cli.CliCommand
+	storeBase
+}
+
+func (c *storePinCmd) Run(cmd *cobra.Command, args []string) error {
+	addr, err := c.address()
+	if err != nil {
+		return err
+	}
+	id := strings.TrimSpace(args[0])
+	if id == "" {
+		return errors.New("no store id given")
+	}
+	// make sure that the store actually exists before pinning it
+	if _, err = dawg.NewStore(id, c.service(), addr); err != nil {
+		return err
+	}
+	if err = data.PinStore(c.db, addr, id); err != nil {
+		return err
+	}
+	c.Printf("pinned store %s to %s\n", id, oneLineAddr(addr))
+	return nil
+}
+
+func newStoreUnpinCmd(b cli.Builder) cli.CliCommand {
+	c := &storeUnpinCmd{storeBase: newStoreBase(b)}
+	c.CliCommand = b.Build("unpin", "Go back to using the nearest store for the current address.", c)
+	c.Cmd().Args = cobra.NoArgs
+	return c
+}
+
+// `apizza store unpin`
+type storeUnpinCmd struct {
+	cli.CliCommand
+	storeBase
+}
+
+func (c *storeUnpinCmd) Run(cmd *cobra.Command, args []string) error {
+	addr, err := c.address()
+	if err != nil {
+		return err
+	}
+	id, err := data.PinnedStore(c.db, addr)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		c.Println("No store pinned to", oneLineAddr(addr))
+		return nil
+	}
+	if err = data.UnpinStore(c.db, addr); err != nil {
+		return err
+	}
+	c.Printf("unpinned store %s from %s\n", id, oneLineAddr(addr))
+	return nil
+}
+
+func oneLineAddr(a dawg.Address) string {
+	return strings.Replace(obj.AddressFmt(a), "\n", " ", -1)
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestStoreUnpin(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	cmd := newStoreUnpinCmd(r)
+
+	tests.Check(cmd.Run(cmd.Cmd(), []string{}))
+	r.Compare(t, "No store pinned to 1600 Pennsylvania Ave NW Washington, DC 20500\n")
+	r.ClearBuf()
+
+	tests.Check(data.PinStore(r.DB(), r.Address(), "4344"))
+	tests.Check(cmd.Run(cmd.Cmd(), []string{}))
+	r.Compare(t, "unpinned store 4344 from 1600 Pennsylvania Ave NW Washington, DC 20500\n")
+	id, err := data.PinnedStore(r.DB(), r.Address())
+	tests.Check(err)
+	tests.StrEq(id, "", "store should not be pinned")
+
+	r.Conf.Address = obj.Address{}
+	tests.Exp(cmd.Run(cmd.Cmd(), []string{}))
+}
+
+func TestStoreList(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	cassette := tests.UseCassette(t, "../../dawg/testdata/cassettes/nearby-stores.json")
+	defer cassette.Eject()
+	defer dawg.SetTransport(dawg.SetTransport(cassette))
+
+	tests.Check(data.PinStore(r.DB(), r.Address(), "4328"))
+	cmd := newStoreListCmd(r)
+	tests.Check(cmd.Run(cmd.Cmd(), []string{}))
+
+	lines := strings.Split(strings.TrimSpace(r.Out.String()), "\n")
+	tests.StrEq(lines[0], "Stores near 1600 Pennsylvania Ave NW Washington, DC 20500:", "wrong header")
+	if len(lines) != 14 {
+		t.Fatalf("expected 13 stores, got %d lines", len(lines)-1)
+	}
+	if !strings.HasPrefix(lines[1], "  4336 ") {
+		t.Errorf("nearest store should be first: %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "* 4328 ") {
+		t.Errorf("pinned store should be marked: %q", lines[3])
+	}
+}
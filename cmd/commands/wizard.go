@@ -0,0 +1,319 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/harrybrwn/apizza/dawg"
+)
+
+// errWizardInput is returned when the input ends before the order is done.
+var errWizardInput = errors.New("input ended before the order was finished, the order was not saved")
+
+var toppingSides = map[string]string{
+	"full":  dawg.ToppingFull,
+	"left":  dawg.ToppingLeft,
+	"right": dawg.ToppingRight,
+}
+
+// orderWizard builds an order by asking questions one line at a time.
+type orderWizard struct {
+	menu  *dawg.Menu
+	order *dawg.Order
+	r     reader
+	w     io.Writer
+
+	// total is the sum of the menu prices of the products that have been
+	// added so far.
+	total float64
+}
+
+func newOrderWizard(menu *dawg.Menu, order *dawg.Order, in io.Reader, w io.Writer) *orderWizard {
+	return &orderWizard{
+		menu:  menu,
+		order: order,
+		r:     reader{bufio.NewReader(in)},
+		w:     w,
+	}
+}
+
+// Run walks through the food categories on the menu until the user is done.
+func (wz *orderWizard) Run() error {
+	for {
+		done, err := wz.category(wz.menu.Categorization.Food, true)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// category lets the user pick a sub-category or an item from a category.
+// Returns true when the user is done with the order.
+func (wz *orderWizard) category(cat dawg.MenuCategory, top bool) (bool, error) {
+	for {
+		if cat.HasItems() {
+			return false, wz.product(cat)
+		}
+		var subs []dawg.MenuCategory
+		var names []string
+		for _, sub := range cat.Categories {
+			if !sub.IsEmpty() && sub.Name != "" {
+				subs = append(subs, sub)
+				names = append(names, sub.Name)
+			}
+		}
+		if len(subs) == 0 {
+			return false, nil
+		}
+		prompt, stop := "Choose a category, or 'back': ", "back"
+		if top {
+			prompt, stop = "Choose a category, or 'done' to save the order: ", "done"
+		}
+		fmt.Fprintf(wz.w, "\n%s:\n", categoryName(cat))
+		i, err := wz.choose(names, prompt, stop)
+		if err != nil {
+			return false, err
+		}
+		if i < 0 {
+			return top, nil
+		}
+		if done, err := wz.category(subs[i], false); err != nil || done {
+			return done, err
+		}
+	}
+}
+
+// product lets the user pick a product and one of its variants, then adds it
+// to the order.
+func (wz *orderWizard) product(cat dawg.MenuCategory) error {
+	var (
+		products []*dawg.Product
+		names    []string
+	)
+	for _, code := range cat.Products {
+		if p, err := wz.menu.GetProduct(code); err == nil && len(p.Variants) > 0 {
+			products = append(products, p)
+			names = append(names, p.Name)
+		}
+	}
+	if len(products) == 0 {
+		fmt.Fprintln(wz.w, "Nothing in this category can be ordered.")
+		return nil
+	}
+	fmt.Fprintf(wz.w, "\n%s:\n", categoryName(cat))
+	i, err := wz.choose(names, "Choose a product, or 'back': ", "back")
+	if err != nil || i < 0 {
+		return err
+	}
+	product := products[i]
+
+	var variants []*dawg.Variant
+	names = names[:0]
+	for _, code := range product.Variants {
+		if v, err := wz.menu.GetVariant(code); err == nil {
+			variants = append(variants, v)
+			names = append(names, fmt.Sprintf("%s - $%s", v.Name, v.Price))
+		}
+	}
+	fmt.Fprintf(wz.w, "\n%s:\n", product.Name)
+	if i, err = wz.choose(names, "Choose one, or 'back': ", "back"); err != nil || i < 0 {
+		return err
+	}
+	variant := variants[i]
+
+	toppings, err := wz.toppings(product)
+	if err != nil {
+		return err
+	}
+	qty, err := wz.quantity()
+	if err != nil {
+		return err
+	}
+
+	if err = wz.order.AddProductQty(variant, qty); err != nil {
+		return err
+	}
+	p := wz.order.Products[len(wz.order.Products)-1]
+	for _, t := range toppings {
+		if err = p.AddTopping(t.code, t.side, t.amount); err != nil {
+			return err
+		}
+	}
+
+	price, _ := strconv.ParseFloat(variant.Price, 64)
+	wz.total += price * float64(qty)
+	fmt.Fprintf(wz.w, "\nAdded %d %s. Running total: about $%.2f (menu prices without extra toppings, tax, or fees)\n",
+		qty, variant.Name, wz.total)
+	return nil
+}
+
+type wizardTopping struct {
+	code, side, amount string
+}
+
+// toppings asks for the toppings to add to a product until the user enters
+// an empty line.
+func (wz *orderWizard) toppings(p *dawg.Product) ([]wizardTopping, error) {
+	var (
+		codes   []string
+		names   []string
+		amounts = map[string][]string{}
+		chosen  []wizardTopping
+	)
+	for _, t := range strings.Split(p.AvailableToppings, ",") {
+		parts := strings.SplitN(t, "=", 2)
+		code := strings.TrimSpace(parts[0])
+		top, ok := wz.menu.Toppings[p.ProductType][code]
+		if code == "" || !ok {
+			continue
+		}
+		codes = append(codes, code)
+		names = append(names, fmt.Sprintf("%s (%s)", top.Name, code))
+		if len(parts) == 2 {
+			amounts[code] = strings.Split(parts[1], ":")
+		}
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	fmt.Fprintf(wz.w, "\nToppings for %s:\n", p.Name)
+	wz.list(names)
+	for {
+		fmt.Fprint(wz.w, "Add a topping, or press enter when finished: ")
+		line, err := wz.readline()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			return chosen, nil
+		}
+		i, ok := choice(line, len(codes))
+		if !ok {
+			fmt.Fprintf(wz.w, "Enter a number from 1 to %d.\n", len(codes))
+			continue
+		}
+		t := wizardTopping{code: codes[i], side: dawg.ToppingFull, amount: "1"}
+		if p.ProductType == "Pizza" {
+			if t.side, err = wz.side(); err != nil {
+				return nil, err
+			}
+		}
+		if t.amount, err = wz.amount(amounts[t.code]); err != nil {
+			return nil, err
+		}
+		chosen = append(chosen, t)
+	}
+}
+
+func (wz *orderWizard) side() (string, error) {
+	for {
+		fmt.Fprint(wz.w, "Side (full, left, or right) [full]: ")
+		line, err := wz.readline()
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			return dawg.ToppingFull, nil
+		}
+		if side, ok := toppingSides[strings.ToLower(line)]; ok {
+			return side, nil
+		}
+		fmt.Fprintln(wz.w, "The side must be full, left, or right.")
+	}
+}
+
+// amount asks for the amount of a topping. If allowed is empty then any
+// amount from the topping amounts that dominos uses is accepted.
+func (wz *orderWizard) amount(allowed []string) (string, error) {
+	if len(allowed) == 0 {
+		allowed = []string{"0", "0.5", "1", "1.5", "2"}
+	}
+	for {
+		fmt.Fprintf(wz.w, "Amount (%s) [1]: ", strings.Join(allowed, ", "))
+		line, err := wz.readline()
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			line = "1"
+		}
+		for _, a := range allowed {
+			if line == a {
+				return line, nil
+			}
+		}
+		fmt.Fprintf(wz.w, "The amount must be one of %s.\n", strings.Join(allowed, ", "))
+	}
+}
+
+func (wz *orderWizard) quantity() (int, error) {
+	for {
+		fmt.Fprint(wz.w, "Quantity [1]: ")
+		line, err := wz.readline()
+		if err != nil {
+			return 0, err
+		}
+		if line == "" {
+			return 1, nil
+		}
+		if n, err := strconv.Atoi(line); err == nil && n > 0 {
+			return n, nil
+		}
+		fmt.Fprintln(wz.w, "The quantity must be a number greater than zero.")
+	}
+}
+
+// choose prints a numbered list and asks the user to pick from it. Returns -1
+// if the user enters stop.
+func (wz *orderWizard) choose(options []string, prompt, stop string) (int, error) {
+	wz.list(options)
+	for {
+		fmt.Fprint(wz.w, prompt)
+		line, err := wz.readline()
+		if err != nil {
+			return 0, err
+		}
+		if strings.ToLower(line) == stop {
+			return -1, nil
+		}
+		if i, ok := choice(line, len(options)); ok {
+			return i, nil
+		}
+		fmt.Fprintf(wz.w, "Enter a number from 1 to %d or '%s'.\n", len(options), stop)
+	}
+}
+
+func (wz *orderWizard) list(options []string) {
+	for i, opt := range options {
+		fmt.Fprintf(wz.w, "  %2d. %s\n", i+1, opt)
+	}
+}
+
+func (wz *orderWizard) readline() (string, error) {
+	line, err := wz.r.readline()
+	if err == io.EOF {
+		return "", errWizardInput
+	}
+	return line, err
+}
+
+// choice turns a number from a numbered list into an index.
+func choice(line string, n int) (int, bool) {
+	i, err := strconv.Atoi(line)
+	if err != nil || i < 1 || i > n {
+		return 0, false
+	}
+	return i - 1, true
+}
+
+func categoryName(cat dawg.MenuCategory) string {
+	if cat.Code == "Food" || cat.Name == "" {
+		return "Categories"
+	}
+	return cat.Name
+}
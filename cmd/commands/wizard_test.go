@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func testMenu(t *testing.T) *dawg.Menu {
+	raw, err := ioutil.ReadFile("../../dawg/testdata/menu.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	menu := &dawg.Menu{}
+	if err = json.Unmarshal(raw, menu); err != nil {
+		t.Fatal(err)
+	}
+	return menu
+}
+
+func TestOrderWizard(t *testing.T) {
+	tests.InitHelpers(t)
+	menu := testMenu(t)
+	order := &dawg.Order{}
+	in := strings.Join([]string{
+		"1", "x", "2", "back", // Pizza, bad input, Specialty Pizzas, back
+		"1", "1", "6", // Build Your Own, Pizza, Large Hand Tossed
+		"8", "left", "1.5", // Pepperoni on the left
+		"1", "", "2", "1", // Robust Inspired Tomato Sauce, bad amount
+		"", "0", "2", // no more toppings, bad quantity, two pizzas
+		"7", "back", "done", "",
+	}, "\n")
+	out := new(bytes.Buffer)
+	wz := newOrderWizard(menu, order, strings.NewReader(in), out)
+	tests.Check(wz.Run())
+
+	if len(order.Products) != 1 {
+		t.Fatalf("expected one product, got %d:\n%s", len(order.Products), out)
+	}
+	p := order.Products[0]
+	tests.StrEq(p.Code, "14SCREEN", "wrong product")
+	if p.Qty != 2 {
+		t.Errorf("expected 2 pizzas, got %d", p.Qty)
+	}
+	opts := dawg.ReadableOptions(p)
+	tests.StrEq(opts["P"], "left 1.5", "wrong pepperoni")
+	tests.StrEq(opts["X"], "full 1.0", "wrong sauce")
+	for _, s := range []string{
+		"Enter a number from 1 to 2 or 'back'.\n",
+		"The amount must be one of 0, 0.5, 1, 1.5.\n",
+		"The quantity must be a number greater than zero.\n",
+		"Added 2 Large (14\") Hand Tossed Pizza. Running total: about $27.98",
+	} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected %q in the output:\n%s", s, out)
+		}
+	}
+	v, err := menu.GetVariant("14SCREEN")
+	tests.Check(err)
+	if _, ok := v.Options()["P"]; ok {
+		t.Error("toppings should not be added to the menu")
+	}
+
+	order = &dawg.Order{}
+	wz = newOrderWizard(menu, order, strings.NewReader("1\n1\n"), new(bytes.Buffer))
+	if err = wz.Run(); err != errWizardInput {
+		t.Errorf("expected an input error, got %v", err)
+	}
+}
+
+func TestAddOrderInteractiveFlags(t *testing.T) {
+	r := cmdtest.NewTestRecorder(t)
+	defer r.CleanUp()
+	c := newAddOrderCmd(r).(*addOrderCmd)
+	tests.Check(c.Cmd().ParseFlags([]string{"--interactive", "--product", "14SCREEN"}))
+	tests.Exp(c.Run(c.Cmd(), []string{"myorder"}), "expected an error when using --product with --interactive")
+}
@@ -0,0 +1,27 @@
+package cmdtest
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+// CassettePath returns the path of a cassette in dawg/testdata/cassettes.
+func CassettePath(name string) string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "dawg", "testdata", "cassettes", name)
+}
+
+// UseCassette replays the dominos traffic recorded in a cassette for the rest
+// of a test. Defer the function that is returned.
+func UseCassette(t *testing.T, name string) func() {
+	cassette := tests.UseCassette(t, CassettePath(name))
+	old := dawg.SetTransport(cassette)
+	return func() {
+		dawg.SetTransport(old)
+		cassette.Eject()
+	}
+}
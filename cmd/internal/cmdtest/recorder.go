@@ -58,7 +58,7 @@ func NewRecorder() *Recorder {
 }
 
 // DB will return the internal database.
-func (r *Recorder) DB() *cache.DataBase {
+func (r *Recorder) DB() cache.Backend {
 	return r.DataBase
 }
 
@@ -93,7 +93,7 @@ func (r *Recorder) GlobalOptions() *opts.CliFlags {
 }
 
 // ToApp returns the arguments needed to create a cmd.App.
-func (r *Recorder) ToApp() (*cache.DataBase, *cli.Config, io.Writer) {
+func (r *Recorder) ToApp() (cache.Backend, *cli.Config, io.Writer) {
 	return r.DB(), r.Conf, r.Output()
 }
 
@@ -0,0 +1,290 @@
+package data
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/harrybrwn/apizza/pkg/cache"
+)
+
+const (
+	// ArchiveFormat identifies a file as an apizza database archive.
+	ArchiveFormat = "apizza-db"
+
+	// ArchiveVersion is the version of the archive file format.
+	ArchiveVersion = 1
+)
+
+// Archive is a copy of the whole database that can be written as json.
+type Archive struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int       `json:"schema_version"`
+	Created       time.Time `json:"created"`
+	// DefaultBucket is the database's main bucket. It is named after the
+	// database file so it is restored into the main bucket of the database
+	// being restored even if the file names are different.
+	DefaultBucket string                              `json:"default_bucket"`
+	Buckets       map[string]map[string]*ArchiveValue `json:"buckets"`
+}
+
+// ArchiveValue is one value in an archive. Only one of the fields will be
+// set depending on what the value looks like; json values are kept as json,
+// plain text is kept as a string, and everything else is base64 encoded.
+type ArchiveValue struct {
+	JSON   json.RawMessage `json:"json,omitempty"`
+	Text   *string         `json:"text,omitempty"`
+	Base64 string          `json:"base64,omitempty"`
+}
+
+// NewArchiveValue will create an ArchiveValue from raw bytes.
+func NewArchiveValue(raw []byte) *ArchiveValue {
+	if isCompactJSON(raw) {
+		return &ArchiveValue{JSON: append(json.RawMessage{}, raw...)}
+	}
+	if isText(raw) {
+		s := string(raw)
+		return &ArchiveValue{Text: &s}
+	}
+	return &ArchiveValue{Base64: base64.StdEncoding.EncodeToString(raw)}
+}
+
+// Bytes returns the raw bytes of the value.
+func (v *ArchiveValue) Bytes() ([]byte, error) {
+	switch {
+	case v.JSON != nil:
+		// undo any indentation added when the archive was written
+		buf := &bytes.Buffer{}
+		err := json.Compact(buf, v.JSON)
+		return buf.Bytes(), err
+	case v.Text != nil:
+		return []byte(*v.Text), nil
+	default:
+		return base64.StdEncoding.DecodeString(v.Base64)
+	}
+}
+
+// isCompactJSON returns true if raw is json without any extra whitespace so
+// that it can be restored exactly as it was.
+func isCompactJSON(raw []byte) bool {
+	if len(raw) == 0 || !json.Valid(raw) {
+		return false
+	}
+	buf := &bytes.Buffer{}
+	return json.Compact(buf, raw) == nil && bytes.Equal(buf.Bytes(), raw)
+}
+
+func isText(raw []byte) bool {
+	if !utf8.Valid(raw) {
+		return false
+	}
+	for _, r := range string(raw) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewArchive copies all the buckets in the database into an Archive.
+func NewArchive(db cache.Backend) (*Archive, error) {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	names, err := db.Buckets()
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{
+		Format:        ArchiveFormat,
+		Version:       ArchiveVersion,
+		SchemaVersion: version,
+		Created:       time.Now().UTC(),
+		DefaultBucket: db.DefaultBucket(),
+		Buckets:       make(map[string]map[string]*ArchiveValue, len(names)),
+	}
+	for _, name := range names {
+		all, err := db.WithBucket(name).Map()
+		if err != nil {
+			return nil, err
+		}
+		bucket := make(map[string]*ArchiveValue, len(all))
+		for k, v := range all {
+			bucket[k] = NewArchiveValue(v)
+		}
+		a.Buckets[name] = bucket
+	}
+	return a, nil
+}
+
+// Dump writes the whole database to a writer as json.
+func Dump(db cache.Backend, w io.Writer) error {
+	a, err := NewArchive(db)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// ReadArchive reads an archive written by Dump.
+func ReadArchive(r io.Reader) (*Archive, error) {
+	a := &Archive{}
+	if err := json.NewDecoder(r).Decode(a); err != nil {
+		return nil, err
+	}
+	if a.Format != ArchiveFormat {
+		return nil, errors.New("not an apizza database archive")
+	}
+	if a.Version > ArchiveVersion {
+		return nil, fmt.Errorf("archive version %d is not supported by this version of apizza", a.Version)
+	}
+	if a.SchemaVersion > LatestSchemaVersion() {
+		return nil, fmt.Errorf(
+			"archive schema version %d is newer than this version of apizza supports (%d)",
+			a.SchemaVersion, LatestSchemaVersion())
+	}
+	return a, nil
+}
+
+// Restore will write an archive to the database. If overwrite is true then
+// everything in the database is removed first, otherwise the archive is merged
+// into the database and values in the archive replace existing values.
+//
+// The database is migrated after the restore in case the archive was made
+// with an older version of apizza.
+func Restore(db cache.Backend, a *Archive, overwrite bool) error {
+	if overwrite {
+		if err := clearDatabase(db); err != nil {
+			return err
+		}
+	}
+	for name, bucket := range a.Buckets {
+		if name == a.DefaultBucket {
+			name = db.DefaultBucket()
+		}
+		for key, val := range bucket {
+			raw, err := val.Bytes()
+			if err != nil {
+				return fmt.Errorf("%s/%s: %v", name, key, err)
+			}
+			if err = db.WithBucket(name).Put(key, raw); err != nil {
+				return err
+			}
+		}
+	}
+	if _, ok := a.Buckets[a.DefaultBucket][SchemaVersionKey]; !ok {
+		// make sure the archive's data is migrated from the right version
+		if err := db.Put(SchemaVersionKey, []byte(fmt.Sprint(a.SchemaVersion))); err != nil {
+			return err
+		}
+	}
+	_, err := Migrate(db)
+	return err
+}
+
+func clearDatabase(db cache.Backend) error {
+	names, err := db.Buckets()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name != db.DefaultBucket() {
+			if err = db.DeleteBucket(name); err != nil {
+				return err
+			}
+			continue
+		}
+		all, err := db.Map()
+		if err != nil {
+			return err
+		}
+		for key := range all {
+			if err = db.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BucketStats describes the size of a bucket.
+type BucketStats struct {
+	Name string
+	Keys int
+	Size int // size of all the keys and values in bytes
+}
+
+// DBStats describes the size of the database.
+type DBStats struct {
+	Path     string
+	FileSize int64 // zero for databases that are not stored on disk
+	Buckets  []BucketStats
+}
+
+// Stats gets the size of the database and all of its buckets.
+func Stats(db cache.Backend) (*DBStats, error) {
+	names, err := db.Buckets()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	stats := &DBStats{Path: db.Path()}
+	if stats.Path != "" {
+		if stats.FileSize, err = diskUsage(stats.Path); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		all, err := db.WithBucket(name).Map()
+		if err != nil {
+			return nil, err
+		}
+		b := BucketStats{Name: name, Keys: len(all)}
+		for k, v := range all {
+			b.Size += len(k) + len(v)
+		}
+		stats.Buckets = append(stats.Buckets, b)
+	}
+	return stats, nil
+}
+
+// Sweep deletes the expired values in every bucket and returns the number of
+// values deleted. Expired menus are kept for offline use until this is run.
+func Sweep(db cache.Backend) (int, error) {
+	names, err := db.Buckets()
+	if err != nil {
+		return 0, err
+	}
+	var total int
+	for _, name := range names {
+		n, err := db.WithBucket(name).Sweep()
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, pruneMenuIndex(db)
+}
+
+// diskUsage gets the size of a file or all the files in a directory.
+func diskUsage(path string) (size int64, err error) {
+	return size, filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return err
+	})
+}
@@ -0,0 +1,118 @@
+package data
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestDumpRestore(t *testing.T) {
+	tests.InitHelpers(t)
+	values := map[string][]byte{
+		"json":   []byte(`{"name":"home","zip":"20009"}`),
+		"text":   []byte("Carryout"),
+		"binary": {0x0f, 0xff, 0x00, 0x81},
+	}
+	db := cmdtest.TempDB()
+	for k, v := range values {
+		tests.Check(db.Put(k, v))
+	}
+	tests.Check(db.PutWithTTL("ttl", []byte("menu"), time.Hour))
+	tests.Check(db.WithBucket("orders").Put("friday", []byte(`{"StoreID":"4336"}`)))
+	_, err := Migrate(db)
+	tests.Check(err)
+
+	buf := &bytes.Buffer{}
+	tests.Fatal(Dump(db, buf))
+	dump := buf.String()
+	for _, s := range []string{`"format": "apizza-db"`, `"orders": {`, `"text": "Carryout"`, `"zip": "20009"`} {
+		if !strings.Contains(dump, s) {
+			t.Errorf("dump should contain %s", s)
+		}
+	}
+	tests.Check(db.Destroy())
+
+	db = cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+	tests.Check(db.Put("extra", []byte("kept")))
+	a, err := ReadArchive(strings.NewReader(dump))
+	tests.Fatal(err)
+	tests.Check(Restore(db, a, false))
+	for k, v := range values {
+		raw, err := db.Get(k)
+		tests.Check(err)
+		if !bytes.Equal(raw, v) {
+			t.Errorf("%s: got %q, want %q", k, raw, v)
+		}
+	}
+	e, err := db.GetEntry("ttl")
+	tests.Check(err)
+	if !e.Fresh() || string(e.Value) != "menu" {
+		t.Error("ttl entries should be restored as they were")
+	}
+	raw, err := db.WithBucket("orders").Get("friday")
+	tests.Check(err)
+	tests.StrEq(string(raw), `{"StoreID":"4336"}`, "orders bucket was not restored")
+	if !db.Exists("extra") {
+		t.Error("merge should keep keys that are not in the archive")
+	}
+
+	tests.Check(Restore(db, a, true))
+	if db.Exists("extra") {
+		t.Error("overwrite should remove keys that are not in the archive")
+	}
+	if !db.Exists("json") {
+		t.Error("overwrite should still restore the archive")
+	}
+
+	stats, err := Stats(db)
+	tests.Check(err)
+	for _, b := range stats.Buckets {
+		if b.Name == "orders" && b.Keys != 1 {
+			t.Errorf("expected 1 key in orders, got %d", b.Keys)
+		}
+	}
+
+	_, err = ReadArchive(strings.NewReader(`{"format":"apizza-db","version":1,"schema_version":1000}`))
+	if err == nil {
+		t.Error("expected an error for an archive from a newer version")
+	}
+	_, err = ReadArchive(strings.NewReader(`{"menu":"..."}`))
+	if err == nil {
+		t.Error("expected an error for a file that is not an archive")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+
+	old, fresh := MenuKey("4336", "en"), MenuKey("4344", "en")
+	tests.Check(db.PutWithTTL(old, []byte("old menu"), -time.Minute))
+	tests.Check(db.PutWithTTL(fresh, []byte("new menu"), time.Hour))
+	tests.Check(putMenuIndex(db, []string{old, fresh}))
+	tests.Check(db.Put("plain", []byte("no ttl")))
+	tests.Check(db.WithBucket("other").PutWithTTL("expired", []byte("x"), -time.Minute))
+
+	n, err := Sweep(db)
+	tests.Check(err)
+	if n != 2 {
+		t.Errorf("expected 2 expired values, got %d", n)
+	}
+	if db.Exists(old) || db.WithBucket("other").Exists("expired") {
+		t.Error("expired values should be deleted")
+	}
+	if !db.Exists(fresh) || !db.Exists("plain") {
+		t.Error("only expired values should be deleted")
+	}
+	index, err := menuIndex(db)
+	tests.Check(err)
+	if len(index) != 1 || index[0] != fresh {
+		t.Errorf("expired menus should be removed from the index: %v", index)
+	}
+}
@@ -0,0 +1,207 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/cache"
+)
+
+const (
+	// HistoryBucket is the database bucket that holds every order that was
+	// sent to dominos. The key is the entry's id.
+	HistoryBucket = "history"
+
+	historyIDFmt = "20060102-150405"
+)
+
+// Outcomes of a placed order.
+const (
+	OrderSucceeded = "placed"
+	OrderFailed    = "failed"
+)
+
+// HistoryEntry is the record of an order that was sent to dominos.
+type HistoryEntry struct {
+	ID      string    `json:"id" yaml:"id"`
+	Time    time.Time `json:"time" yaml:"time"`
+	Order   string    `json:"order" yaml:"order"`
+	StoreID string    `json:"store_id" yaml:"store_id"`
+	Service string    `json:"service" yaml:"service"`
+	// Source is the command that placed the order.
+	Source   string             `json:"source,omitempty" yaml:"source,omitempty"`
+	Address  *out.Address       `json:"address,omitempty" yaml:"address,omitempty"`
+	Products []out.OrderProduct `json:"products" yaml:"products"`
+	Total    float64            `json:"total" yaml:"total"`
+	OrderID  string             `json:"order_id,omitempty" yaml:"order_id,omitempty"`
+	Outcome  string             `json:"outcome" yaml:"outcome"`
+	Error    string             `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Succeeded returns true if the order was accepted by dominos.
+func (h *HistoryEntry) Succeeded() bool {
+	return h.Outcome == OrderSucceeded
+}
+
+// NewHistoryEntry creates the record of an order that was placed at time t.
+// The error is the result of placing the order.
+func NewHistoryEntry(t time.Time, o *dawg.Order, total float64, source string, err error) *HistoryEntry {
+	order := out.NewOrder(o, total, false)
+	h := &HistoryEntry{
+		Time:     t,
+		Order:    order.Name,
+		StoreID:  order.StoreID,
+		Service:  order.Service,
+		Source:   source,
+		Address:  order.Address,
+		Products: order.Products,
+		Total:    total,
+		OrderID:  o.OrderID,
+		Outcome:  OrderSucceeded,
+	}
+	if err != nil {
+		h.Outcome = OrderFailed
+		h.Error = err.Error()
+	}
+	return h
+}
+
+// RecordOrder adds an order that was sent to dominos to the history.
+func RecordOrder(db cache.Backend, o *dawg.Order, total float64, source string, err error) (*HistoryEntry, error) {
+	h := NewHistoryEntry(time.Now(), o, total, source, err)
+	return h, SaveHistory(db, h)
+}
+
+// SaveHistory stores a history entry. If the entry has no id then it is given
+// one based on its time.
+func SaveHistory(db cache.Backend, h *HistoryEntry) error {
+	if h.ID == "" {
+		id := h.Time.Format(historyIDFmt)
+		h.ID = id
+		for i := 2; db.WithBucket(HistoryBucket).Exists(h.ID); i++ {
+			h.ID = fmt.Sprintf("%s-%d", id, i)
+		}
+	}
+	raw, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return db.WithBucket(HistoryBucket).Put(h.ID, raw)
+}
+
+// GetHistory gets one entry from the order history.
+func GetHistory(db cache.Backend, id string) (*HistoryEntry, error) {
+	raw, err := db.WithBucket(HistoryBucket).Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("no order '%s' in the history", id)
+	}
+	h := &HistoryEntry{}
+	return h, json.Unmarshal(raw, h)
+}
+
+// HistoryFilter selects entries from the order history. Zero values match
+// everything.
+type HistoryFilter struct {
+	// From and To are the range of times, To is not included.
+	From, To time.Time
+	StoreID  string
+}
+
+// Match returns true if the entry is selected by the filter.
+func (f HistoryFilter) Match(h *HistoryEntry) bool {
+	if !f.From.IsZero() && h.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !h.Time.Before(f.To) {
+		return false
+	}
+	return f.StoreID == "" || f.StoreID == h.StoreID
+}
+
+// History returns the entries in the order history that match the filter,
+// oldest first.
+func History(db cache.Backend, filter HistoryFilter) ([]*HistoryEntry, error) {
+	all, err := db.WithBucket(HistoryBucket).Map()
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*HistoryEntry, 0, len(all))
+	for _, raw := range all {
+		h := &HistoryEntry{}
+		if err = json.Unmarshal(raw, h); err != nil {
+			return nil, err
+		}
+		if filter.Match(h) {
+			history = append(history, h)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].Time.Equal(history[j].Time) {
+			return history[i].ID < history[j].ID
+		}
+		return history[i].Time.Before(history[j].Time)
+	})
+	return history, nil
+}
+
+// Spending periods.
+const (
+	Weekly  = "week"
+	Monthly = "month"
+)
+
+// Spending is the money spent on orders during one week or month.
+type Spending struct {
+	Start  time.Time `json:"start" yaml:"start"`
+	Orders int       `json:"orders" yaml:"orders"`
+	Total  float64   `json:"total" yaml:"total"`
+}
+
+// SpendingPer adds up the orders that succeeded for each week or month,
+// oldest first. Weeks start on monday.
+func SpendingPer(period string, history []*HistoryEntry) ([]Spending, error) {
+	var start func(time.Time) time.Time
+	switch period {
+	case Weekly:
+		start = func(t time.Time) time.Time {
+			days := (int(t.Weekday()) + 6) % 7
+			return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, t.Location())
+		}
+	case Monthly:
+		start = func(t time.Time) time.Time {
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		}
+	default:
+		return nil, fmt.Errorf("unknown period '%s', use %s or %s", period, Weekly, Monthly)
+	}
+
+	periods := make(map[time.Time]*Spending)
+	for _, h := range history {
+		if !h.Succeeded() {
+			continue
+		}
+		t := start(h.Time.Local())
+		s, ok := periods[t]
+		if !ok {
+			s = &Spending{Start: t}
+			periods[t] = s
+		}
+		s.Orders++
+		s.Total += h.Total
+	}
+	spending := make([]Spending, 0, len(periods))
+	for _, s := range periods {
+		spending = append(spending, *s)
+	}
+	sort.Slice(spending, func(i, j int) bool {
+		return spending[i].Start.Before(spending[j].Start)
+	})
+	return spending, nil
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func day(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestHistory(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+
+	o := cmdtest.NewTestOrder()
+	o.OrderID = "abc123"
+	o.Products = append(o.Products, &dawg.OrderProduct{
+		ItemCommon: dawg.ItemCommon{Code: "14SCREEN", Name: "Large Pizza"},
+		Qty:        2,
+		Opts:       map[string]interface{}{"P": map[string]string{"1/1": "1.0"}},
+	})
+
+	h, err := RecordOrder(db, o, 21.5, "order", nil)
+	tests.Fatal(err)
+	if h.ID == "" || !h.Succeeded() {
+		t.Errorf("bad history entry: %+v", h)
+	}
+	got, err := GetHistory(db, h.ID)
+	tests.Fatal(err)
+	if got.Order != cmdtest.OrderName || got.StoreID != "4336" || got.Service != dawg.Delivery ||
+		got.Total != 21.5 || got.OrderID != "abc123" || got.Source != "order" {
+		t.Errorf("wrong history entry: %+v", got)
+	}
+	if len(got.Products) != 1 || got.Products[0].Quantity != 2 || got.Products[0].Options["P"] != "full 1.0" {
+		t.Errorf("wrong products: %+v", got.Products)
+	}
+	if got.Address == nil || got.Address.Zipcode != "20500" {
+		t.Error("the address should be saved")
+	}
+	_, err = GetHistory(db, "nope")
+	tests.Exp(err, "should not find a missing entry")
+
+	// entries at the same time get different ids
+	failed := NewHistoryEntry(h.Time, o, 0, "daemon", errors.New("store closed"))
+	tests.Check(SaveHistory(db, failed))
+	if failed.ID == h.ID {
+		t.Error("entries should have unique ids")
+	}
+	if failed.Succeeded() || failed.Error != "store closed" {
+		t.Errorf("wrong failed entry: %+v", failed)
+	}
+	all, err := History(db, HistoryFilter{})
+	tests.Check(err)
+	if len(all) != 2 || all[0].ID != h.ID || all[1].ID != failed.ID {
+		t.Errorf("wrong history: %v", all)
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+
+	for _, h := range []*HistoryEntry{
+		{Time: day("2020-05-04 12:00"), StoreID: "4336", Total: 10, Outcome: OrderSucceeded},
+		{Time: day("2020-05-08 12:00"), StoreID: "4336", Total: 20, Outcome: OrderSucceeded},
+		{Time: day("2020-05-08 13:00"), StoreID: "4336", Total: 99, Outcome: OrderFailed},
+		{Time: day("2020-05-11 12:00"), StoreID: "4344", Total: 15, Outcome: OrderSucceeded},
+		{Time: day("2020-06-01 12:00"), StoreID: "4336", Total: 5, Outcome: OrderSucceeded},
+	} {
+		tests.Fatal(SaveHistory(db, h))
+	}
+
+	for _, tt := range []struct {
+		filter HistoryFilter
+		n      int
+	}{
+		{HistoryFilter{}, 5},
+		{HistoryFilter{StoreID: "4336"}, 4},
+		{HistoryFilter{From: day("2020-05-08 00:00")}, 4},
+		{HistoryFilter{To: day("2020-05-08 00:00")}, 1},
+		{HistoryFilter{From: day("2020-05-05 00:00"), To: day("2020-06-01 00:00"), StoreID: "4344"}, 1},
+	} {
+		history, err := History(db, tt.filter)
+		tests.Check(err)
+		if len(history) != tt.n {
+			t.Errorf("%+v: got %d entries, want %d", tt.filter, len(history), tt.n)
+		}
+	}
+
+	all, err := History(db, HistoryFilter{})
+	tests.Fatal(err)
+	weeks, err := SpendingPer(Weekly, all)
+	tests.Fatal(err)
+	if len(weeks) != 3 {
+		t.Fatalf("expected 3 weeks, got %v", weeks)
+	}
+	if !weeks[0].Start.Equal(day("2020-05-04 00:00")) || weeks[0].Orders != 2 || weeks[0].Total != 30 {
+		t.Errorf("wrong first week: %+v", weeks[0])
+	}
+	if !weeks[1].Start.Equal(day("2020-05-11 00:00")) || weeks[1].Total != 15 {
+		t.Errorf("wrong second week: %+v", weeks[1])
+	}
+	months, err := SpendingPer(Monthly, all)
+	tests.Fatal(err)
+	if len(months) != 2 || months[0].Orders != 3 || months[0].Total != 45 || months[1].Total != 5 {
+		t.Errorf("wrong months: %+v", months)
+	}
+	_, err = SpendingPer("year", all)
+	tests.Exp(err, "should not know about years")
+}
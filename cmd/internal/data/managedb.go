@@ -2,9 +2,11 @@ package data
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/harrybrwn/apizza/cmd/internal/out"
@@ -22,10 +24,100 @@ const (
 	DataBaseName = "apizza.db"
 )
 
-// OpenDatabase make the default database.
+// OpenDatabase make the default database and runs any pending migrations.
 func OpenDatabase() (*cache.DataBase, error) {
-	dbPath := filepath.Join(config.Folder(), "cache", DataBaseName)
-	return cache.GetDB(dbPath)
+	db, err := OpenUnmigratedDatabase()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = Migrate(db); err != nil {
+		return nil, errs.Pair(err, db.Close())
+	}
+	return db, nil
+}
+
+// OpenUnmigratedDatabase opens the default database without running any
+// migrations.
+func OpenUnmigratedDatabase() (*cache.DataBase, error) {
+	return cache.GetDB(DatabasePath())
+}
+
+// Storage backends that can be used in the config file.
+const (
+	BoltBackend   = "bolt"
+	MemoryBackend = "memory"
+	DirBackend    = "dir"
+)
+
+// NewDatabase returns the database for a storage backend. An empty path will
+// use the default location for the backend. The bolt database is not opened
+// until it is first used (see LazyDatabase). If migrate is true then any
+// pending migrations are run when the database is opened.
+func NewDatabase(backend, path string, migrate bool) (db cache.Backend, err error) {
+	switch backend {
+	case "", BoltBackend:
+		if path == "" {
+			path = DatabasePath()
+		}
+		return lazyDatabase(path, migrate), nil
+	case MemoryBackend:
+		db = cache.NewMemoryDB(filename(DataBaseName))
+	case DirBackend:
+		if path == "" {
+			path = filepath.Join(config.Folder(), "cache", filename(DataBaseName))
+		}
+		if db, err = cache.OpenDirDB(path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected %s, %s, or %s)",
+			backend, BoltBackend, MemoryBackend, DirBackend)
+	}
+	if migrate {
+		if _, err = Migrate(db); err != nil {
+			return nil, errs.Pair(err, db.Close())
+		}
+	}
+	return db, nil
+}
+
+// LazyDatabase returns the default database without opening it. The database
+// is opened the first time it is used and if migrate is true any pending
+// migrations are run when it is opened.
+func LazyDatabase(migrate bool) *cache.DataBase {
+	return lazyDatabase(DatabasePath(), migrate)
+}
+
+func lazyDatabase(path string, migrate bool) *cache.DataBase {
+	opts := &cache.Options{Timeout: cache.DefaultTimeout}
+	if migrate {
+		opts.OnOpen = migrateOnOpen
+	}
+	return cache.Lazy(path, opts)
+}
+
+// DatabasePath is the path to the default database.
+func DatabasePath() string {
+	return filepath.Join(config.Folder(), "cache", DataBaseName)
+}
+
+func filename(file string) string {
+	return strings.TrimSuffix(file, filepath.Ext(file))
+}
+
+func migrateOnOpen(db *cache.DataBase) error {
+	if !db.ReadOnly() {
+		_, err := Migrate(db)
+		return err
+	}
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return errors.New("the database needs to be updated (run 'apizza db migrate')")
+	}
+	return nil
 }
 
 // ListOrders will return a list of orders stored in the database.
@@ -40,8 +132,34 @@ func ListOrders(db cache.MapDB) []string {
 	return names
 }
 
+// Orders returns all the orders saved in the database sorted by name.
+func Orders(db cache.MapDB) ([]*dawg.Order, error) {
+	all, err := db.Map()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		if strings.HasPrefix(k, OrderPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	orders := make([]*dawg.Order, 0, len(keys))
+	for _, k := range keys {
+		order := &dawg.Order{}
+		order.Init()
+		order.SetName(strings.TrimPrefix(k, OrderPrefix))
+		if err = json.Unmarshal(all[k], order); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
 // PrintOrders will print all the names of the saved user orders
-func PrintOrders(db cache.MapDB, w io.Writer, verbose bool, color string) error {
+func PrintOrders(db cache.MapDB, w io.Writer, verbose bool) error {
 	all, err := db.Map()
 	if err != nil {
 		return err
@@ -74,22 +192,15 @@ func PrintOrders(db cache.MapDB, w io.Writer, verbose bool, color string) error
 		return nil
 	}
 
-	var yesColor bool
-	var endcolor = ""
-	if color != "" {
-		yesColor = true
-		endcolor = "\033[0m"
-
-	}
-	fmt.Fprintf(w, "%sYour Orders%s:\n", color, endcolor)
+	fmt.Fprintf(w, "%s:\n", out.Heading("Your Orders"))
 	for i, o := range orders {
 		if verbose {
-			err = out.PrintOrder(uOrders[i], false, yesColor, false)
+			err = out.PrintOrder(uOrders[i], false, false)
 			if err != nil {
 				return err
 			}
 		} else {
-			fmt.Fprintln(w, " ", o)
+			fmt.Fprintln(w, " ", out.Name(o))
 		}
 	}
 	return nil
@@ -112,11 +223,7 @@ func GetOrder(name string, db cache.Getter) (*dawg.Order, error) {
 // Also sends the order to the validation endpoint after saving it to the
 // cache.Putter.
 func SaveOrder(o *dawg.Order, w io.Writer, db cache.Putter) error {
-	raw, err := json.Marshal(o)
-	if err != nil {
-		return err
-	}
-	err = db.Put(OrderPrefix+o.Name(), raw)
+	err := PutOrder(o, db)
 	if err == nil {
 		fmt.Fprintln(w, "order successfully updated.")
 	} else {
@@ -128,3 +235,12 @@ func SaveOrder(o *dawg.Order, w io.Writer, db cache.Putter) error {
 	}
 	return nil
 }
+
+// PutOrder saves an order to a database without validating it.
+func PutOrder(o *dawg.Order, db cache.Putter) error {
+	raw, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return db.Put(OrderPrefix+o.Name(), raw)
+}
@@ -3,25 +3,39 @@ package data
 import (
 	"bytes"
 	"log"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
-	"github.com/harrybrwn/apizza/cmd/internal/obj"
 	"github.com/harrybrwn/apizza/dawg"
 	"github.com/harrybrwn/apizza/pkg/tests"
 )
 
 var testStore *dawg.Store
 
-func init() {
-	a := &obj.Address{
-		Street:   "1600 Pennsylvania Ave NW",
-		CityName: "Washington",
-		State:    "DC",
-		Zipcode:  "20500",
+// TestMain loads the testing store from a cassette so that the tests can run
+// offline. The cassette is used until all the tests have finished because the
+// menu is only downloaded when a test asks for it.
+func TestMain(m *testing.M) {
+	cassette, err := tests.NewCassette(cmdtest.CassettePath("data-store.json"), tests.EnvMode(), nil)
+	if err != nil {
+		panic(err)
 	}
-	testStore, _ = dawg.NearestStore(a, "Delivery")
+	old := dawg.SetTransport(cassette)
+	testStore, err = dawg.NearestStore(cmdtest.TestAddress(), dawg.Delivery)
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	dawg.SetTransport(old)
+	if cassette.Mode() == tests.Record {
+		if err = cassette.Save(); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(code)
 }
 
 func TestDBManagement(t *testing.T) {
@@ -32,7 +46,7 @@ func TestDBManagement(t *testing.T) {
 	o.SetName("test_order")
 	buf := &bytes.Buffer{}
 
-	tests.Check(PrintOrders(db, buf, false, ""))
+	tests.Check(PrintOrders(db, buf, false))
 	tests.Compare(t, buf.String(), "No orders saved.\n")
 	buf.Reset()
 
@@ -40,7 +54,7 @@ func TestDBManagement(t *testing.T) {
 	tests.Compare(t, buf.String(), "order successfully updated.\n")
 	buf.Reset()
 
-	tests.Check(PrintOrders(db, buf, false, ""))
+	tests.Check(PrintOrders(db, buf, false))
 	tests.Compare(t, buf.String(), "Your Orders:\n  test_order\n")
 	buf.Reset()
 
@@ -71,7 +85,7 @@ func TestPrintOrders(t *testing.T) {
 	buf := new(bytes.Buffer)
 	tests.Check(SaveOrder(o, buf, db))
 	buf.Reset()
-	tests.Check(PrintOrders(db, buf, true, ""))
+	tests.Check(PrintOrders(db, buf, true))
 	tests.Compare(t, buf.String(), "Your Orders:\n  test_order -  10SCREEN, \n")
 }
 
@@ -82,7 +96,7 @@ func TestMenuCacherJSON(t *testing.T) {
 	db := cmdtest.TempDB()
 	defer db.Destroy()
 
-	cacher := NewMenuCacher(time.Second, db, func() *dawg.Store { return testStore })
+	cacher := NewMenuCacher(time.Second, db, func() (*dawg.Store, error) { return testStore, nil })
 	var buf bytes.Buffer
 	log.SetFlags(0)
 	log.SetOutput(&buf)
@@ -95,14 +109,14 @@ func TestMenuCacherJSON(t *testing.T) {
 		t.Error("cacher should not have a menu yet")
 	}
 
-	tests.Check(db.UpdateTS("menu", cacher))
+	tests.Check(cacher.UpdateMenu())
 	if c.m == nil {
 		t.Error("cacher should have a menu now")
 	}
 	if cacher.Menu() == nil {
 		t.Error("cacher should have a menu now")
 	}
-	data, err := db.Get("menu")
+	data, err := db.Get(MenuKey(testStore.ID, dawg.DefaultLang))
 	tests.Check(err)
 	if len(data) == 0 {
 		t.Error("should have stored a menu")
@@ -113,7 +127,32 @@ func TestMenuCacherJSON(t *testing.T) {
 	}
 	buf.Reset()
 
-	tests.Check(db.UpdateTS("menu", cacher))
+	tests.Check(cacher.UpdateMenu())
 	c.m = nil
-	tests.Check(db.UpdateTS("menu", c))
+	tests.Check(c.UpdateMenu())
+}
+
+func TestNewDatabase(t *testing.T) {
+	tests.InitHelpers(t)
+	dir := tests.TempDir()
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct{ backend, path string }{
+		{MemoryBackend, ""},
+		{DirBackend, filepath.Join(dir, "apizza")},
+		{BoltBackend, filepath.Join(dir, "apizza.db")},
+	} {
+		db, err := NewDatabase(tc.backend, tc.path, true)
+		tests.Fatal(err)
+		tests.StrEq(db.Path(), tc.path, "wrong path for %s backend", tc.backend)
+		tests.StrEq(db.DefaultBucket(), "apizza", "wrong default bucket for %s backend", tc.backend)
+		version, err := SchemaVersion(db)
+		tests.Check(err)
+		if version != LatestSchemaVersion() {
+			t.Errorf("%s backend should be migrated", tc.backend)
+		}
+		tests.Check(db.Close())
+	}
+	_, err := NewDatabase("sqlite", "", true)
+	tests.Exp(err, "expected an error for an unknown backend")
 }
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"time"
@@ -16,15 +18,55 @@ import (
 // MenuCacher defines an interface that retrieves, caches, and stores
 // menu timestamps.
 type MenuCacher interface {
-	cache.Updater
+	// UpdateMenu will get the menu for the current store, it will only be
+	// downloaded if there is no cached menu for the store or if the cached
+	// menu is too old.
+	UpdateMenu() error
+
 	Menu() *dawg.Menu
+
+	// LoadCached will load a store's menu from the cache without checking
+	// for updates or using the network.
+	LoadCached(storeID string) error
+
+	// CacheMenu stores a menu as the most recently used menu.
+	CacheMenu(*dawg.Menu) error
+
+	// CachedAt returns the time that the current menu was cached.
+	CachedAt() (time.Time, error)
+}
+
+const (
+	// MenuPrefix is the prefix of all the database keys for cached menus.
+	MenuPrefix = "menu_"
+
+	// MaxCachedMenus is the number of menus that are kept in the cache. The
+	// least recently used menu is removed when another store's menu is
+	// cached.
+	MaxCachedMenus = 5
+
+	// menuIndexKey stores the cached menus in order of most recent use.
+	menuIndexKey = "menu_index"
+
+	// legacyMenuKey is where the menu used to be cached before menus were
+	// cached by store.
+	legacyMenuKey = "menu"
+)
+
+// ErrNoCachedMenu is returned when the menu is needed from the cache but
+// there is no menu stored.
+var ErrNoCachedMenu = errors.New("no menu has been cached (run 'apizza menu' while online)")
+
+// MenuKey returns the database key used to cache a store's menu.
+func MenuKey(storeID, lang string) string {
+	return MenuPrefix + storeID + "_" + lang
 }
 
 // NewMenuCacher creates a new MenuCacher.
 func NewMenuCacher(
 	decay time.Duration,
-	db cache.Storage,
-	store func() *dawg.Store,
+	db cache.Backend,
+	store func() (*dawg.Store, error),
 ) MenuCacher {
 	// use gob to cache the menu in binary format
 	return NewGobMenuCacher(decay, db, store)
@@ -47,10 +89,13 @@ type Decoder interface {
 }
 
 type generalMenuCacher struct {
-	cache.Updater
+	decay    time.Duration
+	max      int
 	m        *dawg.Menu
-	db       cache.Storage
-	getstore func() *dawg.Store
+	key      string
+	stored   time.Time
+	db       cache.Backend
+	getstore func() (*dawg.Store, error)
 
 	newEncoder func(io.Writer) Encoder
 	newDecoder func(io.Reader) Decoder
@@ -60,36 +105,36 @@ type generalMenuCacher struct {
 // menu as json.
 func NewJSONMenuCacher(
 	decay time.Duration,
-	db cache.Storage,
-	store func() *dawg.Store,
+	db cache.Backend,
+	store func() (*dawg.Store, error),
 ) MenuCacher {
-	mc := &generalMenuCacher{
+	return &generalMenuCacher{
+		decay:      decay,
+		max:        MaxCachedMenus,
 		m:          nil,
 		db:         db,
 		getstore:   store,
 		newEncoder: func(w io.Writer) Encoder { return json.NewEncoder(w) },
 		newDecoder: func(r io.Reader) Decoder { return json.NewDecoder(r) },
 	}
-	mc.Updater = cache.NewUpdater(decay, mc.cacheNewMenu, mc.getCachedMenu)
-	return mc
 }
 
 // NewGobMenuCacher will create a MenuCacher that will store the menu
 // in a binary format using the "encoding/gob" package.
 func NewGobMenuCacher(
 	decay time.Duration,
-	db cache.Storage,
-	store func() *dawg.Store,
+	db cache.Backend,
+	store func() (*dawg.Store, error),
 ) MenuCacher {
-	mc := &generalMenuCacher{
+	return &generalMenuCacher{
+		decay:      decay,
+		max:        MaxCachedMenus,
 		m:          nil,
 		db:         db,
 		getstore:   store,
 		newEncoder: func(w io.Writer) Encoder { return gob.NewEncoder(w) },
 		newDecoder: func(r io.Reader) Decoder { return gob.NewDecoder(r) },
 	}
-	mc.Updater = cache.NewUpdater(decay, mc.cacheNewMenu, mc.getCachedMenu)
-	return mc
 }
 
 func (mc *generalMenuCacher) Menu() *dawg.Menu {
@@ -99,35 +144,173 @@ func (mc *generalMenuCacher) Menu() *dawg.Menu {
 	return nil
 }
 
-func (mc *generalMenuCacher) cacheNewMenu() error {
-	var e1, e2 error
-	mc.m, e1 = mc.getstore().Menu()
+func (mc *generalMenuCacher) UpdateMenu() error {
+	store, err := mc.getstore()
+	if err != nil {
+		return err
+	}
+	key := MenuKey(store.ID, dawg.DefaultLang)
+	ok, err := mc.getCachedMenu(store, key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err = mc.cacheNewMenu(store, key); err != nil {
+			return err
+		}
+	}
+	return mc.touch(key)
+}
+
+func (mc *generalMenuCacher) LoadCached(storeID string) error {
+	if storeID == "" {
+		return ErrNoCachedMenu
+	}
+	key := MenuKey(storeID, dawg.DefaultLang)
+	e, err := mc.db.GetEntry(key)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return fmt.Errorf("no menu has been cached for store %s (run 'apizza menu' while online)", storeID)
+	}
+	return mc.use(key, e)
+}
+
+func (mc *generalMenuCacher) CacheMenu(m *dawg.Menu) error {
+	key := MenuKey(m.ID, dawg.DefaultLang)
+	if err := mc.save(key, m); err != nil {
+		return err
+	}
+	return mc.touch(key)
+}
+
+func (mc *generalMenuCacher) CachedAt() (time.Time, error) {
+	if mc.key == "" {
+		return time.Time{}, ErrNoCachedMenu
+	}
+	return mc.stored, nil
+}
+
+func (mc *generalMenuCacher) cacheNewMenu(store *dawg.Store, key string) error {
+	m, err := store.Menu()
+	if err != nil {
+		return err
+	}
 	log.Println("caching another menu")
+	return mc.save(key, m)
+}
+
+// getCachedMenu will use the cached menu for a store if it has not expired
+// and returns false if there is no fresh menu for the store.
+func (mc *generalMenuCacher) getCachedMenu(store *dawg.Store, key string) (bool, error) {
+	e, err := mc.db.GetEntry(key)
+	if err != nil || e == nil || !e.Fresh() {
+		return false, err
+	}
+	if err = mc.use(key, e); err != nil {
+		return false, err
+	}
+	return mc.m.ID == store.ID, nil
+}
 
+func (mc *generalMenuCacher) use(key string, e *cache.Entry) error {
+	m := new(dawg.Menu)
+	if err := mc.newDecoder(bytes.NewBuffer(e.Value)).Decode(m); err != nil {
+		return err
+	}
+	mc.m, mc.key, mc.stored = m, key, e.Stored
+	return nil
+}
+
+func (mc *generalMenuCacher) save(key string, m *dawg.Menu) error {
 	buf := &bytes.Buffer{}
-	e2 = mc.newEncoder(buf).Encode(mc.m)
-	return errs.Append(e1, e2, mc.db.Put("menu", buf.Bytes()))
+	if err := mc.newEncoder(buf).Encode(m); err != nil {
+		return err
+	}
+	if err := mc.db.PutWithTTL(key, buf.Bytes(), mc.decay); err != nil {
+		return err
+	}
+	mc.m, mc.key, mc.stored = m, key, time.Now()
+	return nil
 }
 
-func (mc *generalMenuCacher) getCachedMenu() error {
-	if mc.m == nil {
-		mc.m = new(dawg.Menu)
-		raw, err := mc.db.Get("menu")
-		if raw == nil {
-			return mc.cacheNewMenu()
+// touch marks a menu as the most recently used and removes the least
+// recently used menus if there are too many.
+func (mc *generalMenuCacher) touch(key string) error {
+	index, err := menuIndex(mc.db)
+	if err != nil {
+		return err
+	}
+	keys := []string{key}
+	for _, k := range index {
+		if k != key {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) > mc.max {
+		for _, k := range keys[mc.max:] {
+			log.Println("removing old menu", k)
+			if err = mc.db.Delete(k); err != nil {
+				return err
+			}
 		}
+		keys = keys[:mc.max]
+	}
+	return putMenuIndex(mc.db, keys)
+}
 
-		dec := mc.newDecoder(bytes.NewBuffer(raw))
-		err = errs.Pair(err, dec.Decode(mc.m))
-		if err != nil {
+// DeleteMenus will remove all the cached menus from the database.
+func DeleteMenus(db cache.Backend) error {
+	index, err := menuIndex(db)
+	if err != nil {
+		return err
+	}
+	for _, key := range index {
+		if err = db.Delete(key); err != nil {
 			return err
 		}
+	}
+	return errs.Pair(
+		db.Delete(menuIndexKey),
+		errs.Pair(db.Delete(legacyMenuKey), db.DeleteTimeStamp(legacyMenuKey)),
+	)
+}
 
-		if mc.m.ID != mc.getstore().ID {
-			return mc.cacheNewMenu()
+// pruneMenuIndex removes the menus that are no longer in the database from
+// the menu index.
+func pruneMenuIndex(db cache.Backend) error {
+	index, err := menuIndex(db)
+	if err != nil || index == nil {
+		return err
+	}
+	keys := make([]string, 0, len(index))
+	for _, k := range index {
+		if db.Exists(k) {
+			keys = append(keys, k)
 		}
 	}
-	return nil
+	if len(keys) == len(index) {
+		return nil
+	}
+	return putMenuIndex(db, keys)
+}
+
+func menuIndex(db cache.Backend) ([]string, error) {
+	raw, err := db.Get(menuIndexKey)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var keys []string
+	return keys, json.Unmarshal(raw, &keys)
+}
+
+func putMenuIndex(db cache.Backend, keys []string) error {
+	raw, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return db.Put(menuIndexKey, raw)
 }
 
 var _ MenuCacher = (*generalMenuCacher)(nil)
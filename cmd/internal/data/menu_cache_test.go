@@ -0,0 +1,93 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestMenuCacherPerStore(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+
+	var storeID string
+	mc := NewMenuCacher(time.Hour, db, func() (*dawg.Store, error) {
+		return &dawg.Store{ID: storeID}, nil
+	}).(*generalMenuCacher)
+	mc.max = 3
+
+	tests.Exp(mc.LoadCached("1"), "should not have a cached menu yet")
+	for i := 1; i <= 4; i++ {
+		tests.Check(mc.CacheMenu(&dawg.Menu{ID: fmt.Sprint(i)}))
+	}
+	index, err := menuIndex(db)
+	tests.Check(err)
+	tests.Compare(t, fmt.Sprint(index), "[menu_4_en menu_3_en menu_2_en]")
+	if db.Exists(MenuKey("1", dawg.DefaultLang)) {
+		t.Error("the least recently used menu should have been removed")
+	}
+
+	// the menu for store 2 is fresh so it should be loaded from the cache
+	storeID = "2"
+	tests.Check(mc.UpdateMenu())
+	tests.StrEq(mc.Menu().ID, "2", "got the menu for the wrong store")
+	index, err = menuIndex(db)
+	tests.Check(err)
+	tests.Compare(t, fmt.Sprint(index), "[menu_2_en menu_4_en menu_3_en]")
+	if _, err = mc.CachedAt(); err != nil {
+		t.Error(err)
+	}
+
+	mc = NewMenuCacher(time.Hour, db, nil).(*generalMenuCacher)
+	tests.Check(mc.LoadCached("3"))
+	tests.StrEq(mc.Menu().ID, "3", "should load the menu for the store")
+	tests.Exp(mc.LoadCached("4344"), "should not load another store's menu")
+	tests.Exp(mc.LoadCached(""), "should need a store")
+
+	tests.Check(db.Put(legacyMenuKey, []byte("old menu")))
+	tests.Check(DeleteMenus(db))
+	for _, key := range []string{menuIndexKey, legacyMenuKey, MenuKey("2", dawg.DefaultLang)} {
+		if db.Exists(key) {
+			t.Errorf("%s should have been deleted", key)
+		}
+	}
+	tests.Exp(mc.LoadCached("2"), "all the menus should be deleted")
+}
+
+type errTransport struct{ requests int }
+
+func (et *errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	et.requests++
+	return nil, errors.New("no network in tests")
+}
+
+func TestMenuCacherStale(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+	tr := &errTransport{}
+	defer dawg.SetTransport(dawg.SetTransport(tr))
+	dawg.SetRetryPolicy(nil)
+	defer dawg.SetRetryPolicy(&dawg.DefaultRetryPolicy)
+
+	mc := NewMenuCacher(0, db, func() (*dawg.Store, error) {
+		s := &dawg.Store{ID: "4336"}
+		s.Init(dawg.Delivery, cmdtest.TestAddress())
+		return s, nil
+	})
+	tests.Check(mc.CacheMenu(&dawg.Menu{ID: "4336"}))
+	tests.Exp(mc.UpdateMenu(), "should try to download a new menu when the cached one is stale")
+	if tr.requests != 1 {
+		t.Errorf("expected one request for the new menu, got %d", tr.requests)
+	}
+	// the stale menu is still there for offline use
+	tests.Check(mc.LoadCached("4336"))
+	tests.StrEq(mc.Menu().ID, "4336", "wrong menu")
+}
@@ -0,0 +1,146 @@
+package data
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/cache"
+	"github.com/harrybrwn/apizza/pkg/errs"
+)
+
+// SchemaVersionKey is the database key that holds the version of the
+// database's layout.
+const SchemaVersionKey = "schema_version"
+
+// Migration is a change to the layout of the database. Migrations should be
+// safe to run more than once in case the program is stopped before the
+// schema version is updated.
+type Migration struct {
+	// Version is the schema version of the database after the migration.
+	Version     int
+	Description string
+	Run         func(db cache.Backend) error
+}
+
+var migrations []*Migration
+
+// RegisterMigration adds a migration to the registry. Migrations must be
+// registered in order and will panic if the version is not the next version.
+func RegisterMigration(m *Migration) {
+	if m.Version != LatestSchemaVersion()+1 {
+		panic(fmt.Sprintf("migration %d registered out of order", m.Version))
+	}
+	migrations = append(migrations, m)
+}
+
+// Migrations returns all the registered migrations.
+func Migrations() []*Migration {
+	return migrations
+}
+
+// LatestSchemaVersion is the schema version that the program expects.
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion gets the schema version of the database. Databases created
+// before the schema was versioned are version 0.
+func SchemaVersion(db cache.Getter) (int, error) {
+	raw, err := db.Get(SchemaVersionKey)
+	if err != nil || raw == nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(raw))
+}
+
+// PendingMigrations returns the migrations that have not been run on the
+// database.
+func PendingMigrations(db cache.Getter) ([]*Migration, error) {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if version > LatestSchemaVersion() {
+		return nil, fmt.Errorf(
+			"database schema version %d is newer than this version of apizza supports (%d)",
+			version, LatestSchemaVersion())
+	}
+	pending := make([]*Migration, 0, len(migrations))
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate will run all the pending migrations on the database and returns the
+// migrations that were run.
+func Migrate(db cache.Backend) ([]*Migration, error) {
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range pending {
+		log.Printf("running database migration %d: %s\n", m.Version, m.Description)
+		if err = m.Run(db); err != nil {
+			return pending[:i], fmt.Errorf("migration %d failed: %v", m.Version, err)
+		}
+		if err = db.Put(SchemaVersionKey, []byte(strconv.Itoa(m.Version))); err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+func init() {
+	RegisterMigration(&Migration{
+		Version:     1,
+		Description: "start versioning the database schema",
+		Run:         func(cache.Backend) error { return nil },
+	})
+	RegisterMigration(&Migration{
+		Version:     2,
+		Description: "cache menus by store",
+		Run:         migrateMenusByStore,
+	})
+}
+
+// migrateMenusByStore moves the single cached menu to a key for its store
+// and removes the old menu timestamps.
+func migrateMenusByStore(db cache.Backend) error {
+	raw, err := db.Get(legacyMenuKey)
+	if err != nil {
+		return err
+	}
+	if raw != nil {
+		m := new(dawg.Menu)
+		if err = gob.NewDecoder(bytes.NewReader(raw)).Decode(m); err == nil && m.ID != "" {
+			// the menu will be stale so it is only used when offline
+			mc := NewMenuCacher(0, db, nil)
+			if err = mc.CacheMenu(m); err != nil {
+				return err
+			}
+		}
+	}
+	all, err := db.Map()
+	if err != nil {
+		return err
+	}
+	for key := range all {
+		if strings.HasPrefix(key, MenuPrefix) && strings.HasSuffix(key, "_timestamp") {
+			if err = db.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	return errs.Pair(db.Delete(legacyMenuKey), db.DeleteTimeStamp(legacyMenuKey))
+}
@@ -0,0 +1,63 @@
+package data
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestMigrate(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+
+	// a database from before menus were cached by store
+	buf := new(bytes.Buffer)
+	tests.Fatal(gob.NewEncoder(buf).Encode(&dawg.Menu{ID: "4336"}))
+	tests.Check(db.Put(legacyMenuKey, buf.Bytes()))
+	tests.Check(db.ResetTimeStamp(legacyMenuKey))
+
+	version, err := SchemaVersion(db)
+	tests.Check(err)
+	if version != 0 {
+		t.Errorf("unversioned database should be version 0, got %d", version)
+	}
+	pending, err := PendingMigrations(db)
+	tests.Check(err)
+	if len(pending) != len(Migrations()) {
+		t.Error("all migrations should be pending")
+	}
+
+	ran, err := Migrate(db)
+	tests.Check(err)
+	if len(ran) != len(pending) {
+		t.Errorf("expected %d migrations to run, got %d", len(pending), len(ran))
+	}
+	version, err = SchemaVersion(db)
+	tests.Check(err)
+	if version != LatestSchemaVersion() {
+		t.Errorf("expected schema version %d, got %d", LatestSchemaVersion(), version)
+	}
+	for _, key := range []string{legacyMenuKey, legacyMenuKey + "_timestamp"} {
+		if db.Exists(key) {
+			t.Errorf("%s should have been removed", key)
+		}
+	}
+	mc := NewMenuCacher(0, db, nil)
+	tests.Check(mc.LoadCached("4336"))
+	tests.StrEq(mc.Menu().ID, "4336", "the old menu should have been kept")
+
+	ran, err = Migrate(db)
+	tests.Check(err)
+	if len(ran) != 0 {
+		t.Error("migrations should only run once")
+	}
+
+	tests.Check(db.Put(SchemaVersionKey, []byte("1000")))
+	_, err = Migrate(db)
+	tests.Exp(err, "should not migrate a database from a newer version")
+}
@@ -0,0 +1,114 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/harrybrwn/apizza/pkg/cache"
+)
+
+const (
+	// SchedulesBucket is the database bucket that holds the scheduled
+	// orders. The key is the name of the order.
+	SchedulesBucket = "schedules"
+
+	// MaxScheduleRuns is the number of runs that are kept for each schedule.
+	MaxScheduleRuns = 20
+)
+
+// Results of a scheduled run.
+const (
+	RunPlaced  = "placed"
+	RunFailed  = "failed"
+	RunSkipped = "skipped"
+	RunDryRun  = "dry-run"
+)
+
+// Schedule is an order that is placed by 'apizza daemon' every time a cron
+// expression matches.
+type Schedule struct {
+	Order   string        `json:"order"`
+	Cron    string        `json:"cron"`
+	Created time.Time     `json:"created"`
+	Next    time.Time     `json:"next"`
+	Runs    []ScheduleRun `json:"runs,omitempty"`
+}
+
+// ScheduleRun is the result of one run of a schedule.
+type ScheduleRun struct {
+	Time    time.Time `json:"time"`
+	Result  string    `json:"result"`
+	Message string    `json:"message,omitempty"`
+	Total   float64   `json:"total,omitempty"`
+	OrderID string    `json:"order_id,omitempty"`
+}
+
+// Record adds a run to the schedule, only keeping the latest MaxScheduleRuns
+// runs.
+func (s *Schedule) Record(run ScheduleRun) {
+	s.Runs = append(s.Runs, run)
+	if len(s.Runs) > MaxScheduleRuns {
+		s.Runs = s.Runs[len(s.Runs)-MaxScheduleRuns:]
+	}
+}
+
+// LastRun returns the most recent run of the schedule or nil if it has never
+// been run.
+func (s *Schedule) LastRun() *ScheduleRun {
+	if len(s.Runs) == 0 {
+		return nil
+	}
+	return &s.Runs[len(s.Runs)-1]
+}
+
+// SaveSchedule stores a schedule in the database.
+func SaveSchedule(db cache.Backend, s *Schedule) error {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return db.WithBucket(SchedulesBucket).Put(s.Order, raw)
+}
+
+// GetSchedule gets the schedule for an order.
+func GetSchedule(db cache.Backend, order string) (*Schedule, error) {
+	raw, err := db.WithBucket(SchedulesBucket).Get(order)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("order '%s' is not scheduled", order)
+	}
+	s := &Schedule{}
+	return s, json.Unmarshal(raw, s)
+}
+
+// DeleteSchedule removes the schedule for an order.
+func DeleteSchedule(db cache.Backend, order string) error {
+	if _, err := GetSchedule(db, order); err != nil {
+		return err
+	}
+	return db.WithBucket(SchedulesBucket).Delete(order)
+}
+
+// Schedules returns all the schedules sorted by order name.
+func Schedules(db cache.Backend) ([]*Schedule, error) {
+	all, err := db.WithBucket(SchedulesBucket).Map()
+	if err != nil {
+		return nil, err
+	}
+	schedules := make([]*Schedule, 0, len(all))
+	for _, raw := range all {
+		s := &Schedule{}
+		if err = json.Unmarshal(raw, s); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].Order < schedules[j].Order
+	})
+	return schedules, nil
+}
@@ -0,0 +1,130 @@
+package data
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/cache"
+	"github.com/harrybrwn/apizza/pkg/errs"
+)
+
+// StorePrefix is the prefix added to store profiles when stored in a database.
+const StorePrefix = "store_"
+
+// StoreCache caches store profiles in the database so that the store locator
+// and store profile endpoints do not need to be called every time the program
+// runs.
+//
+// Store profiles are kept for the Decay duration but the fields that change
+// throughout the day (open status and wait times) are refreshed once the
+// StatusDecay duration has passed.
+type StoreCache struct {
+	Decay       time.Duration
+	StatusDecay time.Duration
+
+	db      cache.Backend
+	refresh func(*dawg.Store) error
+}
+
+// NewStoreCache creates a new StoreCache.
+func NewStoreCache(db cache.Backend, decay, statusDecay time.Duration) *StoreCache {
+	return &StoreCache{
+		Decay:       decay,
+		StatusDecay: statusDecay,
+		db:          db,
+		refresh:     RefreshStoreStatus,
+	}
+}
+
+// StoreKey is the database key used for a cached store. The id is the pinned
+// store id and should be empty when the nearest store is being used.
+func StoreKey(addr dawg.Address, service, id string) string {
+	return StorePrefix + AddressKey(addr) + "|" + service + "|" + id
+}
+
+// Store will get the store for an address and service from the cache. The
+// find function is called when there is no cached store or the cached store
+// is too old.
+func (sc *StoreCache) Store(
+	addr dawg.Address,
+	service, pinned string,
+	find func() (*dawg.Store, error),
+) (store *dawg.Store, err error) {
+	key := StoreKey(addr, service, pinned)
+	fetch := func() error {
+		log.Println("caching another store")
+		store, err = find()
+		if err != nil {
+			return err
+		}
+		// a new store profile has an up to date status
+		return errs.Pair(sc.put(key, store), sc.db.ResetTimeStamp(key+"_status"))
+	}
+
+	err = sc.db.UpdateTS(key, cache.NewUpdater(sc.Decay, fetch, func() (e error) {
+		store, e = sc.get(key)
+		if e != nil || store == nil {
+			return fetch()
+		}
+		return nil
+	}))
+	if err != nil {
+		return nil, err
+	}
+	store.Init(service, addr)
+
+	return store, sc.db.UpdateTS(key+"_status", cache.NewUpdater(
+		sc.StatusDecay,
+		func() error {
+			if err := sc.refresh(store); err != nil {
+				return err
+			}
+			return sc.put(key, store)
+		},
+		func() error { return nil },
+	))
+}
+
+func (sc *StoreCache) put(key string, s *dawg.Store) error {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return sc.db.Put(key, raw)
+}
+
+func (sc *StoreCache) get(key string) (*dawg.Store, error) {
+	raw, err := sc.db.Get(key)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	s := &dawg.Store{}
+	return s, json.Unmarshal(raw, s)
+}
+
+// RefreshStoreStatus will update the fields of a store that change
+// throughout the day using the store's profile.
+func RefreshStoreStatus(s *dawg.Store) error {
+	status := struct {
+		IsOpen        bool
+		IsOnlineNow   bool
+		ServiceIsOpen map[string]bool
+		Wait          map[string]struct {
+			Min, Max int
+		} `json:"ServiceMethodEstimatedWaitMinutes"`
+	}{}
+	if err := dawg.InitStore(s.ID, &status); err != nil {
+		return err
+	}
+	s.IsOpen = status.IsOpen
+	s.IsOnlineNow = status.IsOnlineNow
+	if status.ServiceIsOpen != nil {
+		s.ServiceIsOpen = status.ServiceIsOpen
+	}
+	if status.Wait != nil {
+		s.ServiceEstimatedWait = status.Wait
+	}
+	return nil
+}
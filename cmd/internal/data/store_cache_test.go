@@ -0,0 +1,83 @@
+package data
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestStoreCache(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+	addr := cmdtest.TestAddress()
+
+	var finds, refreshes int
+	find := func() (*dawg.Store, error) {
+		finds++
+		return &dawg.Store{ID: "4336", MinDistance: 0.5, IsOpen: false}, nil
+	}
+	sc := NewStoreCache(db, time.Hour, time.Hour)
+	sc.refresh = func(s *dawg.Store) error {
+		refreshes++
+		s.IsOpen = true
+		return nil
+	}
+
+	s, err := sc.Store(addr, dawg.Delivery, "", find)
+	tests.Check(err)
+	tests.StrEq(s.ID, "4336", "wrong store id")
+	if finds != 1 || refreshes != 0 {
+		t.Errorf("a new store should be found without refreshing: finds %d, refreshes %d", finds, refreshes)
+	}
+	s, err = sc.Store(addr, dawg.Delivery, "", find)
+	tests.Check(err)
+	if finds != 1 {
+		t.Error("the store should have come from the cache")
+	}
+	if s.MinDistance != 0.5 {
+		t.Error("cached store lost its distance")
+	}
+
+	// the status should be refreshed but not the whole profile
+	sc.StatusDecay = -time.Second
+	s, err = sc.Store(addr, dawg.Delivery, "", find)
+	tests.Check(err)
+	if finds != 1 || refreshes != 1 {
+		t.Errorf("expected only a status refresh: finds %d, refreshes %d", finds, refreshes)
+	}
+	if !s.IsOpen {
+		t.Error("store status was not refreshed")
+	}
+	sc.StatusDecay = time.Hour
+	s, err = sc.Store(addr, dawg.Delivery, "", find)
+	tests.Check(err)
+	if !s.IsOpen {
+		t.Error("refreshed status should be saved in the cache")
+	}
+
+	// different services and pinned stores are cached separately
+	_, err = sc.Store(addr, dawg.Carryout, "", find)
+	tests.Check(err)
+	_, err = sc.Store(addr, dawg.Delivery, "4344", find)
+	tests.Check(err)
+	if finds != 3 {
+		t.Errorf("expected three store lookups, got %d", finds)
+	}
+
+	sc.Decay = -time.Second
+	_, err = sc.Store(addr, dawg.Delivery, "", find)
+	tests.Check(err)
+	if finds != 4 {
+		t.Error("expired store should have been found again")
+	}
+
+	_, err = sc.Store(addr, dawg.Delivery, "", func() (*dawg.Store, error) {
+		return nil, errors.New("no stores")
+	})
+	tests.Exp(err)
+}
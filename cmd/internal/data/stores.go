@@ -0,0 +1,59 @@
+package data
+
+import (
+	"strings"
+
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/cache"
+)
+
+// PinnedStoresBucket is the database bucket that holds the store ids that
+// users have pinned to a specific address.
+const PinnedStoresBucket = "pinned_stores"
+
+// AddressKey creates a normalized key from an address so that the same
+// address will always give the same database key.
+func AddressKey(addr dawg.Address) string {
+	return strings.ToLower(strings.Join([]string{
+		strings.TrimSpace(addr.LineOne()),
+		strings.TrimSpace(addr.City()),
+		strings.TrimSpace(addr.StateCode()),
+		strings.TrimSpace(addr.Zip()),
+	}, "|"))
+}
+
+// PinStore will store a preferred store id for an address.
+func PinStore(db cache.Backend, addr dawg.Address, id string) error {
+	return db.WithBucket(PinnedStoresBucket).Put(AddressKey(addr), []byte(id))
+}
+
+// UnpinStore removes the preferred store for an address.
+func UnpinStore(db cache.Backend, addr dawg.Address) error {
+	return db.WithBucket(PinnedStoresBucket).Delete(AddressKey(addr))
+}
+
+// PinnedStore returns the store id that has been pinned to an address. The
+// id returned will be an empty string if there is no pinned store.
+func PinnedStore(db cache.Backend, addr dawg.Address) (string, error) {
+	raw, err := db.WithBucket(PinnedStoresBucket).Get(AddressKey(addr))
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// CachedStoreID returns the id of the store used for an address and service
+// without using the network. The pinned store is used if there is one,
+// otherwise it is the cached nearest store. The id is empty if neither is in
+// the database.
+func CachedStoreID(db cache.Backend, addr dawg.Address, service string) (string, error) {
+	id, err := PinnedStore(db, addr)
+	if err != nil || id != "" {
+		return id, err
+	}
+	store, err := (&StoreCache{db: db}).get(StoreKey(addr, service, ""))
+	if err != nil || store == nil {
+		return "", err
+	}
+	return store.ID, nil
+}
@@ -0,0 +1,68 @@
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestPinnedStores(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+	addr := cmdtest.TestAddress()
+
+	id, err := PinnedStore(db, addr)
+	tests.Check(err)
+	tests.StrEq(id, "", "should not have a pinned store yet")
+
+	tests.Check(PinStore(db, addr, "4344"))
+	id, err = PinnedStore(db, addr)
+	tests.Check(err)
+	tests.StrEq(id, "4344", "wrong pinned store: got %s", id)
+
+	// the same address with different formatting should give the same key
+	other := &obj.Address{
+		Street:   "1600 pennsylvania ave nw ",
+		CityName: "WASHINGTON",
+		State:    "dc",
+		Zipcode:  "20500",
+	}
+	id, err = PinnedStore(db, other)
+	tests.Check(err)
+	tests.StrEq(id, "4344", "address keys should be normalized")
+
+	tests.Check(UnpinStore(db, addr))
+	id, err = PinnedStore(db, addr)
+	tests.Check(err)
+	tests.StrEq(id, "", "store should have been unpinned")
+}
+
+func TestCachedStoreID(t *testing.T) {
+	tests.InitHelpers(t)
+	db := cmdtest.TempDB()
+	defer func() { tests.Check(db.Destroy()) }()
+	addr := cmdtest.TestAddress()
+
+	id, err := CachedStoreID(db, addr, dawg.Delivery)
+	tests.Check(err)
+	tests.StrEq(id, "", "should not know the store yet")
+
+	sc := NewStoreCache(db, time.Hour, time.Hour)
+	tests.Check(sc.put(StoreKey(addr, dawg.Delivery, ""), &dawg.Store{ID: "4336"}))
+	id, err = CachedStoreID(db, addr, dawg.Delivery)
+	tests.Check(err)
+	tests.StrEq(id, "4336", "should use the cached nearest store")
+	id, err = CachedStoreID(db, addr, dawg.Carryout)
+	tests.Check(err)
+	tests.StrEq(id, "", "stores are cached for each service")
+
+	tests.Check(PinStore(db, addr, "4344"))
+	id, err = CachedStoreID(db, addr, dawg.Delivery)
+	tests.Check(err)
+	tests.StrEq(id, "4344", "the pinned store should be used first")
+}
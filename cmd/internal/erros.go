@@ -1,6 +1,9 @@
 package internal
 
-import "errors"
+import (
+	"errors"
+	"net"
+)
 
 var (
 	// ErrNoAddress is the error found when the cli could no find an address
@@ -9,4 +12,15 @@ var (
 	// ErrNoOrderName is the error raised when the is no order name given to the
 	// cart or the order commands.
 	ErrNoOrderName = errors.New("No order name... use '--name=<order name>' or give name as an argument")
+
+	// ErrOffline is returned when a command needs the network but the
+	// program is running in offline mode.
+	ErrOffline = errors.New("cannot reach dominos in offline mode (remove the '--offline' flag)")
 )
+
+// IsNetworkErr will return true if the error was caused by a network
+// failure (i.e. no internet connection or a timeout).
+func IsNetworkErr(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,132 @@
+package out
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/harrybrwn/apizza/cmd/opts"
+)
+
+// Theme is the color of each part of the output. Colors are the parameters of
+// a terminal escape code, like "01;34" for bold blue, and an empty color means
+// that part of the output is not colored. Use ParseColor to get a color from a
+// name.
+type Theme struct {
+	// Heading is the color of headings like "Your Orders".
+	Heading string
+	// Key is the color of the field names when printing an order.
+	Key string
+	// Name is the color of order names.
+	Name string
+	// Price is the color of prices.
+	Price string
+}
+
+// DefaultTheme is the theme used when none is configured.
+var DefaultTheme = Theme{
+	Heading: "01;34",
+	Key:     "01;34",
+}
+
+var (
+	theme    = DefaultTheme
+	useColor = false
+)
+
+// SetTheme sets the theme used for all output.
+func SetTheme(t Theme) {
+	theme = t
+}
+
+// SetColor turns color on or off for all output. Color is off by default.
+func SetColor(on bool) {
+	useColor = on
+}
+
+// ColorEnabled decides if output written to a file should be colored. In auto
+// mode color is only used when the NO_COLOR environment variable is not set
+// and the file is a terminal.
+func ColorEnabled(mode opts.ColorMode, f *os.File) bool {
+	switch mode {
+	case opts.ColorAlways:
+		return true
+	case opts.ColorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(f)
+}
+
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+var colors = map[string]string{
+	"black":     "30",
+	"red":       "31",
+	"green":     "32",
+	"yellow":    "33",
+	"blue":      "34",
+	"magenta":   "35",
+	"cyan":      "36",
+	"white":     "37",
+	"bold":      "1",
+	"underline": "4",
+}
+
+var rawColor = regexp.MustCompile(`^[0-9]+(;[0-9]+)*$`)
+
+// ParseColor turns a color name into the parameters of an escape code. A name
+// can be several words like "bold red", raw parameters like "01;34", or "none"
+// for no color.
+func ParseColor(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "none" {
+		return "", nil
+	}
+	if rawColor.MatchString(name) {
+		return name, nil
+	}
+	words := strings.Fields(name)
+	codes := make([]string, len(words))
+	for i, w := range words {
+		code, ok := colors[w]
+		if !ok {
+			return "", fmt.Errorf("unknown color '%s'", w)
+		}
+		codes[i] = code
+	}
+	return strings.Join(codes, ";"), nil
+}
+
+// Paint wraps s in the escape codes for a color. Nothing is added if color is
+// off or the color is empty.
+func Paint(color, s string) string {
+	if !useColor || color == "" {
+		return s
+	}
+	return "\033[" + color + "m" + s + "\033[0m"
+}
+
+// Heading colors a heading with the theme.
+func Heading(s string) string { return Paint(theme.Heading, s) }
+
+// Key colors a field name with the theme.
+func Key(s string) string { return Paint(theme.Key, s) }
+
+// Name colors a name with the theme.
+func Name(s string) string { return Paint(theme.Name, s) }
+
+// Price colors a price with the theme.
+func Price(s string) string { return Paint(theme.Price, s) }
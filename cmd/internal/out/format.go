@@ -0,0 +1,44 @@
+package out
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/harrybrwn/apizza/cmd/opts"
+)
+
+// Encode writes v to w using a structured format.
+func Encode(w io.Writer, f opts.Format, v interface{}) error {
+	switch f {
+	case opts.JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case opts.YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return fmt.Errorf("cannot encode output as %s", f)
+}
+
+// Structured returns true if output should be written with Write instead of
+// being printed as a table.
+func Structured(f opts.Format, template string) bool {
+	return template != "" || f.Structured()
+}
+
+// Write writes v using a template from the --format flag if one is given,
+// otherwise it is encoded with the structured output format.
+func Write(w io.Writer, f opts.Format, template string, v interface{}) error {
+	if template != "" {
+		return ExecTemplate(w, template, v)
+	}
+	return Encode(w, f, v)
+}
@@ -84,41 +84,36 @@ func lineone(str string, start, length int) (string, int) {
 	return str[:i], i
 }
 
-// PrintOrder will print the order given.
-func PrintOrder(o *dawg.Order, full, color, price bool) (err error) {
-	var (
-		t      string
-		oPrice float64
-	)
-
-	if full {
-		t = defaultOrderTmpl
-	} else {
-		t = cartOrderTmpl
-	}
+// PrintOrder will print the order given using the colors from the theme.
+func PrintOrder(o *dawg.Order, full, price bool) (err error) {
+	var oPrice float64
 	if price {
 		oPrice, err = o.Price()
 	}
+	return errs.Pair(err, printOrder(o, full, oPrice))
+}
+
+// PrintPricedOrder will print an order with a price that has already been
+// checked.
+func PrintPricedOrder(o *dawg.Order, full bool, price float64) error {
+	return printOrder(o, full, price)
+}
 
-	var keycolor, endcolor string
-	if color {
-		keycolor = "\033[01;34m"
-		endcolor = "\033[0m"
+func printOrder(o *dawg.Order, full bool, price float64) error {
+	t := cartOrderTmpl
+	if full {
+		t = defaultOrderTmpl
 	}
 	data := struct {
 		*dawg.Order
-		Addr     string
-		Price    float64
-		KeyColor string
-		EndColor string
+		Addr  string
+		Price float64
 	}{
-		Order:    o,
-		Addr:     obj.AddressFmtIndent(o.Address, 11),
-		Price:    oPrice,
-		KeyColor: keycolor,
-		EndColor: endcolor,
+		Order: o,
+		Addr:  obj.AddressFmtIndent(o.Address, 11),
+		Price: price,
 	}
-	return errs.Pair(err, tmpl(output, t, data))
+	return tmpl(output, t, data)
 }
 
 // PrintVariant will display a dawg.Variant in a pretty way.
@@ -2,9 +2,17 @@ package out
 
 import (
 	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/opts"
 	"github.com/harrybrwn/apizza/dawg"
 	"github.com/harrybrwn/apizza/pkg/errs"
 	"github.com/harrybrwn/apizza/pkg/tests"
@@ -41,12 +49,27 @@ func TestTmpl(t *testing.T) {
 
 var testStore *dawg.Store
 
-func init() {
-	var err error
+// TestMain loads the testing store from a cassette so that the tests can run
+// offline. The cassette is used until all the tests have finished because the
+// menu is only downloaded when a test asks for it.
+func TestMain(m *testing.M) {
+	cassette, err := tests.NewCassette(cmdtest.CassettePath("out-store.json"), tests.EnvMode(), nil)
+	if err != nil {
+		panic(err)
+	}
+	old := dawg.SetTransport(cassette)
 	testStore, err = dawg.NearestStore(cmdtest.TestAddress(), dawg.Delivery)
 	if err != nil {
 		panic(err)
 	}
+	code := m.Run()
+	dawg.SetTransport(old)
+	if cassette.Mode() == tests.Record {
+		if err = cassette.Save(); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(code)
 }
 
 func TestPrintOrder(t *testing.T) {
@@ -58,10 +81,10 @@ func TestPrintOrder(t *testing.T) {
 
 	buf := new(bytes.Buffer)
 	SetOutput(buf)
-	tests.Check(PrintOrder(o, false, false, false))
+	tests.Check(PrintOrder(o, false, false))
 	tests.CompareV(t, buf.String(), "  TestOrder -  14SCREEN, \n")
 	buf.Reset()
-	tests.Check(PrintOrder(o, true, false, false))
+	tests.Check(PrintOrder(o, true, false))
 	expected := `TestOrder
   products:
     name: Large (14") Hand Tossed Pizza
@@ -77,7 +100,7 @@ func TestPrintOrder(t *testing.T) {
 `
 	tests.CompareV(t, buf.String(), expected)
 	buf.Reset()
-	tests.Check(PrintOrder(o, true, false, true))
+	tests.Check(PrintOrder(o, true, true))
 	tests.Compare(t, buf.String(), expected+"  price:   $20.15\n")
 	ResetOutput()
 }
@@ -128,3 +151,254 @@ func TestPrintMenu(t *testing.T) {
 		t.Error("menu output is too short")
 	}
 }
+
+func TestPrintStore(t *testing.T) {
+	tests.InitHelpers(t)
+	buf := new(bytes.Buffer)
+	SetOutput(buf)
+	defer ResetOutput()
+	store := &dawg.Store{
+		ID:                  "4336",
+		IsOpen:              true,
+		IsOnlineNow:         true,
+		Phone:               "202-639-8700",
+		Address:             "1300 L St Nw\nWashington, DC 20005\nPlease consider tipping your driver",
+		MinDistance:         0.5,
+		AllowDeliveryOrders: true,
+		ServiceIsOpen:       map[string]bool{dawg.Delivery: true, dawg.Carryout: false},
+		StoreCoords:         map[string]string{"StoreLatitude": "38.9036", "StoreLongitude": "-77.03"},
+	}
+	store.ServiceEstimatedWait = map[string]struct{ Min, Max int }{dawg.Delivery: {Min: 14, Max: 24}}
+
+	tests.Check(PrintStore(store, dawg.Delivery))
+	tests.Compare(t, buf.String(), `Store 4336
+  address:  1300 L St Nw
+            Washington, DC 20005
+  phone:    202-639-8700
+  status:   open
+  services: Delivery
+  wait:     14-24 min (Delivery)
+  coordinates: 38.9036, -77.03
+`)
+	buf.Reset()
+	PrintStoreLine(store, dawg.Carryout, true)
+	tests.Compare(t, buf.String(), "* 4336    0.5 mi  open    Delivery          unknown   1300 L St Nw Washington, DC 20005\n")
+}
+
+func TestEncode(t *testing.T) {
+	tests.InitHelpers(t)
+	store := &dawg.Store{
+		ID:                  "4336",
+		IsOpen:              true,
+		Phone:               "202-639-8700 ",
+		Address:             "1300 L St Nw\nWashington, DC 20005\nPlease consider tipping your driver",
+		MinDistance:         0.5,
+		AllowDeliveryOrders: true,
+		StoreCoords:         map[string]string{"StoreLatitude": "38.9036", "StoreLongitude": "-77.03"},
+	}
+	store.ServiceEstimatedWait = map[string]struct{ Min, Max int }{dawg.Delivery: {Min: 14, Max: 24}}
+
+	buf := new(bytes.Buffer)
+	tests.Check(Encode(buf, opts.JSON, NewStore(store, true)))
+	tests.Compare(t, buf.String(), `{
+  "id": "4336",
+  "address": "1300 L St Nw Washington, DC 20005",
+  "phone": "202-639-8700",
+  "open": true,
+  "online": false,
+  "services": [
+    "Delivery"
+  ],
+  "distance": 0.5,
+  "latitude": "38.9036",
+  "longitude": "-77.03",
+  "wait": {
+    "Delivery": {
+      "min": 14,
+      "max": 24
+    }
+  },
+  "pinned": true
+}
+`)
+	buf.Reset()
+	tests.Check(Encode(buf, opts.YAML, NewStore(store, false)))
+	decoded := &Store{}
+	tests.Check(yaml.Unmarshal(buf.Bytes(), decoded))
+	if decoded.ID != "4336" || decoded.Wait[dawg.Delivery].Max != 24 || decoded.Pinned {
+		t.Errorf("bad yaml output: %s", buf.String())
+	}
+	tests.Exp(Encode(buf, opts.Table, store), "table is not a structured format")
+
+	var f opts.Format
+	tests.Exp(f.Set("xml"), "xml is not an output format")
+	tests.Check(f.Set("yaml"))
+	if !f.Structured() || f != opts.YAML {
+		t.Error("format should be yaml")
+	}
+}
+
+func TestOutputSchemas(t *testing.T) {
+	tests.InitHelpers(t)
+	raw, err := ioutil.ReadFile("../../../dawg/testdata/menu.json")
+	tests.Fatal(err)
+	menu := &dawg.Menu{ID: "4336"}
+	tests.Fatal(json.Unmarshal(raw, menu))
+
+	m := NewMenu(menu, menu.Categorization.Food.Categories)
+	tests.StrEq(m.StoreID, "4336", "wrong store id")
+	if len(m.Categories) == 0 {
+		t.Fatal("menu should have categories")
+	}
+	v, err := menu.GetVariant("14SCREEN")
+	tests.Check(err)
+	item := NewItem(v, menu)
+	tests.StrEq(item.Type, VariantItem, "wrong item type")
+	tests.StrEq(item.ProductCode, "S_PIZZA", "wrong product code")
+	if item.Price <= 0 || len(item.Toppings) == 0 {
+		t.Errorf("variant should have a price and toppings: %+v", item)
+	}
+	item = NewItem(menu.FindItem("S_PIZZA"), menu)
+	tests.StrEq(item.Type, ProductItem, "wrong item type")
+	if len(item.Variants) == 0 {
+		t.Error("product should have variants")
+	}
+
+	item = NewItem(menu.FindItem("14SCREEN"), menu)
+	tests.StrEq(item.Type, PreconfiguredItem, "wrong item type")
+
+	o := cmdtest.NewTestOrder()
+	tests.Check(o.AddProduct(v))
+	order := NewOrder(o, 0, false)
+	if order.Price != nil {
+		t.Error("price should not be set")
+	}
+	if len(order.Products) != 1 || order.Products[0].Code != "14SCREEN" || order.Products[0].Quantity != 1 {
+		t.Errorf("wrong products: %+v", order.Products)
+	}
+	if !strings.Contains(order.Address.Street, "1600 Pennsylvania") {
+		t.Errorf("wrong address: %+v", order.Address)
+	}
+	if order = NewOrder(o, 12.5, true); order.Price == nil || *order.Price != 12.5 {
+		t.Error("price should be set")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	tests.InitHelpers(t)
+	dir := tests.TempDir()
+	defer os.RemoveAll(dir)
+	tests.Fatal(ioutil.WriteFile(filepath.Join(dir, "short.tmpl"),
+		[]byte("{{pad 6 .Code}}|{{padleft 4 .Quantity}}\n"), 0644))
+
+	price := 12.5
+	orders := []*Order{
+		{Name: "one", Price: &price, Products: []OrderProduct{{Code: "14SCREEN", Quantity: 1}}},
+		{Name: "two"},
+	}
+	buf := new(bytes.Buffer)
+	tmpl, err := ParseTemplate(`{{.Name}} {{money .Price}} {{upper .Name}}`, dir)
+	tests.Fatal(err)
+	tests.Check(ExecuteTemplate(buf, tmpl, orders))
+	tests.Compare(t, buf.String(), "one $12.50 ONE\ntwo  TWO\n")
+	buf.Reset()
+
+	tmpl, err = ParseTemplate("short", dir)
+	tests.Fatal(err)
+	tests.Check(ExecuteTemplate(buf, tmpl, orders[0].Products[0]))
+	tests.Compare(t, buf.String(), "14SCREEN|   1\n")
+	buf.Reset()
+	_, err = ParseTemplate("short.tmpl", dir)
+	tests.Check(err)
+	_, err = ParseTemplate("missing", dir)
+	tests.Exp(err, "expected an error for a missing template")
+	_, err = ParseTemplate("{{.Name", dir)
+	tests.Exp(err, "expected a parse error")
+
+	menu := &Menu{Categories: []Category{
+		{Code: "Pizza", Items: []Item{{Code: "S_PIZZA"}}},
+		{Code: "Sides", Categories: []Category{{Items: []Item{{Code: "W08PBNLW"}, {Code: "W08PHOTW"}}}}},
+	}}
+	tmpl, err = ParseTemplate(`{{color "red" .Code}}`, dir)
+	tests.Fatal(err)
+	SetColor(true)
+	tests.Check(ExecuteTemplate(buf, tmpl, menu))
+	SetColor(false)
+	tests.Compare(t, buf.String(), "\033[31mS_PIZZA\033[0m\n\033[31mW08PBNLW\033[0m\n\033[31mW08PHOTW\033[0m\n")
+	buf.Reset()
+	tests.Check(ExecuteTemplate(buf, tmpl, menu))
+	tests.Compare(t, buf.String(), "S_PIZZA\nW08PBNLW\nW08PHOTW\n")
+	buf.Reset()
+
+	tmpl, err = ParseTemplate(`{{color "plaid" .Code}}`, dir)
+	tests.Fatal(err)
+	tests.Exp(ExecuteTemplate(buf, tmpl, Topping{Code: "X"}), "expected an unknown color error")
+	buf.Reset()
+
+	tmpl, err = ParseTemplate(`{{.ID}}: {{join ", " .Services}}`, dir)
+	tests.Fatal(err)
+	tests.Check(ExecuteTemplate(buf, tmpl, &Store{ID: "4336", Services: []string{"Delivery", "Carryout"}}))
+	tests.Compare(t, buf.String(), "4336: Delivery, Carryout\n")
+	buf.Reset()
+
+	tmpl, err = ParseTemplate(`{{.Code}}`, dir)
+	tests.Fatal(err)
+	tests.Check(ExecuteTemplate(buf, tmpl, map[string][]Topping{
+		"Sauce": {{Code: "X"}},
+		"Pizza": {{Code: "C"}, {Code: "P"}},
+	}))
+	tests.Compare(t, buf.String(), "C\nP\nX\n")
+}
+
+func TestColor(t *testing.T) {
+	tests.InitHelpers(t)
+	for name, exp := range map[string]string{
+		"":         "",
+		"none":     "",
+		"Red":      "31",
+		"bold red": "1;31",
+		"01;34":    "01;34",
+	} {
+		code, err := ParseColor(name)
+		tests.Check(err)
+		tests.StrEq(code, exp, "wrong color for %q", name)
+	}
+	_, err := ParseColor("bold plaid")
+	tests.Exp(err, "expected an unknown color error")
+
+	defer func() {
+		SetColor(false)
+		SetTheme(DefaultTheme)
+	}()
+	tests.StrEq(Paint("31", "x"), "x", "color should be off by default")
+	SetColor(true)
+	tests.StrEq(Paint("31", "x"), "\033[31mx\033[0m", "wrong color")
+	tests.StrEq(Paint("", "x"), "x", "empty colors should not add escape codes")
+	SetTheme(Theme{Heading: "32", Price: "33"})
+	tests.StrEq(Heading("h")+Key("k")+Name("n")+Price("p"), "\033[32mh\033[0mkn\033[33mp\033[0m", "wrong theme")
+
+	o := &dawg.Order{OrderName: "x", StoreID: "4336", ServiceMethod: dawg.Carryout, Address: dawg.StreetAddrFromAddress(cmdtest.TestAddress())}
+	buf := new(bytes.Buffer)
+	SetOutput(buf)
+	defer ResetOutput()
+	SetTheme(DefaultTheme)
+	tests.Check(PrintOrder(o, true, false))
+	if !strings.Contains(buf.String(), "  \033[01;34mstoreID\033[0m: 4336\n") {
+		t.Errorf("order keys should use the theme:\n%q", buf.String())
+	}
+
+	tests.Check(os.Setenv("NO_COLOR", ""))
+	if ColorEnabled(opts.ColorAuto, os.Stdout) {
+		t.Error("NO_COLOR should turn off color")
+	}
+	if !ColorEnabled(opts.ColorAlways, nil) {
+		t.Error("color should always be used with --color=always")
+	}
+	tests.Check(os.Unsetenv("NO_COLOR"))
+	if ColorEnabled(opts.ColorNever, os.Stdout) {
+		t.Error("color should never be used with --color=never")
+	}
+	if ColorEnabled(opts.ColorAuto, nil) {
+		t.Error("color should not be used when not writing to a terminal")
+	}
+}
@@ -0,0 +1,255 @@
+package out
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/dawg"
+)
+
+// The types in this file are what commands print when using the json and
+// yaml output formats. They are documented in docs/output.md and fields should
+// only ever be added to them so that scripts do not break.
+
+// Menu is the output schema for a menu.
+type Menu struct {
+	StoreID    string     `json:"store_id" yaml:"store_id"`
+	Categories []Category `json:"categories" yaml:"categories"`
+}
+
+// Category is the output schema for a menu category. A category will either
+// have items or sub-categories.
+type Category struct {
+	Code       string     `json:"code" yaml:"code"`
+	Name       string     `json:"name" yaml:"name"`
+	Items      []Item     `json:"items,omitempty" yaml:"items,omitempty"`
+	Categories []Category `json:"categories,omitempty" yaml:"categories,omitempty"`
+}
+
+// Item types.
+const (
+	ProductItem       = "product"
+	VariantItem       = "variant"
+	PreconfiguredItem = "preconfigured"
+)
+
+// Item is the output schema for a menu item.
+type Item struct {
+	Code     string `json:"code" yaml:"code"`
+	Name     string `json:"name" yaml:"name"`
+	Type     string `json:"type" yaml:"type"`
+	Category string `json:"category,omitempty" yaml:"category,omitempty"`
+	// Price is only set for variants.
+	Price float64 `json:"price,omitempty" yaml:"price,omitempty"`
+	// ProductCode is the code of a variant's product.
+	ProductCode string `json:"product_code,omitempty" yaml:"product_code,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Size        string `json:"size,omitempty" yaml:"size,omitempty"`
+	// Toppings maps topping names to the amount of the topping.
+	Toppings map[string]string `json:"toppings,omitempty" yaml:"toppings,omitempty"`
+	// Variants are only set for products.
+	Variants []Item `json:"variants,omitempty" yaml:"variants,omitempty"`
+}
+
+// Topping is the output schema for a topping on the menu.
+type Topping struct {
+	Code string `json:"code" yaml:"code"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// Order is the output schema for an order.
+type Order struct {
+	Name     string         `json:"name" yaml:"name"`
+	StoreID  string         `json:"store_id" yaml:"store_id"`
+	Service  string         `json:"service" yaml:"service"`
+	Address  *Address       `json:"address,omitempty" yaml:"address,omitempty"`
+	Products []OrderProduct `json:"products" yaml:"products"`
+	// Price is only set when the price has been checked with dominos.
+	Price *float64 `json:"price,omitempty" yaml:"price,omitempty"`
+}
+
+// OrderProduct is the output schema for a product in an order.
+type OrderProduct struct {
+	Code     string `json:"code" yaml:"code"`
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	Quantity int    `json:"quantity" yaml:"quantity"`
+	// Options maps topping codes to the amount of the topping.
+	Options map[string]string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// Address is the output schema for an address.
+type Address struct {
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Street  string `json:"street" yaml:"street"`
+	City    string `json:"city" yaml:"city"`
+	State   string `json:"state" yaml:"state"`
+	Zipcode string `json:"zipcode" yaml:"zipcode"`
+}
+
+// Store is the output schema for a store.
+type Store struct {
+	ID        string   `json:"id" yaml:"id"`
+	Address   string   `json:"address" yaml:"address"`
+	Phone     string   `json:"phone" yaml:"phone"`
+	Open      bool     `json:"open" yaml:"open"`
+	Online    bool     `json:"online" yaml:"online"`
+	Services  []string `json:"services" yaml:"services"`
+	Distance  float64  `json:"distance" yaml:"distance"`
+	Latitude  string   `json:"latitude" yaml:"latitude"`
+	Longitude string   `json:"longitude" yaml:"longitude"`
+	// Wait is the estimated wait in minutes for each service.
+	Wait   map[string]Wait `json:"wait" yaml:"wait"`
+	Pinned bool            `json:"pinned" yaml:"pinned"`
+}
+
+// Wait is an estimated wait time in minutes.
+type Wait struct {
+	Min int `json:"min" yaml:"min"`
+	Max int `json:"max" yaml:"max"`
+}
+
+// NewMenu creates the output for the categories of a menu.
+func NewMenu(m *dawg.Menu, categories []dawg.MenuCategory) *Menu {
+	menu := &Menu{StoreID: m.ID, Categories: []Category{}}
+	for _, cat := range categories {
+		if !cat.IsEmpty() {
+			menu.Categories = append(menu.Categories, NewCategory(cat, m))
+		}
+	}
+	return menu
+}
+
+// NewCategory creates the output for a menu category.
+func NewCategory(cat dawg.MenuCategory, m *dawg.Menu) Category {
+	c := Category{Code: cat.Code, Name: cat.Name}
+	if cat.HasItems() {
+		for _, code := range cat.Products {
+			if item := m.FindItem(code); item != nil {
+				c.Items = append(c.Items, *NewItem(item, m))
+			}
+		}
+		return c
+	}
+	for _, sub := range cat.Categories {
+		if !sub.IsEmpty() {
+			c.Categories = append(c.Categories, NewCategory(sub, m))
+		}
+	}
+	return c
+}
+
+// NewItem creates the output for a menu item.
+func NewItem(i dawg.Item, m *dawg.Menu) *Item {
+	item := &Item{
+		Code:     i.ItemCode(),
+		Name:     i.ItemName(),
+		Category: i.Category(),
+	}
+	if len(i.Options()) > 0 {
+		item.Toppings = dawg.ReadableToppings(i, m)
+	}
+	switch p := i.(type) {
+	case *dawg.Variant:
+		item.Type = VariantItem
+		item.Price, _ = strconv.ParseFloat(p.Price, 64)
+		item.ProductCode = p.ProductCode
+	case *dawg.PreConfiguredProduct:
+		item.Type = PreconfiguredItem
+		item.Description = p.Description
+		item.Size = p.Size
+	case *dawg.Product:
+		item.Type = ProductItem
+		item.Description = p.Description
+		for _, code := range p.Variants {
+			if v, err := m.GetVariant(code); err == nil {
+				item.Variants = append(item.Variants, *NewItem(v, m))
+			}
+		}
+	}
+	return item
+}
+
+// NewToppings creates the output for the toppings on a menu grouped by
+// topping category. The toppings in each category are sorted by code.
+func NewToppings(toppings map[string]map[string]dawg.Topping) map[string][]Topping {
+	all := make(map[string][]Topping, len(toppings))
+	for cat, tops := range toppings {
+		list := make([]Topping, 0, len(tops))
+		for code, t := range tops {
+			list = append(list, Topping{Code: code, Name: t.Name})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+		all[cat] = list
+	}
+	return all
+}
+
+// NewOrder creates the output for an order. The price is only included if
+// withPrice is true.
+func NewOrder(o *dawg.Order, price float64, withPrice bool) *Order {
+	order := &Order{
+		Name:     o.Name(),
+		StoreID:  o.StoreID,
+		Service:  o.ServiceMethod,
+		Products: make([]OrderProduct, 0, len(o.Products)),
+	}
+	if o.Address != nil {
+		order.Address = NewAddress("", o.Address)
+	}
+	for _, p := range o.Products {
+		order.Products = append(order.Products, OrderProduct{
+			Code:     p.Code,
+			Name:     p.Name,
+			Quantity: p.Qty,
+			Options:  dawg.ReadableOptions(p),
+		})
+	}
+	if withPrice {
+		order.Price = &price
+	}
+	return order
+}
+
+// NewAddress creates the output for an address.
+func NewAddress(name string, a dawg.Address) *Address {
+	if addr, ok := a.(*obj.Address); ok {
+		// use the fields because the methods hide values that are not valid
+		return &Address{
+			Name:    name,
+			Street:  addr.Street,
+			City:    addr.CityName,
+			State:   addr.State,
+			Zipcode: addr.Zipcode,
+		}
+	}
+	return &Address{
+		Name:    name,
+		Street:  a.LineOne(),
+		City:    a.City(),
+		State:   a.StateCode(),
+		Zipcode: a.Zip(),
+	}
+}
+
+// NewStore creates the output for a store.
+func NewStore(s *dawg.Store, pinned bool) *Store {
+	store := &Store{
+		ID:        s.ID,
+		Address:   storeAddrLine(s),
+		Phone:     strings.TrimSpace(s.Phone),
+		Open:      s.IsOpen,
+		Online:    s.IsOnlineNow,
+		Services:  StoreServices(s),
+		Distance:  s.MinDistance,
+		Latitude:  s.StoreCoords["StoreLatitude"],
+		Longitude: s.StoreCoords["StoreLongitude"],
+		Wait:      make(map[string]Wait, len(s.ServiceEstimatedWait)),
+		Pinned:    pinned,
+	}
+	for service, w := range s.ServiceEstimatedWait {
+		store.Wait[service] = Wait{Min: w.Min, Max: w.Max}
+	}
+	return store
+}
@@ -0,0 +1,88 @@
+package out
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/harrybrwn/apizza/dawg"
+)
+
+// PrintStoreLine prints a one line summary of a store. The service given is
+// used to find the estimated wait time.
+func PrintStoreLine(s *dawg.Store, service string, pinned bool) {
+	var mark = " "
+	if pinned {
+		mark = "*"
+	}
+	fmt.Fprintf(output, "%s %-5s %5.1f mi  %-6s  %-17s %-9s %s\n",
+		mark, s.ID, s.MinDistance, openStatus(s),
+		strings.Join(StoreServices(s), ","),
+		waitTime(s, service), storeAddrLine(s))
+}
+
+// PrintStore will print out the details of a store.
+func PrintStore(s *dawg.Store, service string) error {
+	data := struct {
+		*dawg.Store
+		Addr     string
+		Open     string
+		Services string
+		Wait     string
+		Service  string
+	}{
+		Store:    s,
+		Addr:     strings.Join(storeAddr(s), "\n            "),
+		Open:     openStatus(s),
+		Services: strings.Join(StoreServices(s), ", "),
+		Wait:     waitTime(s, service),
+		Service:  service,
+	}
+	return tmpl(output, storeTmpl, data)
+}
+
+// StoreServices returns the services that a store has available.
+func StoreServices(s *dawg.Store) []string {
+	services := make([]string, 0, 2)
+	for _, service := range []string{dawg.Delivery, dawg.Carryout} {
+		if open, ok := s.ServiceIsOpen[service]; ok {
+			if open {
+				services = append(services, service)
+			}
+			continue
+		}
+		if (service == dawg.Delivery && s.AllowDeliveryOrders) ||
+			(service == dawg.Carryout && s.AllowCarryoutOrders) {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+func openStatus(s *dawg.Store) string {
+	if s.IsOpen {
+		return "open"
+	}
+	return "closed"
+}
+
+func waitTime(s *dawg.Store, service string) string {
+	wait, ok := s.ServiceEstimatedWait[service]
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d-%d min", wait.Min, wait.Max)
+}
+
+// storeAddr returns the street and city lines of the store's address
+// description, the rest of the description is usually a message to customers.
+func storeAddr(s *dawg.Store) []string {
+	lines := strings.Split(s.Address, "\n")
+	if len(lines) > 2 {
+		lines = lines[:2]
+	}
+	return lines
+}
+
+func storeAddrLine(s *dawg.Store) string {
+	return strings.Join(storeAddr(s), " ")
+}
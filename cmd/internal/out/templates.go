@@ -1,36 +1,208 @@
 package out
 
 import (
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"text/template"
 
+	"github.com/harrybrwn/apizza/pkg/config"
 	"github.com/harrybrwn/apizza/pkg/errs"
 )
 
+// themeFuncs color parts of the builtin templates with the theme.
+var themeFuncs = template.FuncMap{
+	"heading": Heading,
+	"key":     Key,
+	"name":    Name,
+	"price":   Price,
+}
+
 func tmpl(w io.Writer, tmplt string, a interface{}) (err error) {
-	t := template.New("apizza")
+	t := template.New("apizza").Funcs(themeFuncs)
 	t, err = t.Parse(tmplt)
 	return errs.Pair(err, t.Execute(w, a))
 }
 
-var defaultOrderTmpl = `{{ .OrderName }}
-{{- $keycol := .KeyColor -}}
-{{- $endcol := .EndColor }}
-  {{.KeyColor}}products{{.EndColor}}:{{ range .Products }}
-    {{$keycol}}name{{$endcol}}: {{.Name}}
-      {{$keycol}}code{{$endcol}}:     {{.Code}}
-      {{$keycol}}options{{$endcol}}:{{ range $k, $v := .ReadableOptions }}
-         {{$keycol}}{{$k}}{{$endcol}}: {{$v}}{{else}}None{{end}}
-      {{$keycol}}quantity{{$endcol}}: {{.Qty}}{{end}}
-  {{.KeyColor}}storeID{{.EndColor}}: {{.StoreID}}
-  {{.KeyColor}}method{{.EndColor}}:  {{.ServiceMethod}}
-  {{.KeyColor}}address{{.EndColor}}: {{.Addr -}}
+// TemplateExt is the file extension of named templates.
+const TemplateExt = ".tmpl"
+
+// TemplateFuncs are the functions that can be used in templates given with
+// the --format flag.
+var TemplateFuncs = template.FuncMap{
+	"money":   money,
+	"pad":     pad,
+	"padleft": padLeft,
+	"color":   color,
+	"join":    join,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+}
+
+var templateName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// TemplateDir returns the folder that named templates are loaded from.
+func TemplateDir() string {
+	return filepath.Join(config.Folder(), "templates")
+}
+
+// ParseTemplate parses a template given with the --format flag. If the format
+// is a name and not a template then the template is loaded from the file with
+// that name in dir.
+func ParseTemplate(format, dir string) (*template.Template, error) {
+	name := "format"
+	if templateName.MatchString(format) {
+		name = strings.TrimSuffix(format, TemplateExt)
+		raw, err := ioutil.ReadFile(filepath.Join(dir, name+TemplateExt))
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no template named '%s' in %s", name, dir)
+		} else if err != nil {
+			return nil, err
+		}
+		// files usually end with a newline but one is already added after each value
+		format = strings.TrimSuffix(string(raw), "\n")
+	}
+	return template.New(name).Funcs(TemplateFuncs).Parse(format)
+}
+
+// ExecTemplate parses the format with ParseTemplate using the default
+// template folder and then runs it with ExecuteTemplate.
+func ExecTemplate(w io.Writer, format string, v interface{}) error {
+	t, err := ParseTemplate(format, TemplateDir())
+	if err != nil {
+		return err
+	}
+	return ExecuteTemplate(w, t, v)
+}
+
+// ExecuteTemplate runs a template for each value in v and writes a newline
+// after each one. Lists are split into their elements, menus and categories
+// into their items, and toppings into each topping.
+func ExecuteTemplate(w io.Writer, t *template.Template, v interface{}) error {
+	for _, val := range templateValues(v) {
+		if err := t.Execute(w, val); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func templateValues(v interface{}) []interface{} {
+	var vals []interface{}
+	switch x := v.(type) {
+	case *Menu:
+		for _, cat := range x.Categories {
+			vals = append(vals, templateValues(cat)...)
+		}
+	case *Category:
+		return templateValues(*x)
+	case Category:
+		for _, item := range x.Items {
+			vals = append(vals, item)
+		}
+		for _, cat := range x.Categories {
+			vals = append(vals, templateValues(cat)...)
+		}
+	case map[string][]Topping:
+		cats := make([]string, 0, len(x))
+		for cat := range x {
+			cats = append(cats, cat)
+		}
+		sort.Strings(cats)
+		for _, cat := range cats {
+			for _, t := range x[cat] {
+				vals = append(vals, t)
+			}
+		}
+	default:
+		val := reflect.ValueOf(v)
+		if val.Kind() != reflect.Slice {
+			return []interface{}{v}
+		}
+		for i := 0; i < val.Len(); i++ {
+			vals = append(vals, val.Index(i).Interface())
+		}
+	}
+	return vals
+}
+
+// money formats a price in dollars. Nil prices are formatted as an empty
+// string.
+func money(v interface{}) (string, error) {
+	var f float64
+	switch p := v.(type) {
+	case nil:
+		return "", nil
+	case *float64:
+		if p == nil {
+			return "", nil
+		}
+		f = *p
+	case float64:
+		f = p
+	case int:
+		f = float64(p)
+	case string:
+		var err error
+		if f, err = strconv.ParseFloat(p, 64); err != nil {
+			return "", fmt.Errorf("money: '%s' is not a number", p)
+		}
+	default:
+		return "", fmt.Errorf("money: cannot format %T", v)
+	}
+	return fmt.Sprintf("$%.2f", f), nil
+}
+
+// pad adds spaces to the end of a value until it is n characters long.
+func pad(n int, v interface{}) string {
+	return fmt.Sprintf("%-*v", n, v)
+}
+
+// padLeft adds spaces to the start of a value until it is n characters long.
+func padLeft(n int, v interface{}) string {
+	return fmt.Sprintf("%*v", n, v)
+}
+
+// color wraps a value in the terminal escape codes for a color. The color is
+// left out when color is turned off.
+func color(name string, v interface{}) (string, error) {
+	code, err := ParseColor(name)
+	if err != nil {
+		return "", fmt.Errorf("color: %v", err)
+	}
+	return Paint(code, fmt.Sprint(v)), nil
+}
+
+func join(sep string, list []string) string {
+	return strings.Join(list, sep)
+}
+
+var defaultOrderTmpl = `{{ name .OrderName }}
+  {{key "products"}}:{{ range .Products }}
+    {{key "name"}}: {{.Name}}
+      {{key "code"}}:     {{.Code}}
+      {{key "options"}}:{{ range $k, $v := .ReadableOptions }}
+         {{key $k}}: {{$v}}{{else}}None{{end}}
+      {{key "quantity"}}: {{.Qty}}{{end}}
+  {{key "storeID"}}: {{.StoreID}}
+  {{key "method"}}:  {{.ServiceMethod}}
+  {{key "address"}}: {{.Addr -}}
 {{ if .Price }}
-  {{.KeyColor}}price{{.EndColor}}:   ${{ .Price -}}
+  {{key "price"}}:   {{ price (printf "$%v" .Price) -}}
 {{else}}{{end}}
 `
 
-var cartOrderTmpl = `  {{ .OrderName }} - {{ range .Products }} {{.Code}}, {{end}}
+var cartOrderTmpl = `  {{ name .OrderName }} - {{ range .Products }} {{.Code}}, {{end}}
 `
 
 var menuCategoryTmpl = ``
@@ -52,3 +224,12 @@ var productTmpl = `    Description: {{.Description}}
   Available sides: {{ if not .AvailableSides }}none{{else}}{{.AvailableSides}}{{end}}
   Available toppings: {{ if not .AvailableToppings }}none{{else}}{{.AvailableToppings}}{{end}}
 `
+
+var storeTmpl = `Store {{.ID}}
+  address:  {{.Addr}}
+  phone:    {{.Phone}}
+  status:   {{.Open}}{{if not .IsOnlineNow}} (not taking online orders){{end}}
+  services: {{if .Services}}{{.Services}}{{else}}none{{end}}
+  wait:     {{.Wait}} ({{.Service}})
+  coordinates: {{index .StoreCoords "StoreLatitude"}}, {{index .StoreCoords "StoreLongitude"}}
+`
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/harrybrwn/apizza/dawg"
 )
@@ -70,3 +71,18 @@ func AddTopping(topStr string, p dawg.Item) error {
 	}
 	return p.AddTopping(topping[0], side, amount)
 }
+
+// Since returns a short human readable description of how long ago a time was.
+func Since(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,237 @@
+// Package webhook sends order events to urls set in the config file.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/errs"
+)
+
+// Event types.
+const (
+	// OrderPlaced is sent after an order is sent to dominos.
+	OrderPlaced = "order.placed"
+	// OrderPriced is sent after the price of an order is checked.
+	OrderPriced = "order.priced"
+	// OrderTracking is sent when the tracking status of a placed order
+	// changes.
+	OrderTracking = "order.tracking"
+)
+
+// Headers sent with every event.
+const (
+	// EventHeader has the type of the event.
+	EventHeader = "X-Apizza-Event"
+	// DeliveryHeader has an id that is the same for every attempt to send an
+	// event so that receivers can ignore duplicates.
+	DeliveryHeader = "X-Apizza-Delivery"
+	// SignatureHeader has the hex encoded HMAC-SHA256 of the body, signed
+	// with the hook's secret and prefixed with "sha256=". It is only sent
+	// when the hook has a secret.
+	SignatureHeader = "X-Apizza-Signature"
+)
+
+// Hook is a url that events are sent to.
+type Hook struct {
+	URL    string `config:"url" json:"url" yaml:"url"`
+	Secret string `config:"secret" json:"secret,omitempty" yaml:"secret,omitempty"`
+	// Events are the types of events sent to the hook. All events are sent
+	// if it is empty.
+	Events []string `config:"events" json:"events,omitempty" yaml:"events,omitempty"`
+}
+
+// Wants returns true if the hook should be sent events of a type.
+func (h *Hook) Wants(event string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == event || e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the json body sent to a hook.
+type Event struct {
+	Type    string    `json:"event"`
+	Time    time.Time `json:"time"`
+	Order   string    `json:"order"`
+	Store   string    `json:"store"`
+	Service string    `json:"service"`
+	// Total is the price of the order if it is known.
+	Total *float64 `json:"total,omitempty"`
+	// Status is "placed" or "priced" when the action worked and "failed"
+	// when it did not. For tracking events it is the status from the
+	// dominos order tracker.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// OrderEvent creates an event for an order. A total less than or equal to
+// zero is left out.
+func OrderEvent(typ string, o *dawg.Order, total float64, err error) *Event {
+	e := &Event{
+		Type:    typ,
+		Time:    time.Now().UTC(),
+		Order:   o.Name(),
+		Store:   o.StoreID,
+		Service: o.ServiceMethod,
+		Status:  strings.TrimPrefix(typ, "order."),
+	}
+	if total > 0 {
+		e.Total = &total
+	}
+	if err != nil {
+		e.Status, e.Error = "failed", err.Error()
+	}
+	return e
+}
+
+// TrackingEvent creates an event for a change in the tracking status of a
+// placed order.
+func TrackingEvent(o *dawg.Order, total float64, status *dawg.OrderStatus) *Event {
+	e := OrderEvent(OrderTracking, o, total, nil)
+	e.Status = status.Status
+	return e
+}
+
+// Notifier sends events to a set of hooks.
+type Notifier struct {
+	Hooks  map[string]*Hook
+	Client *http.Client
+	// Attempts is the number of times an event is sent to a hook before
+	// giving up.
+	Attempts int
+	// Delay is the delay before the first retry, it doubles after every
+	// attempt.
+	Delay time.Duration
+}
+
+// New creates a Notifier for the hooks from the config file.
+func New(hooks map[string]*Hook) *Notifier {
+	return &Notifier{
+		Hooks:    hooks,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		Attempts: 3,
+		Delay:    500 * time.Millisecond,
+	}
+}
+
+// Notify sends an event to every hook that wants it. Hooks that respond
+// with a 5xx or 429 status, or that cannot be reached, are retried. The
+// errors for hooks that never accepted the event are returned together.
+func (n *Notifier) Notify(e *Event) error {
+	if n == nil || len(n.Hooks) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	id, err := deliveryID()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(n.Hooks))
+	for name := range n.Hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var all error
+	for _, name := range names {
+		h := n.Hooks[name]
+		if h == nil || !h.Wants(e.Type) {
+			continue
+		}
+		if err = n.send(h, e.Type, id, body); err != nil {
+			all = errs.Pair(all, fmt.Errorf("webhook %s: %v", name, err))
+		}
+	}
+	return all
+}
+
+func (n *Notifier) send(h *Hook, event, id string, body []byte) (err error) {
+	attempts := n.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := n.Delay
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		var retry bool
+		if retry, err = n.post(h, event, id, body); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// post sends the event once and returns true if it should be tried again.
+func (n *Notifier) post(h *Hook, event, id string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "apizza")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, id)
+	if h.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.Secret, body))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("%s responded with %s", h.URL, resp.Status)
+}
+
+// Sign returns the value of the signature header for a body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature header of an event.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+func deliveryID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,180 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// listener is a local http server that records the events it gets. The
+// status codes are used for each request in order and the last one is
+// repeated.
+type listener struct {
+	*httptest.Server
+	mu       sync.Mutex
+	events   []received
+	statuses []int
+}
+
+func newListener(statuses ...int) *listener {
+	l := &listener{statuses: statuses}
+	l.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.events = append(l.events, received{r.Header, body})
+		status := http.StatusOK
+		if len(l.statuses) > 0 {
+			status = l.statuses[0]
+			if len(l.statuses) > 1 {
+				l.statuses = l.statuses[1:]
+			}
+		}
+		w.WriteHeader(status)
+	}))
+	return l
+}
+
+func testNotifier(hooks map[string]*Hook) *Notifier {
+	n := New(hooks)
+	n.Delay = 0
+	return n
+}
+
+func testOrder() *dawg.Order {
+	o := &dawg.Order{StoreID: "4336", ServiceMethod: dawg.Carryout}
+	o.SetName("dinner")
+	return o
+}
+
+func TestNotify(t *testing.T) {
+	tests.InitHelpers(t)
+	l := newListener()
+	defer l.Close()
+	other := newListener()
+	defer other.Close()
+
+	n := testNotifier(map[string]*Hook{
+		"signed":   {URL: l.URL + "/hook", Secret: "secret"},
+		"unsigned": {URL: l.URL + "/plain", Events: []string{OrderPlaced, OrderPriced}},
+		"placed":   {URL: other.URL, Events: []string{OrderPlaced}},
+		"empty":    nil,
+	})
+	tests.Check(n.Notify(OrderEvent(OrderPriced, testOrder(), 21.5, nil)))
+
+	if len(other.events) != 0 {
+		t.Error("hooks should only get the events they want")
+	}
+	if len(l.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(l.events))
+	}
+	var signed, unsigned received
+	for _, r := range l.events {
+		if r.header.Get(SignatureHeader) == "" {
+			unsigned = r
+		} else {
+			signed = r
+		}
+	}
+	if !Verify("secret", signed.body, signed.header.Get(SignatureHeader)) {
+		t.Error("bad signature")
+	}
+	if Verify("wrong", signed.body, signed.header.Get(SignatureHeader)) {
+		t.Error("signature should not be valid with the wrong secret")
+	}
+	if unsigned.body == nil {
+		t.Fatal("the hook without a secret did not get the event")
+	}
+	tests.StrEq(signed.header.Get(EventHeader), OrderPriced, "wrong event header")
+	tests.StrEq(signed.header.Get("Content-Type"), "application/json", "wrong content type")
+	tests.StrEq(signed.header.Get(DeliveryHeader), unsigned.header.Get(DeliveryHeader),
+		"every hook should get the same delivery id")
+
+	var e Event
+	tests.Fatal(json.Unmarshal(signed.body, &e))
+	if e.Type != OrderPriced || e.Order != "dinner" || e.Store != "4336" || e.Service != dawg.Carryout {
+		t.Errorf("wrong event: %+v", e)
+	}
+	if e.Total == nil || *e.Total != 21.5 {
+		t.Error("wrong total")
+	}
+	tests.StrEq(e.Status, "priced", "wrong status")
+	if e.Time.IsZero() {
+		t.Error("event should have a time")
+	}
+
+	tests.Check(n.Notify(OrderEvent(OrderPlaced, testOrder(), 0, errors.New("no"))))
+	if len(other.events) != 1 {
+		t.Fatal("placed hook should get placed events")
+	}
+	e = Event{}
+	tests.Fatal(json.Unmarshal(other.events[0].body, &e))
+	if e.Status != "failed" || e.Error != "no" || e.Total != nil {
+		t.Errorf("wrong event for a failure: %+v", e)
+	}
+
+	l.events = nil
+	tests.Check(n.Notify(TrackingEvent(testOrder(), 21.5, &dawg.OrderStatus{Status: "Bake"})))
+	if len(l.events) != 1 || len(other.events) != 1 {
+		t.Fatal("tracking events should only go to hooks that want every event")
+	}
+	e = Event{}
+	tests.Fatal(json.Unmarshal(l.events[0].body, &e))
+	if e.Type != OrderTracking || e.Status != "Bake" || e.Total == nil || e.Error != "" {
+		t.Errorf("wrong tracking event: %+v", e)
+	}
+
+	var nilNotifier *Notifier
+	tests.Check(nilNotifier.Notify(&Event{}))
+}
+
+func TestRetry(t *testing.T) {
+	tests.InitHelpers(t)
+	l := newListener(http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK)
+	defer l.Close()
+	n := testNotifier(map[string]*Hook{"a": {URL: l.URL}})
+	tests.Check(n.Notify(OrderEvent(OrderPlaced, testOrder(), 10, nil)))
+	if len(l.events) != 3 {
+		t.Errorf("expected 3 attempts, got %d", len(l.events))
+	}
+	id := l.events[0].header.Get(DeliveryHeader)
+	for _, r := range l.events {
+		tests.StrEq(r.header.Get(DeliveryHeader), id, "retries should have the same delivery id")
+	}
+
+	l = newListener(http.StatusBadGateway)
+	defer l.Close()
+	n = testNotifier(map[string]*Hook{"a": {URL: l.URL}})
+	err := n.Notify(OrderEvent(OrderPlaced, testOrder(), 10, nil))
+	if err == nil || !strings.Contains(err.Error(), "webhook a") {
+		t.Errorf("expected an error for hook a, got %v", err)
+	}
+	if len(l.events) != n.Attempts {
+		t.Errorf("expected %d attempts, got %d", n.Attempts, len(l.events))
+	}
+
+	l = newListener(http.StatusBadRequest)
+	defer l.Close()
+	n = testNotifier(map[string]*Hook{"a": {URL: l.URL}})
+	tests.Exp(n.Notify(OrderEvent(OrderPlaced, testOrder(), 10, nil)))
+	if len(l.events) != 1 {
+		t.Errorf("client errors should not be retried, got %d attempts", len(l.events))
+	}
+
+	l.Close()
+	n = testNotifier(map[string]*Hook{"a": {URL: l.URL}})
+	tests.Exp(n.Notify(OrderEvent(OrderPlaced, testOrder(), 10, nil)), "should fail when the hook is down")
+}
@@ -17,13 +17,18 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"log"
 	"os/exec"
+	"sort"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/harrybrwn/apizza/cmd/cli"
 	"github.com/harrybrwn/apizza/cmd/client"
+	"github.com/harrybrwn/apizza/cmd/internal"
 	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/obj"
 	"github.com/harrybrwn/apizza/cmd/internal/out"
 	"github.com/harrybrwn/apizza/cmd/opts"
 	"github.com/harrybrwn/apizza/pkg/cache"
@@ -38,7 +43,9 @@ type menuCmd struct {
 	data.MenuCacher
 	client.StoreFinder
 
-	db *cache.DataBase
+	db      cache.Backend
+	gOpts   *opts.CliFlags
+	service func() string
 
 	addr dawg.Address
 
@@ -50,11 +57,12 @@ type menuCmd struct {
 	showCategories bool
 	item           string
 	category       string
+	format         string
 }
 
 func (c *menuCmd) Run(cmd *cobra.Command, args []string) error {
-	if err := c.db.UpdateTS("menu", c); err != nil {
-		cmd.Println(err)
+	if err := c.updateMenu(cmd.ErrOrStderr()); err != nil {
+		return err
 	}
 	out.SetOutput(c.Output())
 	defer out.ResetOutput()
@@ -69,7 +77,7 @@ func (c *menuCmd) Run(cmd *cobra.Command, args []string) error {
 		if item == nil && c.category == "" {
 			c.category = strings.ToLower(args[0])
 		} else {
-			return out.ItemInfo(item, c.Menu())
+			return c.printItem(item)
 		}
 	}
 
@@ -78,16 +86,15 @@ func (c *menuCmd) Run(cmd *cobra.Command, args []string) error {
 		if prod == nil {
 			return fmt.Errorf("cannot find %s", c.item)
 		}
-		return out.ItemInfo(prod, c.Menu())
+		return c.printItem(prod)
 	}
 
 	if c.toppings {
-		c.printToppings()
-		return nil
+		return c.printToppings()
 	}
 
 	// printmenu and pageMenu handle most of the menu command's flags
-	if c.page {
+	if c.page && !out.Structured(c.gOpts.Output, c.format) {
 		return c.pageMenu(strings.ToLower(c.category))
 	}
 	return c.printMenu(c.Output(), strings.ToLower(c.category)) // still works with an empty string
@@ -97,6 +104,7 @@ func (c *menuCmd) Run(cmd *cobra.Command, args []string) error {
 func NewMenuCmd(b cli.Builder) cli.CliCommand {
 	c := &menuCmd{
 		db:             b.DB(),
+		gOpts:          b.GlobalOptions(),
 		all:            false,
 		toppings:       false,
 		preconfigured:  false,
@@ -108,8 +116,10 @@ func NewMenuCmd(b cli.Builder) cli.CliCommand {
 		c.StoreFinder = client.NewStoreGetter(b)
 	}
 
+	c.service = func() string { return cli.Service(b) }
+
 	c.CliCommand = b.Build("menu <item>", "View the Dominos menu.", c)
-	c.MenuCacher = data.NewMenuCacher(opts.MenuUpdateTime, b.DB(), c.Store)
+	c.MenuCacher = data.NewMenuCacher(opts.MenuUpdateTime, b.DB(), c.FindStore)
 	c.SetOutput(b.Output())
 
 	c.Cmd().Long = `This command will show the dominos menu.
@@ -132,53 +142,134 @@ as an argument to the command itself.`
 	flags.BoolVarP(&c.preconfigured, "preconfigured",
 		"p", c.preconfigured, "show the pre-configured products on the dominos menu")
 	flags.BoolVar(&c.showCategories, "show-categories", c.showCategories, "print categories")
+	flags.StringVar(&c.format, "format", "", "format each menu item with a go template or a named template")
 	return c
 }
 
+// loadCached loads the cached menu of the store for the current address and
+// service without using the network.
+func (c *menuCmd) loadCached() error {
+	addr := c.Address()
+	if obj.AddrIsEmpty(addr) {
+		return internal.ErrNoAddress
+	}
+	id, err := data.CachedStoreID(c.db, addr, c.service())
+	if err != nil {
+		return err
+	}
+	return c.LoadCached(id)
+}
+
+// updateMenu will update the cached menu. If the program is in offline mode
+// or dominos cannot be reached then the cached menu is used and a staleness
+// warning is written to stderr.
+func (c *menuCmd) updateMenu(stderr io.Writer) error {
+	if !c.gOpts.Offline {
+		err := c.UpdateMenu()
+		if err == nil {
+			return nil
+		}
+		if !internal.IsNetworkErr(err) {
+			return err
+		}
+		log.Println("menu update failed:", err)
+		fmt.Fprintln(stderr, "Warning: could not connect to dominos, using the cached menu")
+	}
+	if err := c.loadCached(); err != nil {
+		return err
+	}
+	stamp, err := c.CachedAt()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "offline: menu for store %s was cached %s (%s)\n",
+		c.Menu().ID, internal.Since(stamp), stamp.Format(time.RFC1123))
+	return nil
+}
+
+func (c *menuCmd) printItem(item dawg.Item) error {
+	if out.Structured(c.gOpts.Output, c.format) {
+		return out.Write(c.Output(), c.gOpts.Output, c.format, out.NewItem(item, c.Menu()))
+	}
+	return out.ItemInfo(item, c.Menu())
+}
+
 func (c *menuCmd) printMenu(w io.Writer, name string) error {
 	out.SetOutput(w)
 	defer out.ResetOutput()
 	menu := c.Menu()
-	var allCategories = c.getCategories(menu)
+	var (
+		allCategories = c.getCategories(menu)
+		format        = c.gOpts.Output
+	)
 
 	if len(name) > 0 {
 		for _, cat := range allCategories {
 			if name == strings.ToLower(cat.Name) || name == strings.ToLower(cat.Code) {
+				if out.Structured(format, c.format) {
+					return out.Write(w, format, c.format, out.NewCategory(cat, menu))
+				}
 				return out.PrintMenu(cat, 0, menu)
 			}
 		}
 		return fmt.Errorf("could not find %s", name)
 	} else if c.showCategories {
 		cats, _ := c.categoryCompletion(nil, []string{}, "")
+		if out.Structured(format, c.format) {
+			return out.Write(w, format, c.format, cats)
+		}
 		fmt.Fprintln(w, strings.Join(cats, "\n"))
 		return nil
 	}
 
+	if out.Structured(format, c.format) {
+		return out.Write(w, format, c.format, out.NewMenu(menu, allCategories))
+	}
+	fmt.Fprintf(w, "Menu for store %s\n\n", menu.ID)
 	for _, cat := range allCategories {
 		out.PrintMenu(cat, 0, menu)
 	}
 	return nil
 }
 
-func (c *menuCmd) printToppings() {
-	var tops = c.Menu().Toppings
+func (c *menuCmd) printToppings() error {
+	var (
+		tops   = c.Menu().Toppings
+		format = c.gOpts.Output
+	)
 
 	if c.category != "" {
 		category := strings.Title(c.category)
+		if out.Structured(format, c.format) {
+			return out.Write(c.Output(), format, c.format, out.NewToppings(
+				map[string]map[string]dawg.Topping{category: tops[category]}))
+		}
 		printToppingCategory(category, tops[category], c.Output())
-		return
+		return nil
 	}
 
 	if c.showCategories {
+		if out.Structured(format, c.format) {
+			cats := make([]string, 0, len(tops))
+			for cat := range tops {
+				cats = append(cats, strings.ToLower(cat))
+			}
+			sort.Strings(cats)
+			return out.Write(c.Output(), format, c.format, cats)
+		}
 		for cat := range tops {
 			c.Println(strings.ToLower(cat))
 		}
-		return
+		return nil
 	}
 
+	if out.Structured(format, c.format) {
+		return out.Write(c.Output(), format, c.format, out.NewToppings(tops))
+	}
 	for typ, toppings := range tops {
 		printToppingCategory(typ, toppings, c.Output())
 	}
+	return nil
 }
 
 func (c *menuCmd) pageMenu(category string) error {
@@ -213,6 +304,12 @@ func (c *menuCmd) categoryCompletion(
 	args []string,
 	toComplete string,
 ) ([]string, cobra.ShellCompDirective) {
+	if c.Menu() == nil {
+		// completions only use the cached menu so that they stay read-only
+		if err := c.loadCached(); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
 	all := c.getCategories(c.Menu())
 	categories := make([]string, 0, len(all))
 	for _, cat := range all {
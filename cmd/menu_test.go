@@ -1,14 +1,25 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
 	"testing"
 
+	"github.com/harrybrwn/apizza/cmd/internal"
 	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/cmd/opts"
+	"github.com/harrybrwn/apizza/dawg"
 	"github.com/harrybrwn/apizza/pkg/tests"
 )
 
 func TestMenuRun(t *testing.T) {
 	tests.InitHelpers(t)
+	defer cmdtest.UseCassette(t, "menu-run.json")()
 	r := cmdtest.NewRecorder()
 	defer r.CleanUp()
 	c := NewMenuCmd(r).(*menuCmd)
@@ -23,12 +34,32 @@ func TestMenuRun(t *testing.T) {
 	tests.Check(c.Run(c.Cmd(), []string{}))
 }
 
+func TestMenuRun_NoAddress(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	r.Conf.Address = obj.Address{}
+	c := NewMenuCmd(r).(*menuCmd)
+	c.Cmd().SetErr(ioutil.Discard)
+
+	err := c.Run(c.Cmd(), []string{})
+	if err != internal.ErrNoAddress {
+		t.Errorf("expected %v, got %v", internal.ErrNoAddress, err)
+	}
+	c.gOpts.Offline = true
+	err = c.Run(c.Cmd(), []string{})
+	if err != internal.ErrNoAddress {
+		t.Errorf("expected %v offline, got %v", internal.ErrNoAddress, err)
+	}
+}
+
 func TestFindProduct(t *testing.T) {
+	defer cmdtest.UseCassette(t, "menu-find-product.json")()
 	r := cmdtest.NewRecorder()
 	defer r.CleanUp()
 	c := NewMenuCmd(r).(*menuCmd)
 
-	if err := r.DB().UpdateTS("menu", c); err != nil {
+	if err := c.UpdateMenu(); err != nil {
 		t.Error(err)
 	}
 	c.all = true
@@ -54,3 +85,74 @@ func TestStringStuff(t *testing.T) {
 		}
 	}
 }
+
+func TestMenuOffline(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	c := NewMenuCmd(r).(*menuCmd)
+	c.gOpts.Offline = true
+	stderr := new(bytes.Buffer)
+	c.Cmd().SetErr(stderr)
+
+	tests.Exp(c.Run(c.Cmd(), []string{}), "should not have a cached menu")
+
+	raw, err := ioutil.ReadFile("../dawg/testdata/menu.json")
+	tests.Fatal(err)
+	tests.Fatal(data.PinStore(r.DB(), r.Address(), "4336"))
+	tests.Fatal(c.CacheMenu(&dawg.Menu{ID: "4344"}))
+	tests.Exp(c.Run(c.Cmd(), []string{}), "should not use another store's menu")
+
+	menu := &dawg.Menu{ID: "4336"}
+	tests.Fatal(json.Unmarshal(raw, menu))
+	tests.Fatal(c.CacheMenu(menu))
+
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	if !strings.Contains(stderr.String(), "offline: menu for store 4336 was cached just now") {
+		t.Errorf("should warn about using the cached menu, got %q", stderr.String())
+	}
+	if r.Out.Len() < 1000 {
+		t.Error("the offline menu output seems too short")
+	}
+}
+
+func TestMenuOutputFormat(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	c := NewMenuCmd(r).(*menuCmd)
+	c.gOpts.Offline = true
+	c.gOpts.Output = opts.JSON
+	c.Cmd().SetErr(new(bytes.Buffer))
+
+	raw, err := ioutil.ReadFile("../dawg/testdata/menu.json")
+	tests.Fatal(err)
+	menu := &dawg.Menu{ID: "4336"}
+	tests.Fatal(json.Unmarshal(raw, menu))
+	tests.Fatal(c.CacheMenu(menu))
+	tests.Fatal(data.PinStore(r.DB(), r.Address(), "4336"))
+
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	m := &out.Menu{}
+	tests.Fatal(json.Unmarshal(r.Out.Bytes(), m))
+	if m.StoreID != "4336" || len(m.Categories) == 0 {
+		t.Errorf("bad menu output: %+v", m)
+	}
+	r.ClearBuf()
+
+	tests.Check(c.Run(c.Cmd(), []string{"S_PIZZA"}))
+	item := &out.Item{}
+	tests.Fatal(json.Unmarshal(r.Out.Bytes(), item))
+	if item.Code != "S_PIZZA" || item.Type != out.ProductItem {
+		t.Errorf("bad item output: %+v", item)
+	}
+	r.ClearBuf()
+
+	c.toppings = true
+	tests.Check(c.Run(c.Cmd(), []string{}))
+	toppings := map[string][]out.Topping{}
+	tests.Fatal(json.Unmarshal(r.Out.Bytes(), &toppings))
+	if len(toppings["Pizza"]) == 0 {
+		t.Errorf("expected pizza toppings, got %v", toppings)
+	}
+}
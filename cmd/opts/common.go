@@ -6,8 +6,17 @@ import (
 	"github.com/spf13/pflag"
 )
 
-// MenuUpdateTime is the time a menu is persistant in cache
-const MenuUpdateTime = 12 * time.Hour
+const (
+	// MenuUpdateTime is the time a menu is persistant in cache
+	MenuUpdateTime = 12 * time.Hour
+
+	// StoreUpdateTime is the time a store profile is persistant in cache
+	StoreUpdateTime = 24 * time.Hour
+
+	// StoreStatusUpdateTime is the time that the open status and wait times of
+	// a cached store are trusted before being refreshed.
+	StoreStatusUpdateTime = 10 * time.Minute
+)
 
 // CliFlags for the root apizza command.
 type CliFlags struct {
@@ -17,6 +26,10 @@ type CliFlags struct {
 	ClearCache bool
 	ResetMenu  bool
 	LogFile    string
+	Offline    bool
+	Profile    string
+	Output     Format
+	Color      ColorMode
 }
 
 // Install the RootFlags
@@ -27,6 +40,12 @@ func (rf *CliFlags) Install(persistflags *pflag.FlagSet) {
 
 	persistflags.StringVarP(&rf.Address, "address", "A", rf.Address, "an address name stored with 'apizza address --new'")
 	persistflags.StringVar(&rf.Service, "service", rf.Service, "select a Dominos service, either 'Delivery' or 'Carryout'")
+	persistflags.BoolVar(&rf.Offline, "offline", false, "only use cached data and never connect to dominos")
+	persistflags.StringVar(&rf.Profile, "profile", "", "use a config profile (can also be set with $APIZZA_PROFILE)")
+	rf.Output = Table
+	persistflags.VarP(&rf.Output, "output", "o", "the output format, one of table, json, or yaml")
+	rf.Color = ColorAuto
+	persistflags.Var(&rf.Color, "color", "when to use color, one of auto, always, or never")
 }
 
 // ApizzaFlags that are not persistant.
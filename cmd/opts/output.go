@@ -0,0 +1,86 @@
+package opts
+
+import "fmt"
+
+// Format is the format that commands print their output in. It can be used
+// as a flag value.
+type Format string
+
+const (
+	// Table is the human readable output format.
+	Table Format = "table"
+	// JSON prints output as json.
+	JSON Format = "json"
+	// YAML prints output as yaml.
+	YAML Format = "yaml"
+)
+
+// Formats is every output format.
+var Formats = []Format{Table, JSON, YAML}
+
+// Structured returns true if the format is meant to be read by other programs.
+func (f Format) Structured() bool {
+	return f == JSON || f == YAML
+}
+
+func (f *Format) String() string {
+	if *f == "" {
+		return string(Table)
+	}
+	return string(*f)
+}
+
+// Set will set the format and fails if the format is not one of Formats.
+func (f *Format) Set(s string) error {
+	for _, format := range Formats {
+		if Format(s) == format {
+			*f = format
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format '%s', must be one of %v", s, Formats)
+}
+
+// Type is the type name shown in the help for flags.
+func (f *Format) Type() string {
+	return "format"
+}
+
+// ColorMode sets when output is colored. It can be used as a flag value.
+type ColorMode string
+
+const (
+	// ColorAuto only uses color when writing to a terminal and NO_COLOR is
+	// not set.
+	ColorAuto ColorMode = "auto"
+	// ColorAlways always uses color.
+	ColorAlways ColorMode = "always"
+	// ColorNever never uses color.
+	ColorNever ColorMode = "never"
+)
+
+// ColorModes is every color mode.
+var ColorModes = []ColorMode{ColorAuto, ColorAlways, ColorNever}
+
+func (m *ColorMode) String() string {
+	if *m == "" {
+		return string(ColorAuto)
+	}
+	return string(*m)
+}
+
+// Set will set the color mode and fails if the mode is not one of ColorModes.
+func (m *ColorMode) Set(s string) error {
+	for _, mode := range ColorModes {
+		if ColorMode(s) == mode {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown color mode '%s', must be one of %v", s, ColorModes)
+}
+
+// Type is the type name shown in the help for flags.
+func (m *ColorMode) Type() string {
+	return "when"
+}
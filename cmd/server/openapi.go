@@ -0,0 +1,258 @@
+package server
+
+// openAPI is the OpenAPI description of the api served at /openapi.json.
+const openAPI = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "apizza",
+    "description": "The apizza menu and cart. Every path under /api needs a bearer token.",
+    "version": "1"
+  },
+  "security": [{"token": []}],
+  "paths": {
+    "/api/menu": {
+      "get": {
+        "summary": "Get the menu of the store for the user's address.",
+        "responses": {
+          "200": {"description": "The menu.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Menu"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/api/menu/toppings": {
+      "get": {
+        "summary": "Get the toppings on the menu by topping category.",
+        "responses": {
+          "200": {"description": "The toppings.", "content": {"application/json": {"schema": {
+            "type": "object",
+            "additionalProperties": {"type": "array", "items": {"$ref": "#/components/schemas/Topping"}}
+          }}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/api/menu/categories/{name}": {
+      "get": {
+        "summary": "Get a menu category by name or code.",
+        "parameters": [{"name": "name", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "The category.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Category"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/api/menu/items/{code}": {
+      "get": {
+        "summary": "Get a product, variant, or preconfigured product by code.",
+        "parameters": [{"name": "code", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "The item.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Item"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/api/orders": {
+      "get": {
+        "summary": "List the orders in the cart.",
+        "responses": {
+          "200": {"description": "The orders sorted by name.", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Order"}}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "post": {
+        "summary": "Create a new order.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/OrderRequest"}}}},
+        "responses": {
+          "201": {"description": "The new order.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/api/orders/{name}": {
+      "parameters": [{"$ref": "#/components/parameters/OrderName"}],
+      "get": {
+        "summary": "Get an order.",
+        "responses": {
+          "200": {"description": "The order.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "put": {
+        "summary": "Replace the products of an order.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/OrderRequest"}}}},
+        "responses": {
+          "200": {"description": "The changed order.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      },
+      "delete": {
+        "summary": "Delete an order.",
+        "responses": {
+          "204": {"description": "The order was deleted."},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/api/orders/{name}/price": {
+      "parameters": [{"$ref": "#/components/parameters/OrderName"}],
+      "get": {
+        "summary": "Get the price of an order from dominos.",
+        "responses": {
+          "200": {"description": "The order with its price.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/api/orders/{name}/validate": {
+      "parameters": [{"$ref": "#/components/parameters/OrderName"}],
+      "post": {
+        "summary": "Send an order to the dominos order-validation endpoint.",
+        "responses": {
+          "200": {"description": "The result of the validation.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Validation"}}}},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/api/orders/{name}/place": {
+      "parameters": [{"$ref": "#/components/parameters/OrderName"}],
+      "post": {
+        "summary": "Send an order to dominos. Only works when the server was started with --allow-orders.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Customer"}}}},
+        "responses": {
+          "200": {"description": "The order that was sent.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}},
+          "403": {"$ref": "#/components/responses/Error"},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "token": {"type": "http", "scheme": "bearer"}
+    },
+    "parameters": {
+      "OrderName": {"name": "name", "in": "path", "required": true, "schema": {"type": "string"}}
+    },
+    "responses": {
+      "Error": {"description": "An error.", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+    },
+    "schemas": {
+      "Error": {
+        "type": "object",
+        "properties": {"error": {"type": "string"}}
+      },
+      "Menu": {
+        "type": "object",
+        "properties": {
+          "store_id": {"type": "string"},
+          "categories": {"type": "array", "items": {"$ref": "#/components/schemas/Category"}}
+        }
+      },
+      "Category": {
+        "type": "object",
+        "properties": {
+          "code": {"type": "string"},
+          "name": {"type": "string"},
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/Item"}},
+          "categories": {"type": "array", "items": {"$ref": "#/components/schemas/Category"}}
+        }
+      },
+      "Item": {
+        "type": "object",
+        "properties": {
+          "code": {"type": "string"},
+          "name": {"type": "string"},
+          "type": {"type": "string", "enum": ["product", "variant", "preconfigured"]},
+          "category": {"type": "string"},
+          "price": {"type": "number"},
+          "product_code": {"type": "string"},
+          "description": {"type": "string"},
+          "size": {"type": "string"},
+          "toppings": {"type": "object", "additionalProperties": {"type": "string"}},
+          "variants": {"type": "array", "items": {"$ref": "#/components/schemas/Item"}}
+        }
+      },
+      "Topping": {
+        "type": "object",
+        "properties": {
+          "code": {"type": "string"},
+          "name": {"type": "string"}
+        }
+      },
+      "Order": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "store_id": {"type": "string"},
+          "service": {"type": "string", "enum": ["Delivery", "Carryout"]},
+          "address": {"$ref": "#/components/schemas/Address"},
+          "products": {"type": "array", "items": {"$ref": "#/components/schemas/OrderProduct"}},
+          "price": {"type": "number"}
+        }
+      },
+      "OrderProduct": {
+        "type": "object",
+        "properties": {
+          "code": {"type": "string"},
+          "name": {"type": "string"},
+          "quantity": {"type": "integer"},
+          "options": {"type": "object", "additionalProperties": {"type": "string"}}
+        }
+      },
+      "Address": {
+        "type": "object",
+        "properties": {
+          "street": {"type": "string"},
+          "city": {"type": "string"},
+          "state": {"type": "string"},
+          "zipcode": {"type": "string"}
+        }
+      },
+      "OrderRequest": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string", "description": "Required when creating an order."},
+          "service": {"type": "string", "enum": ["Delivery", "Carryout"]},
+          "products": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "required": ["code"],
+              "properties": {
+                "code": {"type": "string", "description": "The code of a variant on the menu."},
+                "quantity": {"type": "integer", "minimum": 1, "default": 1},
+                "toppings": {
+                  "type": "array",
+                  "description": "Toppings formatted as <code>:<side>:<amount>, like P:left:1.5",
+                  "items": {"type": "string"}
+                }
+              }
+            }
+          }
+        }
+      },
+      "Validation": {
+        "type": "object",
+        "properties": {
+          "valid": {"type": "boolean"},
+          "message": {"type": "string"}
+        }
+      },
+      "Customer": {
+        "type": "object",
+        "description": "Anything left out is taken from the config file, except the cvv.",
+        "required": ["cvv"],
+        "properties": {
+          "cvv": {"type": "integer"},
+          "number": {"type": "string"},
+          "expiration": {"type": "string"},
+          "first_name": {"type": "string"},
+          "last_name": {"type": "string"},
+          "email": {"type": "string"},
+          "phone": {"type": "string"}
+        }
+      }
+    }
+  }
+}
+`
@@ -0,0 +1,629 @@
+// Package server serves the menu and the cart as a JSON api.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/harrybrwn/apizza/cmd/client"
+	"github.com/harrybrwn/apizza/cmd/internal"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/obj"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/cmd/internal/webhook"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/cache"
+)
+
+// maxBodySize is the largest request body that the server will read.
+const maxBodySize = 1 << 20
+
+var (
+	errNoToken      = errors.New("the server needs a token")
+	errNotFound     = errors.New("not found")
+	errOrderExists  = errors.New("an order with that name already exists")
+	errNoOrderName  = errors.New("orders need a name")
+	errOrdersOff    = errors.New("placing orders is turned off (see 'apizza serve --allow-orders')")
+	errNoCVV        = errors.New("must have cvv number")
+	errNoCardNumber = errors.New("no card number given")
+	errNoCardExp    = errors.New("no card expiration date given")
+)
+
+// Options configures a Server.
+type Options struct {
+	// DB is the database that orders are stored in.
+	DB cache.Backend
+	// Client finds the store and menu for the user's address.
+	Client client.Client
+	// Service is the service method used for new orders.
+	Service string
+	// Token is the bearer token that every request must have.
+	Token string
+	// AllowOrders lets clients send orders to dominos.
+	AllowOrders bool
+	// Customer has the defaults used when an order is placed.
+	Customer Customer
+	// Webhooks are sent events when orders are priced or placed.
+	Webhooks *webhook.Notifier
+}
+
+// Customer is the customer and payment information used to place an order.
+type Customer struct {
+	FirstName  string `json:"first_name,omitempty"`
+	LastName   string `json:"last_name,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Phone      string `json:"phone,omitempty"`
+	Number     string `json:"number,omitempty"`
+	Expiration string `json:"expiration,omitempty"`
+	CVV        int    `json:"cvv,omitempty"`
+}
+
+// Server is an http.Handler for the apizza api.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+
+	// mu is held while the menu, store, or database is being used so that
+	// requests do not change the same order at the same time.
+	mu sync.Mutex
+}
+
+// releaser is a database that can be closed between requests so that other
+// apizza commands can use it while the server is running.
+type releaser interface {
+	Release() error
+}
+
+// New creates a new Server.
+func New(opts Options) (*Server, error) {
+	if opts.Token == "" {
+		return nil, errNoToken
+	}
+	s := &Server{opts: opts, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/openapi.json", s.openapi)
+	s.mux.Handle("/api/menu", s.auth(s.menu))
+	s.mux.Handle("/api/menu/", s.auth(s.menuItem))
+	s.mux.Handle("/api/orders", s.auth(s.orders))
+	s.mux.Handle("/api/orders/", s.auth(s.order))
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// auth checks the bearer token before calling a handler.
+func (s *Server) auth(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(header, "Bearer ")
+		if token == header || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="apizza"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong token"))
+			return
+		}
+		h(w, r)
+	})
+}
+
+// lock locks the server and returns a function that releases the database
+// and unlocks the server.
+func (s *Server) lock() func() {
+	s.mu.Lock()
+	return func() {
+		if db, ok := s.opts.DB.(releaser); ok {
+			db.Release()
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) openapi(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, openAPI)
+}
+
+// GET /api/menu
+func (s *Server) menu(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	defer s.lock()()
+	m, err := s.getMenu()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out.NewMenu(m, categories(m)))
+}
+
+// GET /api/menu/toppings
+// GET /api/menu/categories/{name}
+// GET /api/menu/items/{code}
+func (s *Server) menuItem(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	defer s.lock()()
+	m, err := s.getMenu()
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/menu/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "toppings":
+		writeJSON(w, http.StatusOK, out.NewToppings(m.Toppings))
+		return
+	case len(parts) == 2 && parts[0] == "categories":
+		name := strings.ToLower(parts[1])
+		for _, cat := range categories(m) {
+			if name == strings.ToLower(cat.Name) || name == strings.ToLower(cat.Code) {
+				writeJSON(w, http.StatusOK, out.NewCategory(cat, m))
+				return
+			}
+		}
+	case len(parts) == 2 && parts[0] == "items":
+		if item := m.FindItem(parts[1]); item != nil {
+			writeJSON(w, http.StatusOK, out.NewItem(item, m))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, errNotFound)
+}
+
+// GET /api/orders
+// POST /api/orders
+func (s *Server) orders(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	defer s.lock()()
+	if r.Method == http.MethodGet {
+		orders, err := data.Orders(s.opts.DB)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		list := make([]*out.Order, len(orders))
+		for i, o := range orders {
+			list[i] = out.NewOrder(o, 0, false)
+		}
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+
+	var req orderRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := checkName(req.Name); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if s.opts.DB.Exists(data.OrderPrefix + req.Name) {
+		writeError(w, http.StatusConflict, errOrderExists)
+		return
+	}
+	order, err := s.newOrder(req.Name)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	if err = s.update(order, &req); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	if err = data.PutOrder(order, s.opts.DB); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, out.NewOrder(order, 0, false))
+}
+
+// GET /api/orders/{name}
+// PUT /api/orders/{name}
+// DELETE /api/orders/{name}
+// GET /api/orders/{name}/price
+// POST /api/orders/{name}/validate
+// POST /api/orders/{name}/place
+func (s *Server) order(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/orders/"), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	name, action := parts[0], ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	switch action {
+	case "":
+		if !allow(w, r, http.MethodGet, http.MethodPut, http.MethodDelete) {
+			return
+		}
+	case "price":
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+	case "validate", "place":
+		if !allow(w, r, http.MethodPost) {
+			return
+		}
+	default:
+		writeError(w, http.StatusNotFound, errNotFound)
+		return
+	}
+	if action == "place" && !s.opts.AllowOrders {
+		writeError(w, http.StatusForbidden, errOrdersOff)
+		return
+	}
+
+	defer s.lock()()
+	if !s.opts.DB.Exists(data.OrderPrefix + name) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("cannot find order %s", name))
+		return
+	}
+	order, err := data.GetOrder(name, s.opts.DB)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	switch action {
+	case "":
+		s.editOrder(w, r, order)
+	case "price":
+		s.price(w, order)
+	case "validate":
+		s.validate(w, order)
+	case "place":
+		s.place(w, r, order)
+	}
+}
+
+func (s *Server) editOrder(w http.ResponseWriter, r *http.Request, order *dawg.Order) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, out.NewOrder(order, 0, false))
+	case http.MethodDelete:
+		if err := s.opts.DB.Delete(data.OrderPrefix + order.Name()); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPut:
+		var req orderRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Name != "" && req.Name != order.Name() {
+			writeError(w, http.StatusBadRequest, errors.New("cannot change the name of an order"))
+			return
+		}
+		order.Products = []*dawg.OrderProduct{}
+		if err := s.update(order, &req); err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		if err := data.PutOrder(order, s.opts.DB); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, out.NewOrder(order, 0, false))
+	}
+}
+
+func (s *Server) price(w http.ResponseWriter, order *dawg.Order) {
+	if err := s.locate(order); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	price, err := order.Price()
+	s.notify(webhook.OrderEvent(webhook.OrderPriced, order, price, err))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out.NewOrder(order, price, true))
+}
+
+// validation is the response to a validation request.
+type validation struct {
+	Valid   bool   `json:"valid"`
+	Message string `json:"message,omitempty"`
+}
+
+func (s *Server) validate(w http.ResponseWriter, order *dawg.Order) {
+	if err := s.locate(order); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	err := order.Validate()
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, validation{Valid: true})
+	case dawg.IsWarning(err):
+		writeJSON(w, http.StatusOK, validation{Valid: true, Message: err.Error()})
+	case dawg.IsFailure(err):
+		writeJSON(w, http.StatusOK, validation{Valid: false, Message: err.Error()})
+	default:
+		writeError(w, statusOf(err), err)
+	}
+}
+
+func (s *Server) place(w http.ResponseWriter, r *http.Request, order *dawg.Order) {
+	var c Customer
+	if err := readJSON(r, &c); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.customer(order, c); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.locate(order); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	err := order.PlaceOrder()
+	log.Println("sending order:", dawg.OrderToJSON(order))
+	if err != nil {
+		s.record(order, 0, err)
+		s.notify(webhook.OrderEvent(webhook.OrderPlaced, order, 0, err))
+		writeError(w, statusOf(err), err)
+		return
+	}
+	price, _ := order.Price()
+	s.record(order, price, nil)
+	s.notify(webhook.OrderEvent(webhook.OrderPlaced, order, price, nil))
+	writeJSON(w, http.StatusOK, out.NewOrder(order, price, price > 0))
+}
+
+// notify sends an event to the webhooks. Errors are only logged.
+func (s *Server) notify(e *webhook.Event) {
+	if err := s.opts.Webhooks.Notify(e); err != nil {
+		log.Println(err)
+	}
+}
+
+// record adds an order that was sent to dominos to the order history. Errors
+// are only logged.
+func (s *Server) record(order *dawg.Order, price float64, err error) {
+	if _, err = data.RecordOrder(s.opts.DB, order, price, "serve", err); err != nil {
+		log.Println("could not record order:", err)
+	}
+}
+
+// customer adds the customer and payment information to an order. Anything
+// missing from the request is taken from the server's defaults.
+func (s *Server) customer(order *dawg.Order, c Customer) error {
+	def := s.opts.Customer
+	if c.CVV == 0 {
+		return errNoCVV
+	}
+	number := eitherOr(c.Number, def.Number)
+	exp := eitherOr(c.Expiration, def.Expiration)
+	if number == "" {
+		return errNoCardNumber
+	}
+	if exp == "" {
+		return errNoCardExp
+	}
+	card := dawg.NewCard(number, exp, c.CVV)
+	if err := dawg.ValidateCard(card); err != nil {
+		return err
+	}
+	order.AddCard(card)
+	order.FirstName = eitherOr(c.FirstName, def.FirstName)
+	order.LastName = eitherOr(c.LastName, def.LastName)
+	order.Email = eitherOr(c.Email, def.Email)
+	order.Phone = eitherOr(c.Phone, def.Phone)
+	return nil
+}
+
+// orderRequest is the body used to create or change an order.
+type orderRequest struct {
+	Name     string           `json:"name"`
+	Service  string           `json:"service"`
+	Products []productRequest `json:"products"`
+}
+
+type productRequest struct {
+	Code     string   `json:"code"`
+	Quantity int      `json:"quantity"`
+	Toppings []string `json:"toppings"`
+}
+
+// newOrder creates an empty order for the user's address.
+func (s *Server) newOrder(name string) (*dawg.Order, error) {
+	addr, err := s.address()
+	if err != nil {
+		return nil, err
+	}
+	store, err := s.opts.Client.FindStore()
+	if err != nil {
+		return nil, err
+	}
+	order := &dawg.Order{
+		LanguageCode:  dawg.DefaultLang,
+		ServiceMethod: s.opts.Service,
+		StoreID:       store.ID,
+		Products:      []*dawg.OrderProduct{},
+		Address:       dawg.StreetAddrFromAddress(addr),
+	}
+	order.Init()
+	order.SetName(name)
+	return order, nil
+}
+
+// update changes the service and adds the products of a request to an order.
+func (s *Server) update(order *dawg.Order, req *orderRequest) error {
+	switch req.Service {
+	case "":
+	case dawg.Delivery, dawg.Carryout:
+		order.ServiceMethod = req.Service
+	default:
+		return badRequest(fmt.Errorf("service must be %s or %s", dawg.Delivery, dawg.Carryout))
+	}
+	if len(req.Products) == 0 {
+		return nil
+	}
+	m, err := s.getMenu()
+	if err != nil {
+		return err
+	}
+	for _, p := range req.Products {
+		v, err := m.GetVariant(p.Code)
+		if err != nil {
+			return badRequest(err)
+		}
+		qty := p.Quantity
+		if qty == 0 {
+			qty = 1
+		} else if qty < 0 {
+			return badRequest(fmt.Errorf("bad quantity for %s", p.Code))
+		}
+		if err = order.AddProductQty(v, qty); err != nil {
+			return err
+		}
+		prod := order.Products[len(order.Products)-1]
+		for _, t := range p.Toppings {
+			if err = internal.AddTopping(t, prod); err != nil {
+				return badRequest(fmt.Errorf("%s: %v", t, err))
+			}
+		}
+	}
+	return nil
+}
+
+// locate moves an order to the user's current address and store.
+func (s *Server) locate(order *dawg.Order) error {
+	addr, err := s.address()
+	if err != nil {
+		return err
+	}
+	if order.Address != nil && order.Address.Equal(addr) {
+		return nil
+	}
+	order.Address = dawg.StreetAddrFromAddress(addr)
+	store, err := client.LocateStore(s.opts.DB, addr, order.ServiceMethod)
+	if err != nil {
+		return err
+	}
+	order.StoreID = store.ID
+	return nil
+}
+
+func (s *Server) address() (dawg.Address, error) {
+	addr := s.opts.Client.Address()
+	if addr == nil || obj.AddrIsEmpty(addr) {
+		return nil, internal.ErrNoAddress
+	}
+	return addr, nil
+}
+
+func (s *Server) getMenu() (*dawg.Menu, error) {
+	if _, err := s.address(); err != nil {
+		return nil, err
+	}
+	if err := s.opts.Client.UpdateMenu(); err != nil {
+		return nil, err
+	}
+	return s.opts.Client.Menu(), nil
+}
+
+func categories(m *dawg.Menu) []dawg.MenuCategory {
+	all := m.Categorization.Food.Categories
+	return append(all[:len(all):len(all)], m.Categorization.Preconfigured.Categories...)
+}
+
+func checkName(name string) error {
+	if name == "" {
+		return errNoOrderName
+	}
+	if strings.ContainsAny(name, "/?#") {
+		return errors.New("order names cannot have '/', '?', or '#'")
+	}
+	return nil
+}
+
+// badRequestErr is an error caused by the contents of a request.
+type badRequestErr struct{ error }
+
+func badRequest(err error) error { return badRequestErr{err} }
+
+// statusOf picks the http status for an error.
+func statusOf(err error) int {
+	var br badRequestErr
+	switch {
+	case errors.As(err, &br):
+		return http.StatusBadRequest
+	case err == internal.ErrNoAddress:
+		return http.StatusServiceUnavailable
+	case internal.IsNetworkErr(err):
+		return http.StatusBadGateway
+	case dawg.IsFailure(err):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// allow checks the method of a request and writes an error if it is not one
+// of the methods given.
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("bad request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// apiError is the body of every error response.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
+
+func eitherOr(s1, s2 string) string {
+	if s1 == "" {
+		return s2
+	}
+	return s1
+}
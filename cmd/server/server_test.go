@@ -0,0 +1,253 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/cmd/internal/out"
+	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+const testToken = "secret"
+
+// testClient is a client.Client that uses the menu from the dawg tests and
+// never uses the network.
+type testClient struct {
+	menu *dawg.Menu
+	addr dawg.Address
+}
+
+func (c *testClient) Store() *dawg.Store              { return &dawg.Store{ID: "4336"} }
+func (c *testClient) FindStore() (*dawg.Store, error) { return c.Store(), nil }
+func (c *testClient) Address() dawg.Address           { return c.addr }
+func (c *testClient) UpdateMenu() error               { return nil }
+func (c *testClient) Menu() *dawg.Menu                { return c.menu }
+func (c *testClient) LoadCached(string) error         { return nil }
+func (c *testClient) CacheMenu(*dawg.Menu) error      { return nil }
+func (c *testClient) CachedAt() (time.Time, error)    { return time.Now(), nil }
+
+func testServer(t *testing.T, allowOrders bool) (*Server, *cmdtest.Recorder) {
+	raw, err := ioutil.ReadFile("../../dawg/testdata/menu.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	menu := &dawg.Menu{}
+	if err = json.Unmarshal(raw, menu); err != nil {
+		t.Fatal(err)
+	}
+	r := cmdtest.NewRecorder()
+	s, err := New(Options{
+		DB:          r.DB(),
+		Client:      &testClient{menu: menu, addr: cmdtest.TestAddress()},
+		Service:     dawg.Carryout,
+		Token:       testToken,
+		AllowOrders: allowOrders,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, r
+}
+
+func do(s *Server, method, path, body string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		req = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, rec.Code, rec.Body)
+	}
+	if v == nil {
+		return
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("could not decode %q: %v", rec.Body, err)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	tests.InitHelpers(t)
+	_, err := New(Options{})
+	tests.Exp(err, "should need a token")
+
+	s, r := testServer(t, false)
+	defer r.CleanUp()
+	for _, auth := range []string{"", "Bearer wrong", testToken, "Basic " + testToken} {
+		req := httptest.NewRequest("GET", "/api/orders", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected %d with %q, got %d", http.StatusUnauthorized, auth, rec.Code)
+		}
+	}
+	decode(t, do(s, "GET", "/api/orders", ""), http.StatusOK, nil)
+
+	// the api description does not need a token
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+	var spec struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	decode(t, rec, http.StatusOK, &spec)
+	if spec.OpenAPI == "" || len(spec.Paths) == 0 {
+		t.Error("bad openapi description")
+	}
+	for _, path := range []string{
+		"/api/menu", "/api/menu/toppings", "/api/menu/categories/{name}",
+		"/api/menu/items/{code}", "/api/orders", "/api/orders/{name}",
+		"/api/orders/{name}/price", "/api/orders/{name}/validate",
+		"/api/orders/{name}/place",
+	} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("%s is not in the openapi description", path)
+		}
+	}
+}
+
+func TestMenu(t *testing.T) {
+	s, r := testServer(t, false)
+	defer r.CleanUp()
+
+	var menu out.Menu
+	decode(t, do(s, "GET", "/api/menu", ""), http.StatusOK, &menu)
+	if len(menu.Categories) == 0 {
+		t.Error("menu should have categories")
+	}
+	var cat out.Category
+	decode(t, do(s, "GET", "/api/menu/categories/pizza", ""), http.StatusOK, &cat)
+	if cat.Code != "Pizza" {
+		t.Errorf("wrong category %q", cat.Code)
+	}
+	var item out.Item
+	decode(t, do(s, "GET", "/api/menu/items/B8PCPT", ""), http.StatusOK, &item)
+	if item.Code != "B8PCPT" || item.Type != "variant" {
+		t.Errorf("wrong item: %+v", item)
+	}
+	var toppings map[string][]out.Topping
+	decode(t, do(s, "GET", "/api/menu/toppings", ""), http.StatusOK, &toppings)
+	if len(toppings["Pizza"]) == 0 {
+		t.Error("should have pizza toppings")
+	}
+
+	decode(t, do(s, "GET", "/api/menu/items/nothing", ""), http.StatusNotFound, nil)
+	decode(t, do(s, "GET", "/api/menu/categories/nothing", ""), http.StatusNotFound, nil)
+	decode(t, do(s, "POST", "/api/menu", ""), http.StatusMethodNotAllowed, nil)
+}
+
+func TestOrders(t *testing.T) {
+	tests.InitHelpers(t)
+	s, r := testServer(t, false)
+	defer r.CleanUp()
+
+	var order out.Order
+	rec := do(s, "POST", "/api/orders", `{
+		"name": "dinner",
+		"products": [{"code": "14SCREEN", "quantity": 2, "toppings": ["P:left:1.5"]}]
+	}`)
+	decode(t, rec, http.StatusCreated, &order)
+	if order.Name != "dinner" || order.StoreID != "4336" || order.Service != dawg.Carryout {
+		t.Errorf("wrong order: %+v", order)
+	}
+	if len(order.Products) != 1 || order.Products[0].Quantity != 2 {
+		t.Fatalf("wrong products: %+v", order.Products)
+	}
+	if order.Products[0].Options["P"] == "" {
+		t.Error("pepperoni should have been added")
+	}
+
+	// the order is in the same database as the cart
+	saved, err := data.GetOrder("dinner", r.DB())
+	tests.Fatal(err)
+	if len(saved.Products) != 1 || saved.Products[0].Code != "14SCREEN" {
+		t.Error("order was not saved")
+	}
+	if _, ok := s.opts.Client.Menu().Variants["14SCREEN"].Tags["DefaultToppings"]; !ok {
+		t.Error("menu should not lose its tags")
+	}
+	if _, ok := s.opts.Client.Menu().Variants["14SCREEN"].Options()["P"]; ok {
+		t.Error("toppings should not be added to the menu")
+	}
+
+	decode(t, do(s, "POST", "/api/orders", `{"name": "dinner"}`), http.StatusConflict, nil)
+	decode(t, do(s, "POST", "/api/orders", `{"products": []}`), http.StatusBadRequest, nil)
+	decode(t, do(s, "POST", "/api/orders", `{"name": "x", "products": [{"code": "nope"}]}`), http.StatusBadRequest, nil)
+	decode(t, do(s, "POST", "/api/orders", `{"name": "x", "service": "Pickup"}`), http.StatusBadRequest, nil)
+	decode(t, do(s, "POST", "/api/orders", `{"name": "x", "extra": 1}`), http.StatusBadRequest, nil)
+	decode(t, do(s, "POST", "/api/orders", `{"name": "x", "products": [{"code": "14SCREEN", "toppings": ["P:top"]}]}`), http.StatusBadRequest, nil)
+
+	rec = do(s, "PUT", "/api/orders/dinner", `{
+		"service": "Delivery",
+		"products": [{"code": "2LCOKE"}, {"code": "14SCREEN"}]
+	}`)
+	decode(t, rec, http.StatusOK, &order)
+	if len(order.Products) != 2 || order.Service != dawg.Delivery {
+		t.Errorf("order was not changed: %+v", order)
+	}
+	decode(t, do(s, "PUT", "/api/orders/dinner", `{"name": "lunch"}`), http.StatusBadRequest, nil)
+
+	var list []out.Order
+	decode(t, do(s, "GET", "/api/orders", ""), http.StatusOK, &list)
+	if len(list) != 1 || list[0].Name != "dinner" || len(list[0].Products) != 2 {
+		t.Errorf("wrong orders: %+v", list)
+	}
+	decode(t, do(s, "GET", "/api/orders/dinner", ""), http.StatusOK, &order)
+	if order.Name != "dinner" {
+		t.Error("wrong order")
+	}
+
+	decode(t, do(s, "POST", "/api/orders/dinner/place", `{"cvv": 123}`), http.StatusForbidden, nil)
+	decode(t, do(s, "GET", "/api/orders/dinner/validate", ""), http.StatusMethodNotAllowed, nil)
+	decode(t, do(s, "GET", "/api/orders/dinner/other", ""), http.StatusNotFound, nil)
+	decode(t, do(s, "GET", "/api/orders/lunch", ""), http.StatusNotFound, nil)
+	decode(t, do(s, "GET", "/api/orders/lunch/price", ""), http.StatusNotFound, nil)
+
+	decode(t, do(s, "DELETE", "/api/orders/dinner", ""), http.StatusNoContent, nil)
+	decode(t, do(s, "GET", "/api/orders/dinner", ""), http.StatusNotFound, nil)
+	decode(t, do(s, "GET", "/api/orders", ""), http.StatusOK, &list)
+	if len(list) != 0 {
+		t.Error("order should have been deleted")
+	}
+}
+
+func TestPlaceOrder(t *testing.T) {
+	s, r := testServer(t, true)
+	defer r.CleanUp()
+	decode(t, do(s, "POST", "/api/orders", `{"name": "dinner"}`), http.StatusCreated, nil)
+
+	var e apiError
+	decode(t, do(s, "POST", "/api/orders/dinner/place", `{}`), http.StatusBadRequest, &e)
+	if e.Error != errNoCVV.Error() {
+		t.Errorf("wrong error: %q", e.Error)
+	}
+	decode(t, do(s, "POST", "/api/orders/dinner/place", `{"cvv": 123}`), http.StatusBadRequest, &e)
+	if e.Error != errNoCardNumber.Error() {
+		t.Errorf("wrong error: %q", e.Error)
+	}
+	s.opts.Customer = Customer{Number: "4111111111111111"}
+	decode(t, do(s, "POST", "/api/orders/dinner/place", `{"cvv": 123}`), http.StatusBadRequest, &e)
+	if e.Error != errNoCardExp.Error() {
+		t.Errorf("wrong error: %q", e.Error)
+	}
+}
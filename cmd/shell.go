@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	fp "path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/harrybrwn/apizza/cmd/cli"
+	"github.com/harrybrwn/apizza/pkg/config"
+)
+
+const (
+	// ShellHistoryFile is the name of the file in the config folder that
+	// keeps the shell's history.
+	ShellHistoryFile = "shell_history"
+
+	shellPrompt     = "apizza> "
+	shellHistoryLen = 1000
+)
+
+// NewShellCmd creates the 'shell' command.
+func NewShellCmd(b cli.Builder) cli.CliCommand {
+	c := &shellCmd{in: os.Stdin}
+	c.app, _ = b.(*App)
+	c.CliCommand = b.Build("shell", "Start an interactive apizza shell.", c)
+	c.SetOutput(b.Output())
+	c.Cmd().Long = `The shell command starts a prompt that runs apizza commands without the
+'apizza' prefix. The database, config, and store stay loaded between commands
+so they run faster than separate apizza commands.
+
+Commands and flags, cached menu categories, and order names can be completed
+with tab, and the command history is saved in the config folder. Type 'exit'
+or press ctrl-d to leave the shell.`
+	c.Cmd().Args = cobra.NoArgs
+	return c
+}
+
+// `apizza shell`
+type shellCmd struct {
+	cli.CliCommand
+	app *App
+	in  io.Reader
+}
+
+// lineReader reads the lines typed into the shell.
+type lineReader interface {
+	Prompt(string) (string, error)
+	AppendHistory(string)
+	Close() error
+}
+
+func (c *shellCmd) Run(cmd *cobra.Command, args []string) error {
+	if c.app == nil {
+		return errors.New("the shell can only be started from apizza")
+	}
+	if c.app.shell {
+		return errors.New("already in an apizza shell")
+	}
+	c.app.shell = true
+	defer func() { c.app.shell = false }()
+
+	// Open the database for writing before any command is run. Read-only
+	// commands would otherwise open it in read-only mode for the whole shell.
+	c.app.db.SetReadOnly(false)
+	if _, err := c.app.db.Buckets(); err != nil {
+		return err
+	}
+
+	var lines lineReader
+	if f, ok := c.in.(*os.File); ok && f == os.Stdin && liner.TerminalSupported() {
+		state := liner.NewLiner()
+		state.SetCtrlCAborts(true)
+		state.SetCompleter(c.complete)
+		lines = state
+	} else {
+		lines = &plainReader{r: bufio.NewReader(c.in), w: c.Output()}
+	}
+	defer lines.Close()
+
+	history := readHistory(c.historyFile())
+	for _, line := range history {
+		lines.AppendHistory(line)
+	}
+	defer func() {
+		if err := writeHistory(c.historyFile(), history); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Warning: could not save the shell history:", err)
+		}
+	}()
+
+	for {
+		line, err := lines.Prompt(shellPrompt)
+		if err == io.EOF {
+			fmt.Fprintln(c.Output())
+			return nil
+		} else if err == liner.ErrPromptAborted {
+			continue
+		} else if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !hasCardFlag(line) {
+			lines.AppendHistory(line)
+			history = append(history, line)
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "apizza":
+			args = args[1:]
+		}
+		if err = c.exec(args); err != nil {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+		}
+	}
+}
+
+// exec runs one line of the shell. Every line gets a new command tree so
+// that flags and arguments from one line are not used by the next one.
+func (c *shellCmd) exec(args []string) error {
+	a := c.app
+	conf := *a.conf
+	root := c.root()
+	root.SetArgs(args)
+	err := root.Execute()
+
+	// --address, --service, and --profile only last for one line
+	a.addr = nil
+	if a.gOpts.Service != "" || a.gOpts.Profile != "" {
+		*a.conf = conf
+	}
+	return err
+}
+
+// root creates a new command tree for the shell.
+func (c *shellCmd) root() *cobra.Command {
+	a := c.app
+	root := a.Build("apizza", a.Cmd().Short, a).Cmd()
+	root.PersistentPreRunE = a.prerun
+	root.PostRunE = a.postrun
+	root.Version = a.Cmd().Version
+	a.installFlags(root)
+	root.AddCommand(AllCommands(a)...)
+	return root
+}
+
+// complete returns the completions for a line in the shell.
+func (c *shellCmd) complete(line string) []string {
+	words := strings.Fields(line)
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	prefix := line[:len(line)-len(partial)]
+
+	root := c.root()
+	cmd, args, err := root.Find(words)
+	if err != nil {
+		return nil
+	}
+	var options []string
+	if strings.HasPrefix(partial, "-") {
+		add := func(f *pflag.Flag) {
+			if !f.Hidden {
+				options = append(options, "--"+f.Name)
+			}
+		}
+		cmd.LocalFlags().VisitAll(add)
+		cmd.InheritedFlags().VisitAll(add)
+	} else {
+		if len(args) == 0 {
+			for _, sub := range cmd.Commands() {
+				if sub.IsAvailableCommand() {
+					options = append(options, sub.Name())
+				}
+			}
+			if cmd == root {
+				options = append(options, "exit")
+			}
+		}
+		if cmd.ValidArgsFunction != nil {
+			valid, _ := cmd.ValidArgsFunction(cmd, args, partial)
+			options = append(options, valid...)
+		}
+	}
+
+	var completions []string
+	for _, opt := range options {
+		if strings.HasPrefix(opt, partial) {
+			completions = append(completions, prefix+opt)
+		}
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+func (c *shellCmd) historyFile() string {
+	return fp.Join(config.Folder(), ShellHistoryFile)
+}
+
+func readHistory(file string) []string {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var history []string
+	for _, line := range strings.Split(string(raw), "\n") {
+		if line != "" {
+			history = append(history, line)
+		}
+	}
+	return history
+}
+
+// cardFlags are the flags that take card details. Lines that use them are
+// left out of the history so the card is not saved in plain text.
+var cardFlags = []string{"--cvv", "--number", "--expiration"}
+
+func hasCardFlag(line string) bool {
+	for _, field := range strings.Fields(line) {
+		for _, flag := range cardFlags {
+			if field == flag || strings.HasPrefix(field, flag+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeHistory(file string, history []string) error {
+	if len(history) > shellHistoryLen {
+		history = history[len(history)-shellHistoryLen:]
+	}
+	if len(history) == 0 {
+		return nil
+	}
+	return ioutil.WriteFile(file, []byte(strings.Join(history, "\n")+"\n"), 0600)
+}
+
+// splitArgs splits a line into arguments like a posix shell. Quotes group
+// words together and a backslash escapes the next character.
+func splitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		arg     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("missing closing %c", quote)
+	}
+	if escaped {
+		return nil, errors.New("nothing after a backslash")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// plainReader reads lines when the shell is not used from a terminal.
+type plainReader struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+func (p *plainReader) Prompt(prompt string) (string, error) {
+	fmt.Fprint(p.w, prompt)
+	line, err := p.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func (p *plainReader) AppendHistory(string) {}
+
+func (p *plainReader) Close() error { return nil }
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/harrybrwn/apizza/cmd/internal/cmdtest"
+	"github.com/harrybrwn/apizza/cmd/internal/data"
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestShell(t *testing.T) {
+	tests.InitHelpers(t)
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	a := CreateApp(r.ToApp())
+	tests.Check(r.DB().Put(data.OrderPrefix+"testorder", []byte(`{"ServiceMethod":"Carryout"}`)))
+
+	sh := NewShellCmd(a).(*shellCmd)
+	stderr := new(bytes.Buffer)
+	sh.Cmd().SetErr(stderr)
+	sh.in = strings.NewReader(strings.Join([]string{
+		"cart -o json",
+		"",
+		"apizza cart",
+		"cart 'test order",
+		"nope",
+		"shell",
+		"order testorder --cvv 123 --number=4111111111111111",
+		"exit",
+		"cart",
+	}, "\n"))
+	defer os.Remove(sh.historyFile())
+	tests.Check(sh.Run(sh.Cmd(), []string{}))
+
+	output := r.Out.String()
+	for _, s := range []string{
+		"apizza> [\n  {\n    \"name\": \"testorder\"",
+		"apizza> apizza> Your Orders:\n  testorder\napizza> ",
+	} {
+		if !strings.Contains(output, s) {
+			t.Errorf("expected %q in the output:\n%s", s, output)
+		}
+	}
+	if strings.Count(output, "Your Orders") != 1 {
+		t.Errorf("the shell should stop after exit:\n%s", output)
+	}
+	for _, s := range []string{
+		"Error: missing closing '\n",
+		"Error: unknown command \"nope\"",
+		"Error: already in an apizza shell\n",
+	} {
+		if !strings.Contains(stderr.String(), s) {
+			t.Errorf("expected %q in stderr:\n%s", s, stderr)
+		}
+	}
+	if a.shell {
+		t.Error("the app should not be in shell mode after the shell exits")
+	}
+
+	raw, err := ioutil.ReadFile(sh.historyFile())
+	tests.Check(err)
+	tests.Compare(t, string(raw), "cart -o json\napizza cart\ncart 'test order\nnope\nshell\nexit\n")
+	tests.Check(ioutil.WriteFile(sh.historyFile(), []byte("menu\n"), 0600))
+	sh.in = strings.NewReader("cart\n")
+	tests.Check(sh.Run(sh.Cmd(), []string{}))
+	raw, err = ioutil.ReadFile(sh.historyFile())
+	tests.Check(err)
+	tests.Compare(t, string(raw), "menu\ncart\n")
+}
+
+func TestShellComplete(t *testing.T) {
+	r := cmdtest.NewRecorder()
+	defer r.CleanUp()
+	a := CreateApp(r.ToApp())
+	tests.InitHelpers(t)
+	tests.Check(r.DB().Put(data.OrderPrefix+"testorder", []byte("{}")))
+	sh := NewShellCmd(a).(*shellCmd)
+
+	for line, exp := range map[string][]string{
+		"ca":          {"cart"},
+		"co":          {"completion", "config"},
+		"cart ":       {"cart new", "cart testorder"},
+		"cart te":     {"cart testorder"},
+		"cart --verb": {"cart --verbose"},
+		"nope ":       nil,
+	} {
+		if got := sh.complete(line); !reflect.DeepEqual(got, exp) {
+			t.Errorf("wrong completion for %q: got %v, want %v", line, got, exp)
+		}
+	}
+	if got := sh.complete("store list --"); len(got) == 0 || !strings.HasPrefix(got[0], "store list --") {
+		t.Errorf("expected flags for the store list command, got %v", got)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	for line, exp := range map[string][]string{
+		"cart":                          {"cart"},
+		"  cart   new  ":                {"cart", "new"},
+		`cart new "my order"`:           {"cart", "new", "my order"},
+		`cart --format '{{.Name}} "x"'`: {"cart", "--format", `{{.Name}} "x"`},
+		`menu a\ b ""`:                  {"menu", "a b", ""},
+		`cart new 'a'"b"c`:              {"cart", "new", "abc"},
+		"":                              nil,
+	} {
+		args, err := splitArgs(line)
+		if err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(args, exp) {
+			t.Errorf("wrong args for %q: got %q, want %q", line, args, exp)
+		}
+	}
+	for _, line := range []string{`cart "new`, `cart \`} {
+		if _, err := splitArgs(line); err == nil {
+			t.Errorf("expected an error for %q", line)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package dawg
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -148,15 +149,25 @@ func newPostReq(u *url.URL, vals url.Values) *http.Request {
 type client struct {
 	*http.Client
 	host string
+
+	// retry is the retry policy for requests sent with do, no requests are
+	// retried if it is nil.
+	retry *RetryPolicy
 }
 
 func (c *client) do(req *http.Request) ([]byte, error) {
+	if c.retry != nil && canRetry(req) {
+		return c.retry.do(c.Client, req)
+	}
 	return do(c.Client, req)
 }
 
 func do(d doer, req *http.Request) ([]byte, error) {
+	return readResponse(d.Do(req))
+}
+
+func readResponse(resp *http.Response, err error) ([]byte, error) {
 	var buf bytes.Buffer
-	resp, err := d.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -186,10 +197,14 @@ func dojson(d doer, v interface{}, r *http.Request) (err error) {
 }
 
 func (c *client) get(path string, params URLParam) ([]byte, error) {
+	return c.getContext(context.Background(), path, params)
+}
+
+func (c *client) getContext(ctx context.Context, path string, params URLParam) ([]byte, error) {
 	if params == nil {
 		params = &Params{}
 	}
-	return c.do(&http.Request{
+	req := &http.Request{
 		Method: "GET",
 		Host:   c.host,
 		Proto:  "HTTP/1.1",
@@ -200,25 +215,8 @@ func (c *client) get(path string, params URLParam) ([]byte, error) {
 			Path:     path,
 			RawQuery: params.Encode(),
 		},
-	})
-}
-
-func get(d doer, host, path string, params URLParam) (*http.Response, error) {
-	if params == nil {
-		params = &Params{}
 	}
-	return d.Do(&http.Request{
-		Method: "GET",
-		Host:   host,
-		Proto:  "HTTP/1.1",
-		Header: make(http.Header),
-		URL: &url.URL{
-			Scheme:   "https",
-			Host:     host,
-			Path:     path,
-			RawQuery: params.Encode(),
-		},
-	})
+	return c.do(req.WithContext(ctx))
 }
 
 func (c *client) post(path string, params URLParam, r io.Reader) ([]byte, error) {
@@ -229,7 +227,7 @@ func (c *client) post(path string, params URLParam, r io.Reader) ([]byte, error)
 	if !ok && r != nil {
 		rc = ioutil.NopCloser(r)
 	}
-	return c.do(&http.Request{
+	req := &http.Request{
 		Method: "POST",
 		Host:   c.host,
 		Proto:  "HTTP/1.1",
@@ -241,7 +239,15 @@ func (c *client) post(path string, params URLParam, r io.Reader) ([]byte, error)
 			Path:     path,
 			RawQuery: params.Encode(),
 		},
-	})
+	}
+	// the body needs to be copied so the request can be retried
+	if buf, ok := r.(*bytes.Buffer); ok && buf != nil {
+		body := buf.Bytes()
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	return c.do(req)
 }
 
 func unmarshalToken(r io.ReadCloser, t *auth.Token) error {
@@ -5,11 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -162,6 +162,7 @@ func TestNetworking_Err(t *testing.T) {
 }
 
 func TestDominosErrors(t *testing.T) {
+	defer useCassette(t, "dominos-errors.json")()
 	order := &Order{
 		LanguageCode:  "en",
 		ServiceMethod: "Delivery",
@@ -324,18 +325,9 @@ var (
 )
 
 func testingStore() *Store {
-	var (
-		service string
-		err     error
-	)
-
-	if rand.Intn(2) == 1 {
-		service = "Carryout"
-	} else {
-		service = "Delivery"
-	}
+	var err error
 	if testStore == nil {
-		testStore, err = NearestStore(testAddress(), service)
+		testStore, err = NearestStore(testAddress(), Delivery)
 		if err != nil {
 			panic(err)
 		}
@@ -354,7 +346,22 @@ func testingMenu() *Menu {
 	return testMenu
 }
 
-func storeLocatorHandlerFunc(t *testing.T) func(w http.ResponseWriter, r *http.Request) {
+// useCassette replays the dominos traffic recorded in a cassette from
+// testdata/cassettes for the rest of a test. The testing store and menu are
+// reset so that they are loaded from the cassette. Defer the function that
+// is returned.
+func useCassette(t *testing.T, name string) func() {
+	cassette := tests.UseCassette(t, filepath.Join("testdata", "cassettes", name))
+	old := SetTransport(cassette)
+	testStore, testMenu = nil, nil
+	return func() {
+		testStore, testMenu = nil, nil
+		SetTransport(old)
+		cassette.Eject()
+	}
+}
+
+func storeLocatorHandlerFunc(t testing.TB) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		q := r.URL.Query()
 		addr := testAddress()
@@ -26,6 +26,12 @@ var (
 
 	// ErrNoUserService is thrown when a user has no service method.
 	ErrNoUserService = errors.New("UserProfile has no service method (use user.SetServiceMethod)")
+
+	// ErrNoPhone is returned when an order is tracked without a phone number.
+	ErrNoPhone = errors.New("need a phone number to track an order")
+
+	// ErrNotTracked is returned when the order tracker does not have an order.
+	ErrNotTracked = errors.New("order is not in the order tracker")
 )
 
 var (
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"testing"
 
 	"github.com/harrybrwn/apizza/dawg"
+	"github.com/harrybrwn/apizza/pkg/tests"
 )
 
 var (
@@ -22,6 +25,25 @@ var (
 	}
 )
 
+// TestMain replays the dominos traffic for the examples from a cassette so
+// that they can run offline. Tests that use their own cassette will swap it
+// out while they run.
+func TestMain(m *testing.M) {
+	cassette, err := tests.NewCassette(filepath.Join("testdata", "cassettes", "examples.json"), tests.EnvMode(), nil)
+	if err != nil {
+		panic(err)
+	}
+	old := dawg.SetTransport(cassette)
+	code := m.Run()
+	dawg.SetTransport(old)
+	if cassette.Mode() == tests.Record {
+		if err = cassette.Save(); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(code)
+}
+
 func Example_getStore() {
 	// This can be anything that satisfies the dawg.Address interface.
 	var addr = dawg.StreetAddr{
@@ -276,13 +276,22 @@ type OrderProduct struct {
 
 // OrderProductFromItem will construct an order product from an Item.
 func OrderProductFromItem(itm Item) *OrderProduct {
+	var opts map[string]interface{}
+	if itmOpts := itm.Options(); itmOpts != nil {
+		// the item's options usually belong to a menu, so they are copied
+		// to keep toppings added to the product out of the menu
+		opts = make(map[string]interface{}, len(itmOpts))
+		for k, v := range itmOpts {
+			opts[k] = v
+		}
+	}
 	return &OrderProduct{
 		ItemCommon: ItemCommon{
 			Code: itm.ItemCode(),
 			Name: itm.ItemName(),
 		},
 		Qty:   1,
-		Opts:  itm.Options(),
+		Opts:  opts,
 		pType: itm.Category(),
 	}
 }
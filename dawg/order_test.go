@@ -73,6 +73,7 @@ func TestGetOrderPrice(t *testing.T) {
 
 func TestNewOrder(t *testing.T) {
 	tests.InitHelpers(t)
+	defer useCassette(t, "new-order.json")()
 	s := testingStore()
 	if _, err := s.GetProduct("S_PIZZA"); err != nil {
 		t.Error(err)
@@ -110,6 +111,7 @@ func TestNewOrder(t *testing.T) {
 
 func TestPrepareOrder(t *testing.T) {
 	tests.InitHelpers(t)
+	defer useCassette(t, "prepare-order.json")()
 	st := testingStore()
 	o := st.MakeOrder("Bob", "Smith", "bobsmith@aol.com")
 	tests.StrEq(o.FirstName, "Bob", "wrong first name")
@@ -144,6 +146,7 @@ func TestPrepareOrder(t *testing.T) {
 
 func TestOrder_Err(t *testing.T) {
 	tests.InitHelpers(t)
+	defer useCassette(t, "order-errors.json")()
 	addr := testAddress()
 	addr.Street = ""
 	store, err := NearestStore(addr, Delivery)
@@ -173,6 +176,7 @@ func TestOrder_Err(t *testing.T) {
 
 func TestRawOrder(t *testing.T) {
 	tests.InitHelpers(t)
+	defer useCassette(t, "raw-order.json")()
 	var (
 		err   error
 		o     *Order
@@ -247,6 +251,7 @@ func TestRemoveProduct(t *testing.T) {
 
 func TestOrderProduct(t *testing.T) {
 	tests.InitHelpers(t)
+	defer useCassette(t, "order-product.json")()
 	menu := testingMenu() // this will get the menu from the same store but cached
 	item := menu.FindItem("14SCEXTRAV")
 
@@ -265,6 +270,29 @@ func TestOrderProduct(t *testing.T) {
 	}
 }
 
+func TestOrderProductFromItem_CopiesOptions(t *testing.T) {
+	tests.InitHelpers(t)
+	v := &Variant{
+		ItemCommon: ItemCommon{
+			Code: "14SCREEN",
+			Tags: map[string]interface{}{"DefaultToppings": "X=1,C=1"},
+		},
+		product: &Product{ProductType: "Pizza"},
+	}
+	o := &Order{}
+	tests.Check(o.AddProductQty(v, 2))
+	tests.Check(o.Products[0].AddTopping("P", ToppingFull, "1.5"))
+	if _, ok := v.Options()["P"]; ok {
+		t.Error("adding a topping to the order should not change the item")
+	}
+	if len(o.Products[0].Opts) != 3 {
+		t.Errorf("the product should keep the default toppings: %v", o.Products[0].Opts)
+	}
+	if OrderProductFromItem(&Variant{product: &Product{}}).Opts != nil {
+		t.Error("items without options should not get an empty map")
+	}
+}
+
 func TestCard(t *testing.T) {
 	tests.InitHelpers(t)
 	c := NewCard("1234123412341234", "01/10", 111)
@@ -357,6 +385,7 @@ func TestOrderToJSON(t *testing.T) {
 }
 
 func TestOrderCalls(t *testing.T) {
+	defer useCassette(t, "order-calls.json")()
 	o := new(Order)
 	o.Init()
 	err := sendOrder("/power/validate-order", *o)
@@ -0,0 +1,149 @@
+package dawg
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// placeOrderEndpoint is the only endpoint that is never retried. Sending an
+// order twice could mean paying for two pizzas.
+const placeOrderEndpoint = "/power/place-order"
+
+// RetryPolicy decides how failed requests to dominos are retried.
+//
+// Requests are retried when the server responds with a 5xx status code or a
+// 429 (Too Many Requests), or when the request times out. The delay between
+// attempts grows exponentially from BaseDelay up to MaxDelay with some random
+// jitter added. If the server sends a Retry-After header then that delay is
+// used instead, unless it is longer than MaxDelay in which case the request
+// is not retried.
+//
+// Orders sent to the place-order endpoint are never retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times a request will be sent.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry.
+	BaseDelay time.Duration
+	// MaxDelay is the longest delay between two attempts.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy used by the package.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// SetRetryPolicy sets the retry policy used for requests to dominos. Passing
+// nil will turn off retries.
+func SetRetryPolicy(p *RetryPolicy) {
+	if p == nil {
+		orderClient.retry = nil
+		return
+	}
+	policy := *p
+	orderClient.retry = &policy
+}
+
+// canRetry reports whether a request is safe to send more than once.
+func canRetry(req *http.Request) bool {
+	if req.URL != nil && req.URL.Path == placeOrderEndpoint {
+		return false
+	}
+	// the body has to be read again for every attempt
+	return req.Body == nil || req.GetBody != nil
+}
+
+func (p *RetryPolicy) do(d doer, req *http.Request) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		resp, err := d.Do(r)
+		wait, retry := p.backoff(attempt, resp, err)
+		if !retry || req.Context().Err() != nil {
+			return readResponse(resp, err)
+		}
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// backoff returns the time to wait before sending the next attempt and
+// whether or not the request should be sent again.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	if err != nil {
+		nerr, ok := err.(net.Error)
+		return p.delay(attempt), ok && nerr.Timeout()
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return 0, false
+	}
+	if wait, ok := retryAfter(resp, time.Now()); ok {
+		return wait, wait <= p.MaxDelay
+	}
+	return p.delay(attempt), true
+}
+
+// delay is the exponential backoff for an attempt. The result will be
+// somewhere between half the full backoff and the full backoff.
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << uint(attempt-1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter parses the Retry-After header which can either be a number of
+// seconds or an http date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	h := resp.Header.Get("Retry-After")
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(h)
+	if err != nil {
+		return 0, false
+	}
+	if wait := t.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
@@ -0,0 +1,136 @@
+package dawg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+var testRetryPolicy = &RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    10 * time.Millisecond,
+}
+
+// failingHandler responds with the status code given for the first n
+// requests then responds with an ok json response.
+func failingHandler(t *testing.T, n int32, status int, count *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			b, err := ioutil.ReadAll(r.Body)
+			tests.Check(err)
+			if string(b) != `{"Order":{}}` {
+				t.Errorf("wrong request body %q", b)
+			}
+		}
+		if atomic.AddInt32(count, 1) <= n {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Status":0}`))
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	tests.InitHelpers(t)
+	c, mux, server := testServer()
+	defer server.Close()
+	cli := &client{Client: c, retry: testRetryPolicy}
+
+	var profile, price, place, missing int32
+	mux.HandleFunc("/power/store/4336/profile", failingHandler(t, 2, http.StatusServiceUnavailable, &profile))
+	mux.HandleFunc("/power/price-order", failingHandler(t, 1, http.StatusBadGateway, &price))
+	mux.HandleFunc("/power/place-order", failingHandler(t, 1, http.StatusServiceUnavailable, &place))
+	mux.HandleFunc("/power/store/0/profile", failingHandler(t, 1, http.StatusNotFound, &missing))
+
+	_, err := cli.get("/power/store/4336/profile", nil)
+	tests.Check(err)
+	if profile != 3 {
+		t.Errorf("expected 3 attempts, got %d", profile)
+	}
+
+	body := func() *bytes.Buffer { return bytes.NewBufferString(`{"Order":{}}`) }
+	_, err = cli.post("/power/price-order", nil, body())
+	tests.Check(err)
+	if price != 2 {
+		t.Errorf("expected 2 attempts, got %d", price)
+	}
+
+	_, err = cli.post(placeOrderEndpoint, nil, body())
+	tests.Exp(err, "place-order should have failed")
+	if place != 1 {
+		t.Errorf("place-order should never be retried, got %d attempts", place)
+	}
+
+	_, err = cli.get("/power/store/0/profile", nil)
+	tests.Exp(err)
+	if missing != 1 {
+		t.Errorf("client errors should not be retried, got %d attempts", missing)
+	}
+
+	atomic.StoreInt32(&profile, -10)
+	_, err = cli.get("/power/store/4336/profile", nil)
+	tests.Exp(err, "should give up after MaxAttempts")
+	if profile != -7 {
+		t.Errorf("expected 3 attempts, got %d", profile+10)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		header string
+		wait   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, true},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"tomorrow", 0, false},
+	} {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", tc.header)
+		wait, ok := retryAfter(resp, now)
+		if wait != tc.wait || ok != tc.ok {
+			t.Errorf("Retry-After %q: got (%v, %v), want (%v, %v)", tc.header, wait, ok, tc.wait, tc.ok)
+		}
+	}
+
+	p := &RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "2")
+	if wait, ok := p.backoff(1, resp, nil); !ok || wait != 2*time.Second {
+		t.Errorf("should wait for the Retry-After header, got (%v, %v)", wait, ok)
+	}
+	resp.Header.Set("Retry-After", "60")
+	if _, ok := p.backoff(1, resp, nil); ok {
+		t.Error("should not retry when Retry-After is longer than MaxDelay")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, max := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		for i := 0; i < 20; i++ {
+			d := p.delay(attempt + 1)
+			if d < max/2 || d > max {
+				t.Fatalf("attempt %d: delay %v not in [%v, %v]", attempt+1, d, max/2, max)
+			}
+		}
+	}
+}
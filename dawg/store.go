@@ -1,10 +1,12 @@
 package dawg
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -37,7 +39,8 @@ func NearestStore(addr Address, service string) (*Store, error) {
 }
 
 // GetNearbyStores is a way of getting all the nearby stores
-// except they will by full initialized.
+// except they will by full initialized. See GetNearbyStoresWith for
+// the stores returned when there is an error.
 func GetNearbyStores(addr Address, service string) ([]*Store, error) {
 	return asyncNearbyStores(orderClient, addr, service)
 }
@@ -69,7 +72,8 @@ func InitStore(id string, obj interface{}) error {
 }
 
 var orderClient = &client{
-	host: orderHost,
+	host:  orderHost,
+	retry: &DefaultRetryPolicy,
 	Client: &http.Client{
 		Timeout:       60 * time.Second,
 		CheckRedirect: noRedirects,
@@ -141,6 +145,19 @@ type StoreHours struct {
 	}
 }
 
+// Init will make sure that a store is initialized correctly. This is only
+// needed for stores that were not created by the dawg package, for example
+// a store that was decoded from a cache.
+//
+// The addr argument should be the address to deliver to not the address of the
+// store itself.
+func (s *Store) Init(service string, addr Address) {
+	s.userService, s.userAddress = service, addr
+	if s.cli == nil {
+		s.cli = orderClient
+	}
+}
+
 // Menu returns the menu for a store object
 func (s *Store) Menu() (*Menu, error) {
 	var err error
@@ -248,7 +265,7 @@ func findNearbyStores(c *client, addr Address, service string) (*StoreLocs, erro
 	}
 	// TODO: on the dominos website, the c param can sometimes be just the zip code
 	// and it still works.
-	resp, err := get(c, orderHost, "/power/store-locator", &Params{
+	b, err := c.get("/power/store-locator", &Params{
 		"s":    addr.LineOne(),
 		"c":    format("%s, %s %s", addr.City(), addr.StateCode(), addr.Zip()),
 		"type": service,
@@ -256,13 +273,12 @@ func findNearbyStores(c *client, addr Address, service string) (*StoreLocs, erro
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	result := struct {
 		*StoreLocs
 		*DominosError
 	}{nil, nil}
-	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err = json.Unmarshal(b, &result); err != nil {
 		return nil, err
 	}
 	if result.DominosError.Status != OkStatus {
@@ -271,73 +287,132 @@ func findNearbyStores(c *client, addr Address, service string) (*StoreLocs, erro
 	return result.StoreLocs, nil
 }
 
+// StoreLoadOptions controls how store profiles are loaded when getting all of
+// the stores near an address.
+type StoreLoadOptions struct {
+	// Workers is the maximum number of store profiles that will be requested
+	// at the same time.
+	Workers int
+	// Timeout is the time limit for each store profile request. There is no
+	// limit if Timeout is zero.
+	Timeout time.Duration
+}
+
+// DefaultStoreLoadOptions are the options used by GetNearbyStores.
+var DefaultStoreLoadOptions = StoreLoadOptions{
+	Workers: 4,
+	Timeout: 15 * time.Second,
+}
+
+// GetNearbyStoresWith gets all the stores near an address using the options
+// given to load the store profiles.
+//
+// Stores that fail to load are left out of the results and their errors are
+// returned as a StoreErrors, so there may be both stores and an error. The
+// stores are returned in order of distance from the address.
+func GetNearbyStoresWith(addr Address, service string, opts StoreLoadOptions) ([]*Store, error) {
+	return loadNearbyStores(orderClient, addr, service, opts)
+}
+
+// StoreError is an error for one store that could not be loaded.
+type StoreError struct {
+	ID  string
+	Err error
+}
+
+func (e *StoreError) Error() string {
+	return fmt.Sprintf("store %s: %v", e.ID, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
+
+// StoreErrors is returned when some of the nearby stores could not be loaded.
+type StoreErrors []*StoreError
+
+func (e StoreErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("could not load %d store(s):\n  %s", len(e), strings.Join(msgs, "\n  "))
+}
+
 func asyncNearbyStores(cli *client, addr Address, service string) ([]*Store, error) {
+	return loadNearbyStores(cli, addr, service, DefaultStoreLoadOptions)
+}
+
+func loadNearbyStores(cli *client, addr Address, service string, opts StoreLoadOptions) ([]*Store, error) {
 	all, err := findNearbyStores(cli, addr, service)
 	if err != nil {
 		return nil, fmt.Errorf("findNearbyStores: %v", err)
 	}
 
 	var (
-		nStores = len(all.Stores)
-		stores  = make([]*Store, nStores) // return value
-		store   *Store
-		pair    maybeStore
-		builder = storebuilder{
-			WaitGroup: sync.WaitGroup{},
-			stores:    make(chan maybeStore),
-		}
+		n       = len(all.Stores)
+		errs    = make([]error, n)
+		indexes = make(chan int)
+		wg      sync.WaitGroup
+		workers = opts.Workers
 	)
-	builder.Add(nStores)
-
-	go func() {
-		defer close(builder.stores)
-		for i, store := range all.Stores {
-			go builder.initStore(cli, store.ID, i)
-		}
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
 
-		builder.Wait()
-	}()
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			// each worker only writes to the index it was given
+			for i := range indexes {
+				errs[i] = loadStoreProfile(cli, all.Stores[i], opts.Timeout)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
 
-	for pair = range builder.stores {
-		if pair.err != nil {
-			return nil, pair.err
+	var (
+		stores = make([]*Store, 0, n) // locator results are sorted by distance
+		failed StoreErrors
+	)
+	for i, store := range all.Stores {
+		if errs[i] != nil {
+			failed = append(failed, &StoreError{ID: store.ID, Err: errs[i]})
+			continue
 		}
-		store = pair.store
 		store.userAddress = addr
 		store.userService = service
 		store.cli = cli
-
-		stores[pair.index] = store
+		stores = append(stores, store)
 	}
-
-	return stores, err
-}
-
-type storebuilder struct {
-	sync.WaitGroup
-	stores chan maybeStore
-}
-
-type maybeStore struct {
-	store *Store
-	index int
-	err   error
+	if len(failed) > 0 {
+		return stores, failed
+	}
+	return stores, nil
 }
 
-func (sb *storebuilder) initStore(cli *client, id string, index int) {
-	defer sb.Done()
-	path := fmt.Sprintf(profileEndpoint, id)
-	store := &Store{}
-
-	b, err := cli.get(path, nil)
-	if err != nil {
-		sb.stores <- maybeStore{store: nil, err: err, index: -1}
+// loadStoreProfile will load the store profile on top of the store given so
+// that fields only sent by the store-locator (distance, service status) are
+// kept.
+func loadStoreProfile(cli *client, store *Store, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
-
-	err = errpair(json.Unmarshal(b, store), dominosErr(b))
+	b, err := cli.getContext(ctx, fmt.Sprintf(profileEndpoint, store.ID), nil)
 	if err != nil {
-		sb.stores <- maybeStore{store: nil, err: err, index: -1}
+		return err
 	}
-
-	sb.stores <- maybeStore{store: store, err: nil, index: index}
+	return errpair(json.Unmarshal(b, store), dominosErr(b))
 }
@@ -1,8 +1,14 @@
 package dawg
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/harrybrwn/apizza/pkg/tests"
 )
@@ -18,6 +24,7 @@ func testAddress() *StreetAddr {
 }
 
 func TestNewStore(t *testing.T) {
+	defer useCassette(t, "new-store.json")()
 	id := "4339"
 	service := Carryout
 	s, err := NewStore(id, service, nil)
@@ -41,6 +48,7 @@ func TestNewStore(t *testing.T) {
 }
 
 func TestNearestStore_Err(t *testing.T) {
+	defer useCassette(t, "nearest-store-errors.json")()
 	_, err := NearestStore(&StreetAddr{}, Delivery)
 	if err == nil {
 		t.Error("expected error")
@@ -56,6 +64,7 @@ func TestNearestStore_Err(t *testing.T) {
 
 func TestGetAllNearbyStores(t *testing.T) {
 	tests.InitHelpers(t)
+	defer useCassette(t, "all-nearby-stores.json")()
 	addr := testAddress()
 	validation, err := findNearbyStores(orderClient, addr, "Delivery")
 	if err != nil {
@@ -89,6 +98,7 @@ func TestGetAllNearbyStores(t *testing.T) {
 
 func TestInitStore(t *testing.T) {
 	tests.InitHelpers(t)
+	defer useCassette(t, "init-store.json")()
 	m := map[string]interface{}{}
 	check := func(k string, exp interface{}) {
 		if res, ok := m[k]; !ok {
@@ -126,6 +136,7 @@ func TestInitStore(t *testing.T) {
 }
 
 func TestInitStore_Err(t *testing.T) {
+	defer useCassette(t, "init-store-errors.json")()
 	ids := []string{"", "0000", "999999999999", "-7765"}
 	for _, id := range ids {
 		s := new(Store)
@@ -137,6 +148,7 @@ func TestInitStore_Err(t *testing.T) {
 }
 
 func TestGetNearestStore(t *testing.T) {
+	defer useCassette(t, "get-nearest-store.json")()
 	a := testAddress()
 	for _, service := range []string{Delivery, Carryout} {
 		s, err := getNearestStore(orderClient, a, service)
@@ -148,3 +160,123 @@ func TestGetNearestStore(t *testing.T) {
 		}
 	}
 }
+
+// storeProfilesHandlerFunc serves a minimal profile for any store id. The
+// handle function is called before the response is written.
+func storeProfilesHandlerFunc(handle func(id string) int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/power/store/"), "/profile")
+		if status := handle(id); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"StoreID":%q,"Status":0,"IsOpen":true,"Phone":"202-000-%s"}`, id, id)
+	}
+}
+
+func TestLoadNearbyStores(t *testing.T) {
+	tests.InitHelpers(t)
+	c, mux, server := testServer()
+	defer server.Close()
+	cli := &client{Client: c}
+
+	var running, maxRunning int32
+	mux.HandleFunc("/power/store-locator", storeLocatorHandlerFunc(t))
+	mux.HandleFunc("/power/store/", storeProfilesHandlerFunc(func(id string) int {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		switch id {
+		case "4344":
+			return http.StatusInternalServerError
+		case "4330":
+			time.Sleep(300 * time.Millisecond)
+		default:
+			time.Sleep(5 * time.Millisecond)
+		}
+		return http.StatusOK
+	}))
+
+	stores, err := loadNearbyStores(cli, testAddress(), Delivery, StoreLoadOptions{
+		Workers: 3,
+		Timeout: 100 * time.Millisecond,
+	})
+	storeErrs, ok := err.(StoreErrors)
+	if !ok {
+		t.Fatalf("expected StoreErrors, got %T: %v", err, err)
+	}
+	if len(storeErrs) != 2 {
+		t.Fatalf("expected 2 failed stores, got %d", len(storeErrs))
+	}
+	tests.StrEq(storeErrs[0].ID, "4344", "wrong failed store")
+	tests.StrEq(storeErrs[1].ID, "4330", "wrong timed out store")
+	if !errors.Is(storeErrs[1], context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", storeErrs[1].Err)
+	}
+	if max := atomic.LoadInt32(&maxRunning); max > 3 {
+		t.Errorf("had %d requests at once, wanted no more than 3", max)
+	}
+
+	if len(stores) != 11 {
+		t.Fatalf("expected 11 stores, got %d", len(stores))
+	}
+	for i, s := range stores {
+		tests.StrEq(s.Phone, "202-000-"+s.ID, "profile not loaded for %s", s.ID)
+		if s.cli != cli || s.userService != Delivery || s.userAddress == nil {
+			t.Errorf("store %s was not initialized", s.ID)
+		}
+		if i > 0 && stores[i-1].MinDistance > s.MinDistance {
+			t.Error("stores should be sorted by distance")
+		}
+	}
+}
+
+func benchmarkLoadNearbyStores(b *testing.B, workers int) {
+	c, mux, server := testServer()
+	defer server.Close()
+	cli := &client{Client: c}
+	mux.HandleFunc("/power/store-locator", storeLocatorHandlerFunc(b))
+	mux.HandleFunc("/power/store/", storeProfilesHandlerFunc(func(string) int {
+		time.Sleep(time.Millisecond) // simulate some latency
+		return http.StatusOK
+	}))
+	opts := StoreLoadOptions{Workers: workers, Timeout: 5 * time.Second}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := loadNearbyStores(cli, testAddress(), Delivery, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadNearbyStores_1(b *testing.B)  { benchmarkLoadNearbyStores(b, 1) }
+func BenchmarkLoadNearbyStores_4(b *testing.B)  { benchmarkLoadNearbyStores(b, 4) }
+func BenchmarkLoadNearbyStores_16(b *testing.B) { benchmarkLoadNearbyStores(b, 16) }
+
+func TestNearbyStoresCassette(t *testing.T) {
+	tests.InitHelpers(t)
+	cassette := tests.UseCassette(t, "testdata/cassettes/nearby-stores.json")
+	defer cassette.Eject()
+	defer SetTransport(SetTransport(cassette))
+
+	stores, err := GetNearbyStores(testAddress(), Delivery)
+	tests.Check(err)
+	ids := []string{"4336", "4344", "4328", "4329", "4330", "4326", "4335", "4341", "4346", "4333", "4331", "4362", "4339"}
+	if len(stores) != len(ids) {
+		t.Fatalf("expected %d stores, got %d", len(ids), len(stores))
+	}
+	for i, s := range stores {
+		tests.StrEq(s.ID, ids[i], "wrong store order")
+		if s.MinDistance == 0 {
+			t.Errorf("store %s lost its distance", s.ID)
+		}
+		tests.StrEq(s.Phone, "202-232-8400", "store profile not loaded")
+	}
+}
@@ -0,0 +1,91 @@
+package dawg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+const (
+	trackerHost     = "tracker.dominos.com"
+	trackerEndpoint = "/tracker-presentation-service/v2/orders"
+)
+
+// OrderStatus is the status of a placed order from the dominos order tracker.
+type OrderStatus struct {
+	OrderID       string `json:"OrderID"`
+	StoreID       string `json:"StoreID"`
+	Phone         string `json:"Phone"`
+	ServiceMethod string `json:"ServiceMethod"`
+	Description   string `json:"OrderDescription"`
+
+	// Status is the step that the order is on. Dominos uses "Order Placed",
+	// "Prep", "Bake", "Quality Check", "Out the Door", and "Complete".
+	Status     string `json:"OrderStatus"`
+	DriverName string `json:"DriverName"`
+}
+
+// Done returns true if the tracker is finished with the order.
+func (s *OrderStatus) Done() bool {
+	return strings.EqualFold(s.Status, "Complete")
+}
+
+// TrackOrders gets the status of the recent orders placed with a phone
+// number from the dominos order tracker.
+func TrackOrders(phone string) ([]*OrderStatus, error) {
+	return trackOrders(&client{host: trackerHost, Client: orderClient.Client, retry: orderClient.retry}, phone)
+}
+
+func trackOrders(c *client, phone string) ([]*OrderStatus, error) {
+	phone = strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+	if phone == "" {
+		return nil, ErrNoPhone
+	}
+	req := &http.Request{
+		Method: "GET",
+		Host:   c.host,
+		Proto:  "HTTP/1.1",
+		Header: http.Header{
+			"Dpz-Language": {"en"},
+			"Dpz-Market":   {"UNITED_STATES"},
+		},
+		URL: &url.URL{
+			Scheme:   "https",
+			Host:     c.host,
+			Path:     trackerEndpoint,
+			RawQuery: Params{"phonenumber": phone}.Encode(),
+		},
+	}
+	b, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []*OrderStatus
+	return statuses, json.Unmarshal(b, &statuses)
+}
+
+// Track gets the status of an order after it has been placed. The order is
+// looked up with the phone number it was placed with and ErrNotTracked is
+// returned if the tracker does not have it yet.
+func (o *Order) Track() (*OrderStatus, error) {
+	statuses, err := TrackOrders(o.Phone)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statuses {
+		if s.StoreID != o.StoreID {
+			continue
+		}
+		if o.OrderID == "" || s.OrderID == "" || s.OrderID == o.OrderID {
+			return s, nil
+		}
+	}
+	return nil, ErrNotTracked
+}
@@ -0,0 +1,60 @@
+package dawg
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestTrackOrders(t *testing.T) {
+	tests.InitHelpers(t)
+	client, mux, server := testServer()
+	defer server.Close()
+	defer swapClientWith(client)()
+
+	mux.HandleFunc(trackerEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != trackerHost {
+			t.Errorf("wrong host: %s", r.Host)
+		}
+		if r.Header.Get("Dpz-Market") != "UNITED_STATES" {
+			t.Error("should have a market header")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("phonenumber") {
+		case "2025551234":
+			w.Write([]byte(`[
+				{"OrderID":"old","StoreID":"4336","Phone":"2025551234","OrderStatus":"Complete"},
+				{"OrderID":"abc","StoreID":"4336","Phone":"2025551234","OrderStatus":"Bake","ServiceMethod":"Delivery"}
+			]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+
+	statuses, err := TrackOrders("(202) 555-1234")
+	tests.Check(err)
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 orders, got %d", len(statuses))
+	}
+	if !statuses[0].Done() || statuses[1].Done() {
+		t.Error("only completed orders should be done")
+	}
+	_, err = TrackOrders("no digits")
+	tests.Exp(err)
+	if err != ErrNoPhone {
+		t.Errorf("expected %v, got %v", ErrNoPhone, err)
+	}
+
+	o := &Order{OrderID: "abc", StoreID: "4336", Phone: "202-555-1234"}
+	status, err := o.Track()
+	tests.Check(err)
+	tests.StrEq(status.Status, "Bake", "wrong status")
+	tests.StrEq(status.ServiceMethod, Delivery, "wrong service")
+
+	o.Phone = "2025550000"
+	_, err = o.Track()
+	if err != ErrNotTracked {
+		t.Errorf("expected %v, got %v", ErrNotTracked, err)
+	}
+}
@@ -99,7 +99,7 @@ func (u *UserProfile) NearestStore(service string) (*Store, error) {
 	// Pass the authorized user's client along to the
 	// store which will use the user's credentials
 	// on each request.
-	c := &client{host: orderHost, Client: u.cli.Client}
+	c := &client{host: orderHost, Client: u.cli.Client, retry: u.cli.retry}
 	if err = u.addressCheck(); err != nil {
 		return nil, err
 	}
@@ -21,10 +21,7 @@ func TestSignIn(t *testing.T) {
 	defer server.Close()
 	defer swapClientWith(client)()
 	addUserHandlers(t, mux)
-	username, password, ok := gettestcreds()
-	if !ok {
-		t.Skip()
-	}
+	username, password := usercreds()
 	tests.InitHelpers(t)
 
 	user, err := getTestUser(username, password) // calls SignIn if global user is nil
@@ -42,7 +39,7 @@ func TestUser_WithProxy(t *testing.T) {
 	defer server.Close()
 	defer swapClientWith(client)()
 	addUserHandlers(t, mux)
-	uname, pass, _ := gettestcreds()
+	uname, pass := usercreds()
 	user, err := SignIn(uname, pass)
 	if err != nil {
 		t.Error(err)
@@ -66,7 +63,7 @@ func TestUser(t *testing.T) {
 	mux.HandleFunc("/power/store-locator", storeLocatorHandlerFunc(t))
 	mux.HandleFunc("/power/store/", storeProfileHandlerFunc(t))
 
-	username, password, _ := gettestcreds()
+	username, password := usercreds()
 	user, err := SignIn(username, password)
 	if err != nil {
 		t.Error(err)
@@ -133,7 +130,7 @@ func TestUser_CustmerEndpoint(t *testing.T) {
 		fileHandleFunc(t, "./testdata/order-meta.json")(w, r)
 	})
 
-	username, password, _ := gettestcreds()
+	username, password := usercreds()
 	user, err := SignIn(username, password)
 	if err != nil {
 		t.Error(err)
@@ -363,12 +360,19 @@ func TestUserProfile_NewOrder(t *testing.T) {
 	}
 }
 
+// usercreds returns the test credentials or placeholder credentials when
+// they are not set. The handlers from addUserHandlers accept either one so
+// the tests that use them never need a real account.
+func usercreds() (string, string) {
+	if u, p, ok := gettestcreds(); ok {
+		return u, p
+	}
+	return "tester", "testpassword"
+}
+
 func addUserHandlers(t *testing.T, mux *http.ServeMux) {
 	t.Helper()
-	username, password, ok := gettestcreds()
-	if !ok {
-		t.Error("could not get test credentials")
-	}
+	username, password := usercreds()
 	mux.HandleFunc("/auth-proxy-service/login", func(w http.ResponseWriter, r *http.Request) {
 		var b bytes.Buffer
 		b.ReadFrom(r.Body)
@@ -402,7 +406,7 @@ func addUserHandlers(t *testing.T, mux *http.ServeMux) {
 
 var testdataMutex sync.Mutex
 
-func fileHandleFunc(t *testing.T, filename string) func(http.ResponseWriter, *http.Request) {
+func fileHandleFunc(t testing.TB, filename string) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// testdataMutex.Lock()
 		// defer testdataMutex.Unlock()
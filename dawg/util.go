@@ -74,3 +74,17 @@ func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	}
 	return rt.inner.RoundTrip(req)
 }
+
+// SetTransport changes the http.RoundTripper used to send requests to dominos
+// and returns the old one so that it can be put back later. Requests will
+// still have the package's headers set. This is mainly used for testing.
+func SetTransport(rt http.RoundTripper) http.RoundTripper {
+	if tr, ok := orderClient.Transport.(*roundTripper); ok {
+		old := tr.inner
+		tr.inner = rt
+		return old
+	}
+	old := orderClient.Transport
+	orderClient.Transport = rt
+	return old
+}
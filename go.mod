@@ -3,9 +3,11 @@ module github.com/harrybrwn/apizza
 go 1.13
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/boltdb/bolt v1.3.1
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mitchellh/mapstructure v1.3.0
+	github.com/peterh/liner v1.2.1
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/pflag v1.0.5
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0
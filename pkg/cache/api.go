@@ -63,3 +63,33 @@ type FullDB interface {
 	Exists(string) bool
 	Destroy() error
 }
+
+// Bucketer defines a database that keeps keys in separate buckets. Calling
+// WithBucket will change the bucket used by the next operation only.
+type Bucketer interface {
+	WithBucket(string) Backend
+	Buckets() ([]string, error)
+	DefaultBucket() string
+	DeleteBucket(string) error
+}
+
+// Expirer defines a database that can store values with a time to live.
+type Expirer interface {
+	PutWithTTL(string, []byte, time.Duration) error
+	GetEntry(string) (*Entry, error)
+	GetFresh(string) ([]byte, bool, error)
+	Sweep() (int, error)
+}
+
+// Backend is the full set of database interfaces that every storage backend
+// implements.
+type Backend interface {
+	FullDB
+	TimeStamper
+	Bucketer
+	Expirer
+	DeleteTimeStamp(string) error
+	UpdateTS(string, Updater) error
+	SetReadOnly(bool) error
+	ReadOnly() bool
+}
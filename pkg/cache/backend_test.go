@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestBackends(t *testing.T) {
+	backends := map[string]func() Backend{
+		"bolt": func() Backend {
+			db, err := GetDB(tests.TempFile())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return db
+		},
+		"memory": func() Backend { return NewMemoryDB("test") },
+		"dir": func() Backend {
+			db, err := OpenDirDB(filepath.Join(tests.TempDir(), "test"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return db
+		},
+	}
+	for name, newdb := range backends {
+		t.Run(name, func(t *testing.T) {
+			db := newdb()
+			defer func() {
+				if err := db.Destroy(); err != nil {
+					t.Error(err)
+				}
+			}()
+			testBackend(t, db)
+		})
+	}
+}
+
+func testBackend(t *testing.T, db Backend) {
+	tests.InitHelpers(t)
+	tests.Check(db.Put("key", []byte("value")))
+	tests.Check(db.Put("../weird key/.", []byte("")))
+	raw, err := db.Get("key")
+	tests.Check(err)
+	tests.StrEq(string(raw), "value", "got the wrong value")
+	raw, err = db.Get("missing")
+	tests.Check(err)
+	if raw != nil {
+		t.Error("missing keys should be nil")
+	}
+	if !db.Exists("../weird key/.") {
+		t.Error("empty values should exist")
+	}
+	all, err := db.Map()
+	tests.Check(err)
+	if len(all) != 2 {
+		t.Errorf("expected 2 keys, got %d", len(all))
+	}
+
+	tests.Check(db.WithBucket("other").Put("key", []byte("other value")))
+	raw, err = db.Get("key")
+	tests.Check(err)
+	tests.StrEq(string(raw), "value", "WithBucket should only change the next operation")
+	raw, err = db.WithBucket("other").Get("key")
+	tests.Check(err)
+	tests.StrEq(string(raw), "other value", "got the wrong value from the other bucket")
+	names, err := db.Buckets()
+	tests.Check(err)
+	if len(names) != 2 {
+		t.Errorf("expected 2 buckets, got %v", names)
+	}
+	tests.Check(db.DeleteBucket("other"))
+	if db.WithBucket("other").Exists("key") {
+		t.Error("bucket should have been deleted")
+	}
+
+	tests.Check(db.ResetTimeStamp("key"))
+	stamp, err := db.TimeStamp("key")
+	tests.Check(err)
+	if time.Since(stamp) > time.Minute {
+		t.Error("timestamp should be recent")
+	}
+	tests.Check(db.DeleteTimeStamp("key"))
+	if db.Exists("key_timestamp") {
+		t.Error("timestamp should have been deleted")
+	}
+
+	tests.Check(db.PutWithTTL("fresh", []byte("new"), time.Hour))
+	tests.Check(db.PutWithTTL("stale", []byte("old"), -time.Hour))
+	raw, fresh, err := db.GetFresh("fresh")
+	tests.Check(err)
+	if !fresh || string(raw) != "new" {
+		t.Error("expected a fresh value")
+	}
+	raw, err = db.Get("stale")
+	tests.Check(err)
+	tests.StrEq(string(raw), "old", "Get should strip the ttl header")
+	n, err := db.Sweep()
+	tests.Check(err)
+	if n != 1 || db.Exists("stale") || !db.Exists("key") {
+		t.Errorf("Sweep should only remove the stale entry, removed %d", n)
+	}
+
+	tests.Check(db.Delete("key"))
+	if db.Exists("key") {
+		t.Error("key should have been deleted")
+	}
+
+	if _, ok := db.(*DataBase); ok {
+		return // bolt can only change modes before it is opened
+	}
+	tests.Check(db.SetReadOnly(true))
+	if !db.ReadOnly() {
+		t.Error("database should be read-only")
+	}
+	tests.Exp(db.Put("key", nil), "should not write in read-only mode")
+	tests.Check(db.SetReadOnly(false))
+}
+
+func TestDirEscape(t *testing.T) {
+	dir := filepath.Join(tests.TempDir(), "apizza")
+	defer os.RemoveAll(filepath.Dir(dir))
+	db, err := OpenDirDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.DefaultBucket() != "apizza" {
+		t.Errorf("default bucket should be named after the directory, got %s", db.DefaultBucket())
+	}
+	if err = db.Put("../../escape", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "apizza", "%2E.%2F..%2Fescape")); err != nil {
+		t.Error("keys should be escaped:", err)
+	}
+}
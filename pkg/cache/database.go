@@ -24,31 +24,37 @@ type DataBase struct {
 }
 
 // GetDB returns an initialized DataBase. Will either create a brand new boltdb
-// or open existing one.
+// or open existing one. GetDB will wait for DefaultTimeout if another process
+// has the database open.
 func GetDB(dbfile string) (db *DataBase, err error) {
-	err = ensurePath(dbfile)
-	if err != nil {
-		return nil, err
-	}
-	boltdb, err := bolt.Open(dbfile, 0600, nil)
-	if err != nil {
+	return Open(dbfile, &Options{Timeout: DefaultTimeout})
+}
+
+// Open will open a database with options.
+func Open(dbfile string, opts *Options) (*DataBase, error) {
+	db := Lazy(dbfile, opts)
+	if err := db.open(); err != nil {
 		return nil, err
 	}
+	return db, nil
+}
 
+// Lazy returns a DataBase that is not opened until it is first used. Errors
+// from opening the database are returned by the first operation that uses
+// it. Nil options will use the DefaultTimeout.
+func Lazy(dbfile string, opts *Options) *DataBase {
+	if opts == nil {
+		opts = &Options{Timeout: DefaultTimeout}
+	}
 	name := []byte(filename(dbfile))
-	err = boltdb.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(name)
-		return err
-	})
-	db = &DataBase{
+	return &DataBase{
 		innerdb: &innerdb{
 			defaultBucket: name,
-			db:            boltdb,
 			path:          dbfile,
 			bucketHEAD:    name,
+			opts:          *opts,
 		},
 	}
-	return db, err
 }
 
 type innerdb struct {
@@ -56,6 +62,10 @@ type innerdb struct {
 	defaultBucket []byte
 	bucketHEAD    []byte
 	path          string
+
+	opts     Options
+	readOnly bool
+	openErr  error
 }
 
 // Put stores bytes the database
@@ -65,10 +75,14 @@ func (idb *innerdb) Put(key string, val []byte) error {
 	})
 }
 
-// Get will retrieve the value given a key
+// Get will retrieve the value given a key. Values stored with PutWithTTL are
+// returned without checking if they have expired.
 func (idb *innerdb) Get(key string) (raw []byte, err error) {
 	err = idb.view(func(b *bolt.Bucket) error {
 		raw = b.Get([]byte(key))
+		if isEntry(raw) {
+			raw = decodeEntry(raw).Value
+		}
 		return nil
 	})
 	return raw, err
@@ -108,6 +122,9 @@ func (idb *innerdb) Path() string {
 
 // Close will close the DataBase's inner bolt.DB
 func (idb *innerdb) Close() error {
+	if idb.db == nil {
+		return nil
+	}
 	return idb.db.Close()
 }
 
@@ -125,7 +142,8 @@ func (db *DataBase) Map() (all map[string][]byte, err error) {
 	all = map[string][]byte{}
 	return all, db.view(func(b *bolt.Bucket) error {
 		return b.ForEach(func(k, v []byte) error {
-			all[string(k)] = v
+			// the slice from bolt is only valid during the transaction
+			all[string(k)] = append([]byte{}, v...)
 			return nil
 		})
 	})
@@ -136,8 +154,11 @@ func (db *DataBase) Map() (all map[string][]byte, err error) {
 //
 // The default bucket will be reset when the database calls Put, Get, Exists,
 // Map, TimeStamp, and UpdateTS (any method that calls view or update internally).
-func (db *DataBase) WithBucket(bucket string) *DataBase {
+func (db *DataBase) WithBucket(bucket string) Backend {
 	db.bucketHEAD = []byte(bucket)
+	if db.open() != nil || db.readOnly {
+		return db
+	}
 	db.db.Update(func(tx *bolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists([]byte(db.bucketHEAD))
 		return err
@@ -145,6 +166,25 @@ func (db *DataBase) WithBucket(bucket string) *DataBase {
 	return db
 }
 
+// Buckets returns the names of all the buckets in the database.
+func (db *DataBase) Buckets() (names []string, err error) {
+	if err = db.open(); err != nil {
+		return nil, err
+	}
+	return names, db.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, string(name))
+			return nil
+		})
+	})
+}
+
+// DefaultBucket returns the name of the bucket used when no other bucket is
+// given.
+func (db *DataBase) DefaultBucket() string {
+	return string(db.defaultBucket)
+}
+
 // SetBucket will set the bucket used in all database transactions.
 func (db *DataBase) SetBucket(name string) {
 	db.defaultBucket = []byte(name)
@@ -158,20 +198,35 @@ func (db *DataBase) DeleteBucket(name string) error {
 	if name == string(db.defaultBucket) {
 		panic("cannot delete default bucket")
 	}
+	if err := db.open(); err != nil {
+		return err
+	}
 	return db.db.Update(func(tx *bolt.Tx) error {
 		return tx.DeleteBucket([]byte(name))
 	})
 }
 
 func (idb *innerdb) view(fn func(*bolt.Bucket) error) error {
+	if err := idb.open(); err != nil {
+		idb.resetHEAD()
+		return err
+	}
 	return idb.db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(idb.bucketHEAD))
 		defer idb.resetHEAD()
+		if bucket == nil {
+			// buckets are not created in read-only mode
+			return nil
+		}
 		return fn(bucket)
 	})
 }
 
 func (idb *innerdb) update(fn func(*bolt.Bucket) error) error {
+	if err := idb.open(); err != nil {
+		idb.resetHEAD()
+		return err
+	}
 	return idb.db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(idb.bucketHEAD))
 		defer idb.resetHEAD()
@@ -197,3 +252,5 @@ func ensurePath(path string) (err error) {
 	}
 	return err
 }
+
+var _ Backend = (*DataBase)(nil)
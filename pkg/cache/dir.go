@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OpenDirDB opens a database that stores every key in its own file. Buckets
+// are sub-directories of dir. The directory is created if it does not exist.
+//
+// Values are written to a temporary file and renamed into place so readers
+// never see partial values, which makes the database usable from more than one
+// machine on a shared filesystem. There is no locking between processes; the
+// last write to a key wins.
+func OpenDirDB(dir string) (Backend, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return newKVDB(&dirStore{dir: dir}, dir, filename(dir))
+}
+
+type dirStore struct {
+	dir string
+}
+
+// escape makes a key or bucket name safe to use as a file name.
+func escape(name string) string {
+	name = url.PathEscape(name)
+	if strings.HasPrefix(name, ".") {
+		// hidden files are used for temporary files and "." and ".." are
+		// not valid names
+		name = "%2E" + name[1:]
+	}
+	return name
+}
+
+func (d *dirStore) file(bucket, key string) string {
+	return filepath.Join(d.dir, escape(bucket), escape(key))
+}
+
+func (d *dirStore) get(bucket, key string) ([]byte, error) {
+	raw, err := ioutil.ReadFile(d.file(bucket, key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if raw == nil && err == nil {
+		raw = []byte{}
+	}
+	return raw, err
+}
+
+func (d *dirStore) put(bucket, key string, val []byte) error {
+	dir := filepath.Join(d.dir, escape(bucket))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(val); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), d.file(bucket, key))
+}
+
+func (d *dirStore) delete(bucket, key string) error {
+	err := os.Remove(d.file(bucket, key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (d *dirStore) forEach(bucket string, fn func(string, []byte) error) error {
+	files, err := ioutil.ReadDir(filepath.Join(d.dir, escape(bucket)))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		key, err := url.PathUnescape(f.Name())
+		if err != nil {
+			continue
+		}
+		val, err := d.get(bucket, key)
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			// removed by another process
+			continue
+		}
+		if err = fn(key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dirStore) createBucket(name string) error {
+	return os.MkdirAll(filepath.Join(d.dir, escape(name)), 0700)
+}
+
+func (d *dirStore) buckets() ([]string, error) {
+	files, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		if name, err := url.PathUnescape(f.Name()); err == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (d *dirStore) deleteBucket(name string) error {
+	return os.RemoveAll(filepath.Join(d.dir, escape(name)))
+}
+
+func (d *dirStore) close() error { return nil }
+
+func (d *dirStore) destroy() error {
+	return os.RemoveAll(d.dir)
+}
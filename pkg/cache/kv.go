@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrReadOnly is returned when writing to a database that was opened in
+// read-only mode.
+var ErrReadOnly = errors.New("database is in read-only mode")
+
+// kvStore is the storage used by backends that are not bolt. Everything else
+// (buckets used for one operation, timestamps, and entries with a time to
+// live) is handled by kvdb so that all the backends behave the same way.
+type kvStore interface {
+	// get returns nil if the bucket or the key does not exist.
+	get(bucket, key string) ([]byte, error)
+	put(bucket, key string, val []byte) error
+	delete(bucket, key string) error
+	forEach(bucket string, fn func(key string, val []byte) error) error
+
+	createBucket(name string) error
+	buckets() ([]string, error)
+	deleteBucket(name string) error
+
+	close() error
+	destroy() error
+}
+
+type kvdb struct {
+	store         kvStore
+	path          string
+	defaultBucket string
+	bucketHEAD    string
+	readOnly      bool
+}
+
+func newKVDB(store kvStore, path, defaultBucket string) (*kvdb, error) {
+	if err := store.createBucket(defaultBucket); err != nil {
+		return nil, err
+	}
+	return &kvdb{
+		store:         store,
+		path:          path,
+		defaultBucket: defaultBucket,
+		bucketHEAD:    defaultBucket,
+	}, nil
+}
+
+// bucket returns the bucket for the current operation and resets the bucket
+// back to the default.
+func (db *kvdb) bucket() string {
+	b := db.bucketHEAD
+	db.bucketHEAD = db.defaultBucket
+	return b
+}
+
+func (db *kvdb) write() (string, error) {
+	b := db.bucket()
+	if db.readOnly {
+		return b, ErrReadOnly
+	}
+	return b, nil
+}
+
+func (db *kvdb) Get(key string) ([]byte, error) {
+	raw, err := db.store.get(db.bucket(), key)
+	if isEntry(raw) {
+		raw = decodeEntry(raw).Value
+	}
+	return raw, err
+}
+
+func (db *kvdb) Put(key string, val []byte) error {
+	b, err := db.write()
+	if err != nil {
+		return err
+	}
+	return db.store.put(b, key, val)
+}
+
+func (db *kvdb) Delete(key string) error {
+	b, err := db.write()
+	if err != nil {
+		return err
+	}
+	return db.store.delete(b, key)
+}
+
+func (db *kvdb) Exists(key string) bool {
+	raw, err := db.store.get(db.bucket(), key)
+	return err == nil && raw != nil
+}
+
+func (db *kvdb) Map() (map[string][]byte, error) {
+	all := map[string][]byte{}
+	return all, db.store.forEach(db.bucket(), func(k string, v []byte) error {
+		all[k] = v
+		return nil
+	})
+}
+
+func (db *kvdb) Path() string {
+	return db.path
+}
+
+func (db *kvdb) Close() error {
+	return db.store.close()
+}
+
+func (db *kvdb) Destroy() error {
+	return db.store.destroy()
+}
+
+func (db *kvdb) TimeStamp(key string) (time.Time, error) {
+	key = ts(key)
+	stamp, err := timestampE(db, key)
+	if err == nil {
+		return stamp, nil
+	} else if isTimeStampNotFound(err) {
+		return time.Now(), db.Put(key, unixNow())
+	}
+	return time.Time{}, err
+}
+
+func (db *kvdb) ResetTimeStamp(key string) error {
+	return db.Put(ts(key), unixNow())
+}
+
+func (db *kvdb) DeleteTimeStamp(key string) error {
+	return db.Delete(ts(key))
+}
+
+func (db *kvdb) UpdateTS(key string, updater Updater) error {
+	return check(db, ts(key), updater)
+}
+
+func (db *kvdb) PutWithTTL(key string, val []byte, ttl time.Duration) error {
+	return db.Put(key, encodeEntry(val, now(), ttl))
+}
+
+func (db *kvdb) GetEntry(key string) (*Entry, error) {
+	raw, err := db.store.get(db.bucket(), key)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	return decodeEntry(raw), nil
+}
+
+func (db *kvdb) GetFresh(key string) ([]byte, bool, error) {
+	e, err := db.GetEntry(key)
+	if err != nil || e == nil {
+		return nil, false, err
+	}
+	return e.Value, e.Fresh(), nil
+}
+
+func (db *kvdb) Sweep() (n int, err error) {
+	b, err := db.write()
+	if err != nil {
+		return 0, err
+	}
+	var expired []string
+	err = db.store.forEach(b, func(k string, v []byte) error {
+		if isEntry(v) && !decodeEntry(v).Fresh() {
+			expired = append(expired, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, k := range expired {
+		if err = db.store.delete(b, k); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (db *kvdb) WithBucket(bucket string) Backend {
+	db.bucketHEAD = bucket
+	if !db.readOnly {
+		db.store.createBucket(bucket)
+	}
+	return db
+}
+
+func (db *kvdb) Buckets() ([]string, error) {
+	return db.store.buckets()
+}
+
+func (db *kvdb) DefaultBucket() string {
+	return db.defaultBucket
+}
+
+// DeleteBucket will delete the bucket given.
+//
+// Will panic if the name argument is the same as the database's default bucket.
+func (db *kvdb) DeleteBucket(name string) error {
+	if name == db.defaultBucket {
+		panic("cannot delete default bucket")
+	}
+	if db.readOnly {
+		return ErrReadOnly
+	}
+	return db.store.deleteBucket(name)
+}
+
+func (db *kvdb) SetReadOnly(readOnly bool) error {
+	db.readOnly = readOnly
+	return nil
+}
+
+func (db *kvdb) ReadOnly() bool {
+	return db.readOnly
+}
+
+var _ Backend = (*kvdb)(nil)
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+)
+
+// NewMemoryDB creates a database that is only stored in memory. Everything
+// in the database is lost when the program exits. The name is used as the
+// database's default bucket.
+func NewMemoryDB(name string) Backend {
+	db, _ := newKVDB(&memoryStore{data: map[string]map[string][]byte{}}, "", name)
+	return db
+}
+
+type memoryStore struct {
+	mu   sync.RWMutex
+	data map[string]map[string][]byte
+}
+
+func (m *memoryStore) get(bucket, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.data[bucket][key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, v...), nil
+}
+
+func (m *memoryStore) put(bucket, key string, val []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.data[bucket]
+	if !ok {
+		b = map[string][]byte{}
+		m.data[bucket] = b
+	}
+	b[key] = append([]byte{}, val...)
+	return nil
+}
+
+func (m *memoryStore) delete(bucket, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data[bucket], key)
+	return nil
+}
+
+func (m *memoryStore) forEach(bucket string, fn func(string, []byte) error) error {
+	m.mu.RLock()
+	keys := make([]string, 0, len(m.data[bucket]))
+	for k := range m.data[bucket] {
+		keys = append(keys, k)
+	}
+	m.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v, err := m.get(bucket, k)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			continue
+		}
+		if err = fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) createBucket(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.data[name]; !ok {
+		m.data[name] = map[string][]byte{}
+	}
+	return nil
+}
+
+func (m *memoryStore) buckets() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.data))
+	for name := range m.data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *memoryStore) deleteBucket(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, name)
+	return nil
+}
+
+func (m *memoryStore) close() error { return nil }
+
+func (m *memoryStore) destroy() error {
+	m.mu.Lock()
+	m.data = map[string]map[string][]byte{}
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// DefaultTimeout is how long GetDB will wait for another process to close
+// the database.
+var DefaultTimeout = 3 * time.Second
+
+// ErrLocked is returned when the database could not be opened because
+// another process is using it.
+var ErrLocked = errors.New("database is being used by another process")
+
+// Options are options for opening a database.
+type Options struct {
+	// Timeout is how long to wait for another process to close the database.
+	// A timeout of zero will wait forever.
+	Timeout time.Duration
+
+	// ReadOnly opens the database so that other read-only processes can use
+	// it at the same time. Writes will fail in read-only mode. If the database
+	// file does not exist yet it is created and opened normally.
+	ReadOnly bool
+
+	// OnOpen is called after the database has been opened.
+	OnOpen func(*DataBase) error
+}
+
+// SetReadOnly will change whether the database is opened in read-only mode.
+// It can only be called before a lazy database is used.
+func (db *DataBase) SetReadOnly(readOnly bool) error {
+	if db.db != nil {
+		return errors.New("cannot change the mode of an open database")
+	}
+	db.opts.ReadOnly = readOnly
+	return nil
+}
+
+// ReadOnly returns true if the database was opened in read-only mode.
+func (db *DataBase) ReadOnly() bool {
+	return db.readOnly
+}
+
+// Release closes the database so that other processes can open it. Unlike
+// Close, the database will be opened again the next time it is used, and
+// OnOpen is called again when it is.
+func (db *DataBase) Release() error {
+	if db.db == nil {
+		return nil
+	}
+	err := db.db.Close()
+	db.db, db.openErr = nil, nil
+	return err
+}
+
+// open will open the bolt database the first time it is called.
+func (idb *innerdb) open() error {
+	if idb.db != nil || idb.openErr != nil {
+		return idb.openErr
+	}
+	if idb.openErr = idb.openBolt(); idb.openErr != nil {
+		return idb.openErr
+	}
+	if idb.opts.OnOpen != nil {
+		// the hook should not see (or reset) the bucket that the caller
+		// selected before the database was opened
+		head := idb.bucketHEAD
+		idb.bucketHEAD = idb.defaultBucket
+		err := idb.opts.OnOpen(&DataBase{innerdb: idb})
+		idb.bucketHEAD = head
+		if err != nil {
+			idb.db.Close()
+			idb.db, idb.openErr = nil, err
+		}
+	}
+	return idb.openErr
+}
+
+func (idb *innerdb) openBolt() error {
+	readOnly := idb.opts.ReadOnly
+	if _, err := os.Stat(idb.path); os.IsNotExist(err) {
+		readOnly = false
+	}
+	if !readOnly {
+		if err := ensurePath(idb.path); err != nil {
+			return err
+		}
+	}
+	boltdb, err := bolt.Open(idb.path, 0600, &bolt.Options{
+		Timeout:  idb.opts.Timeout,
+		ReadOnly: readOnly,
+	})
+	if err == bolt.ErrTimeout {
+		return fmt.Errorf("could not open %s after %s: %w", idb.path, idb.opts.Timeout, ErrLocked)
+	} else if err != nil {
+		return err
+	}
+	if !readOnly {
+		err = boltdb.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(idb.defaultBucket)
+			return err
+		})
+		if err != nil {
+			boltdb.Close()
+			return err
+		}
+	}
+	idb.db, idb.readOnly = boltdb, readOnly
+	return nil
+}
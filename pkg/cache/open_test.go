@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestOpen(t *testing.T) {
+	tests.InitHelpers(t)
+	file := tests.TempFile()
+	db := Lazy(file, &Options{Timeout: 50 * time.Millisecond})
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("lazy database should not be opened until it is used")
+	}
+	tests.Check(db.Put("key", []byte("value")))
+	if _, err := os.Stat(file); err != nil {
+		t.Error("database should have been opened by Put:", err)
+	}
+
+	_, err := Open(file, &Options{Timeout: 50 * time.Millisecond, ReadOnly: true})
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("expected a locked database error, got %v", err)
+	}
+	tests.Check(db.Close())
+
+	ro1, err := Open(file, &Options{Timeout: 50 * time.Millisecond, ReadOnly: true})
+	tests.Fatal(err)
+	ro2, err := Open(file, &Options{Timeout: 50 * time.Millisecond, ReadOnly: true})
+	tests.Fatal(err)
+	if !ro1.ReadOnly() || !ro2.ReadOnly() {
+		t.Error("databases should be read-only")
+	}
+	raw, err := ro2.Get("key")
+	tests.Check(err)
+	tests.StrEq(string(raw), "value", "wrong value from read-only database")
+	raw, err = ro2.WithBucket("missing").Get("key")
+	tests.Check(err)
+	if raw != nil {
+		t.Error("missing buckets should not have any values")
+	}
+	tests.Exp(ro1.Put("key", []byte("x")), "should not be able to write in read-only mode")
+	tests.Check(ro1.Close())
+	tests.Check(ro2.Close())
+
+	opened := 0
+	db = Lazy(file, &Options{OnOpen: func(db *DataBase) error {
+		opened++
+		return db.Put("opened", []byte("yes"))
+	}})
+	tests.Check(db.SetReadOnly(false))
+	tests.Check(db.Put("key", []byte("new value")))
+	tests.Check(db.Put("key", []byte("new value")))
+	if opened != 1 {
+		t.Errorf("OnOpen should be called once, got %d", opened)
+	}
+	tests.Exp(db.SetReadOnly(true), "should not change the mode of an open database")
+	tests.Check(db.Destroy())
+
+	db = Lazy(file, &Options{Timeout: 50 * time.Millisecond})
+	tests.Check(db.Put("key", []byte("value")))
+	tests.Check(db.Release())
+	other, err := Open(file, &Options{Timeout: 50 * time.Millisecond})
+	tests.Fatal(err)
+	tests.Check(other.Put("key", []byte("changed")))
+	tests.Check(other.Close())
+	raw, err = db.Get("key")
+	tests.Check(err)
+	tests.StrEq(string(raw), "changed", "a released database should be opened again")
+	tests.Check(db.Release())
+	tests.Check(db.Release())
+	tests.Check(db.Destroy())
+
+	db = Lazy(file, &Options{OnOpen: func(*DataBase) error { return errors.New("failed") }})
+	tests.Exp(db.Put("key", nil))
+	tests.Check(db.Close())
+	tests.Check(os.Remove(file))
+}
+
+func TestOpen_BucketBeforeOpen(t *testing.T) {
+	tests.InitHelpers(t)
+	file := tests.TempFile()
+	db := Lazy(file, &Options{OnOpen: func(db *DataBase) error {
+		raw, err := db.Get("version")
+		if err != nil {
+			return err
+		}
+		if raw == nil {
+			return db.Put("version", []byte("1"))
+		}
+		return nil
+	}})
+	tests.Check(db.WithBucket("pinned").Put("k", []byte("v")))
+
+	raw, err := db.WithBucket("pinned").Get("k")
+	tests.Check(err)
+	tests.StrEq(string(raw), "v", "the first write should go to the selected bucket")
+	raw, err = db.Get("k")
+	tests.Check(err)
+	if raw != nil {
+		t.Error("the first write should not go to the default bucket")
+	}
+	raw, err = db.Get("version")
+	tests.Check(err)
+	tests.StrEq(string(raw), "1", "OnOpen should use the default bucket")
+	raw, err = db.WithBucket("pinned").Get("version")
+	tests.Check(err)
+	if raw != nil {
+		t.Error("OnOpen should not use the selected bucket")
+	}
+
+	tests.Check(db.Release())
+	raw, err = db.WithBucket("pinned").Get("k")
+	tests.Check(err)
+	tests.StrEq(string(raw), "v", "the first read should use the selected bucket")
+	tests.Check(db.Destroy())
+}
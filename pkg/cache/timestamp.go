@@ -34,6 +34,11 @@ func (db *DataBase) ResetTimeStamp(key string) error {
 	return db.Put(ts(key), unixNow())
 }
 
+// DeleteTimeStamp removes the timestamp for the given key.
+func (db *DataBase) DeleteTimeStamp(key string) error {
+	return db.Delete(ts(key))
+}
+
 // UpdateTS or "UpdateTimeStamp" will execute an Updater's methods in correspondence with the
 // database's timestamp at the key given
 func (db *DataBase) UpdateTS(key string, updater Updater) error {
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// ttlMagic marks the start of a value that was stored with a time to live.
+// The magic bytes are followed by the time the value was stored, the time it
+// expires (both unix nanoseconds), and then the value itself.
+var ttlMagic = []byte("\x00apizza-ttl\x00")
+
+const ttlHeaderLen = 12 + 8 + 8 // len(ttlMagic) + stored + expires
+
+// now is used to get the current time so it can be changed in tests.
+var now = time.Now
+
+// Entry is a value that was stored with a time to live.
+type Entry struct {
+	Value   []byte
+	Stored  time.Time
+	Expires time.Time
+}
+
+// Fresh returns true if the entry has not expired. Entries that were not
+// stored with a time to live are never fresh.
+func (e *Entry) Fresh() bool {
+	if e == nil || e.Expires.IsZero() {
+		return false
+	}
+	return now().Before(e.Expires)
+}
+
+// PutWithTTL stores a value that will expire once the ttl has passed. Expired
+// values are not deleted until Sweep is called.
+func (db *DataBase) PutWithTTL(key string, val []byte, ttl time.Duration) error {
+	return db.Put(key, encodeEntry(val, now(), ttl))
+}
+
+// GetEntry gets a value along with the time it was stored and when it
+// expires. The entry will be nil if there is no value for the key.
+func (db *DataBase) GetEntry(key string) (e *Entry, err error) {
+	err = db.view(func(b *bolt.Bucket) error {
+		raw := b.Get([]byte(key))
+		if raw == nil {
+			return nil
+		}
+		e = decodeEntry(raw)
+		// the slice from bolt is only valid during the transaction
+		e.Value = append([]byte{}, e.Value...)
+		return nil
+	})
+	return e, err
+}
+
+// GetFresh gets a value and whether or not it is still fresh. Stale values are
+// still returned so they can be used when a new value cannot be found.
+func (db *DataBase) GetFresh(key string) ([]byte, bool, error) {
+	e, err := db.GetEntry(key)
+	if err != nil || e == nil {
+		return nil, false, err
+	}
+	return e.Value, e.Fresh(), nil
+}
+
+// Sweep deletes all the expired entries in the current bucket and returns the
+// number of entries deleted. Values stored without a time to live are never
+// deleted.
+func (db *DataBase) Sweep() (n int, err error) {
+	return n, db.update(func(b *bolt.Bucket) error {
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			if !isEntry(v) {
+				return nil
+			}
+			if e := decodeEntry(v); !e.Fresh() {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err = b.Delete(k); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+}
+
+func isEntry(raw []byte) bool {
+	return len(raw) >= ttlHeaderLen && bytes.HasPrefix(raw, ttlMagic)
+}
+
+func encodeEntry(val []byte, stored time.Time, ttl time.Duration) []byte {
+	buf := make([]byte, ttlHeaderLen, ttlHeaderLen+len(val))
+	copy(buf, ttlMagic)
+	binary.BigEndian.PutUint64(buf[len(ttlMagic):], uint64(stored.UnixNano()))
+	binary.BigEndian.PutUint64(buf[len(ttlMagic)+8:], uint64(stored.Add(ttl).UnixNano()))
+	return append(buf, val...)
+}
+
+// decodeEntry will decode a raw value. Values that were not stored with a
+// time to live are returned as entries that are never fresh.
+func decodeEntry(raw []byte) *Entry {
+	if !isEntry(raw) {
+		return &Entry{Value: raw}
+	}
+	stored := binary.BigEndian.Uint64(raw[len(ttlMagic):])
+	expires := binary.BigEndian.Uint64(raw[len(ttlMagic)+8:])
+	return &Entry{
+		Value:   raw[ttlHeaderLen:],
+		Stored:  time.Unix(0, int64(stored)),
+		Expires: time.Unix(0, int64(expires)),
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestTTL(t *testing.T) {
+	tests.InitHelpers(t)
+	db, err := GetDB(tests.TempFile())
+	tests.Fatal(err)
+	defer func() { tests.Check(db.Destroy()) }()
+	start := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start }
+	defer func() { now = time.Now }()
+
+	tests.Check(db.PutWithTTL("short", []byte("one"), time.Minute))
+	tests.Check(db.PutWithTTL("long", []byte("two"), time.Hour))
+	tests.Check(db.WithBucket("other").PutWithTTL("short", []byte("three"), time.Minute))
+	tests.Check(db.Put("plain", []byte("four")))
+
+	val, fresh, err := db.GetFresh("short")
+	tests.Check(err)
+	tests.StrEq(string(val), "one", "wrong value")
+	if !fresh {
+		t.Error("value should be fresh")
+	}
+	raw, err := db.Get("short")
+	tests.Check(err)
+	tests.StrEq(string(raw), "one", "Get should not return the ttl header")
+	e, err := db.GetEntry("long")
+	tests.Check(err)
+	if !e.Stored.Equal(start) || !e.Expires.Equal(start.Add(time.Hour)) {
+		t.Errorf("wrong entry times: %v %v", e.Stored, e.Expires)
+	}
+
+	now = func() time.Time { return start.Add(30 * time.Minute) }
+	val, fresh, err = db.GetFresh("short")
+	tests.Check(err)
+	if fresh || string(val) != "one" {
+		t.Error("stale values should still be returned but not be fresh")
+	}
+	if _, fresh, _ = db.GetFresh("plain"); fresh {
+		t.Error("values without a ttl should never be fresh")
+	}
+	if val, fresh, err = db.GetFresh("missing"); val != nil || fresh || err != nil {
+		t.Error("missing values should be nil")
+	}
+
+	n, err := db.Sweep()
+	tests.Check(err)
+	if n != 1 {
+		t.Errorf("expected to sweep 1 entry, got %d", n)
+	}
+	if db.Exists("short") {
+		t.Error("expired entry should have been swept")
+	}
+	for _, key := range []string{"long", "plain"} {
+		if !db.Exists(key) {
+			t.Errorf("%s should not have been swept", key)
+		}
+	}
+	if !db.WithBucket("other").Exists("short") {
+		t.Error("should only sweep the current bucket")
+	}
+	n, err = db.WithBucket("other").Sweep()
+	tests.Check(err)
+	if n != 1 {
+		t.Errorf("expected to sweep 1 entry, got %d", n)
+	}
+}
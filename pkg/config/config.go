@@ -35,6 +35,8 @@ const (
 	YamlType Type = iota
 	// JSONType is the config filetype for json
 	JSONType
+	// TOMLType is the config filetype for toml
+	TOMLType
 )
 
 // SetConfig sets the config file and also runs through the configuration
@@ -76,6 +78,11 @@ type configfile struct {
 	dir     string
 	changed bool
 	typ     Type
+
+	files   []string               // every config file that was loaded
+	origins map[string]string      // where each value was loaded from
+	user    interface{}            // the values from the user's config file
+	loaded  map[string]interface{} // the values after loading every layer
 }
 
 func (c *configfile) save() error {
@@ -89,12 +96,25 @@ func (c *configfile) save() error {
 		raw []byte
 		err error
 	)
-	if c.typ == JSONType {
-		raw, err = json.MarshalIndent(c.conf, "", "    ")
-	} else {
-		raw, err = yaml.Marshal(c.conf)
+	conf := c.userConfig()
+	switch c.typ {
+	case JSONType:
+		raw, err = json.MarshalIndent(conf, "", "    ")
+	case TOMLType:
+		raw, err = encodeTOML(conf)
+	default:
+		raw, err = yaml.Marshal(conf)
+	}
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(c.file, raw, 0644); err != nil {
+		return err
+	}
+	if c.user != nil {
+		c.user = conf
 	}
-	return errs.Pair(err, ioutil.WriteFile(c.file, raw, 0644))
+	return nil
 }
 
 func (c *configfile) reset() error {
@@ -107,15 +127,7 @@ func (c *configfile) setup() error {
 }
 
 func (c *configfile) init() error {
-	b, err := ioutil.ReadFile(c.file)
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(b, c.conf)
-	if err != nil {
-		return yaml.Unmarshal(b, c.conf)
-	}
-	return err
+	return c.loadLayers()
 }
 
 func (c *configfile) exists() bool {
@@ -187,7 +199,15 @@ func setup(fname string, obj interface{}) error {
 	if err != nil {
 		return err
 	}
-	raw, err := yaml.Marshal(obj)
+	var raw []byte
+	if isTOML(fname) {
+		// new toml files are generated with the values from default tags
+		if obj, err = withDefaults(obj); err == nil {
+			raw, err = encodeTOML(obj)
+		}
+	} else {
+		raw, err = yaml.Marshal(obj)
+	}
 	if err != nil {
 		return err
 	}
@@ -262,7 +282,8 @@ func getdir(fname string) string {
 var configFileNames = []string{
 	"config.json",
 	"config.yaml",
-	"config.yml",
+	"config.toml",
+	"config.yml", // the last name is used when there is no config file
 }
 
 func findConfigFile(root string) string {
@@ -282,6 +303,8 @@ func getFileType(file string) Type {
 		return JSONType
 	case ".yml", ".yaml":
 		return YamlType
+	case ".toml":
+		return TOMLType
 	default:
 		fmt.Fprintln(os.Stderr, "config filetype not supported")
 		return -1
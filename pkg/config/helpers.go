@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -168,8 +169,11 @@ func visitAll(val reflect.Value, depth int, fmtr Formatter) string {
 		}
 
 		fieldVal := val.Field(i)
+		indent := strings.Repeat(" ", depth*fmtr.TabSize)
 		if fieldVal.Kind() == reflect.Struct {
-			display += fmtr.StructFormat(name, visitAll(fieldVal, depth+1, fmtr))
+			display += indent + fmtr.StructFormat(name, visitAll(fieldVal, depth+1, fmtr))
+		} else if fieldVal.Kind() == reflect.Map {
+			display += indent + fmtr.StructFormat(name, visitMap(fieldVal, depth+1, fmtr))
 		} else if fieldVal.Kind() == reflect.Interface && fieldVal.IsNil() {
 			display += fmtr.KeyValFormat(name, "null")
 		} else {
@@ -180,6 +184,27 @@ func visitAll(val reflect.Value, depth int, fmtr Formatter) string {
 	return display
 }
 
+// visitMap formats a map with string keys in the same way as a struct.
+func visitMap(val reflect.Value, depth int, fmtr Formatter) string {
+	keys := val.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	indent := strings.Repeat(" ", depth*fmtr.TabSize)
+
+	var display string
+	for _, k := range keys {
+		v := val.MapIndex(k)
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() == reflect.Struct {
+			display += indent + fmtr.StructFormat(k.String(), visitAll(v, depth+1, fmtr))
+		} else {
+			display += indent + fmtr.KeyValFormat(k.String(), fmt.Sprintf("%v", v))
+		}
+	}
+	return display
+}
+
 // DefaultFormatter is the default formatter object.
 var DefaultFormatter = Formatter{
 	KeyValFormat: func(k, v string) string { return fmt.Sprintf("%s: %s\n", k, v) },
@@ -0,0 +1,374 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// EnvPrefix is the prefix for environment variables that override config
+	// values. The variable for a key is the prefix followed by the key in upper
+	// case with '.' and '-' replaced by '_' (address.street is
+	// PREFIX_ADDRESS_STREET). Environment overrides are not used if the prefix
+	// is empty.
+	EnvPrefix = ""
+
+	// SystemFile is a config file that is loaded before the user's config
+	// file. It is not used if it is empty or does not exist.
+	SystemFile = ""
+
+	// ProjectFileName is the name of a config file that is looked for in the
+	// current directory and its parents. It is loaded after the user's config
+	// file. Project files are not used if the name is empty.
+	ProjectFileName = ""
+)
+
+// DefaultOrigin is the origin of values that were not set by any config
+// file or environment variable.
+const DefaultOrigin = "default"
+
+// KeyOrigin describes where a config value came from.
+type KeyOrigin struct {
+	Key    string
+	Value  interface{}
+	Origin string
+}
+
+// Origin returns where the value for a key came from. Values from files are
+// "file:<path>" and values from the environment are "env:<variable>".
+func Origin(key string) string {
+	if o, ok := cfg.origins[canonicalKey(key)]; ok {
+		return o
+	}
+	return DefaultOrigin
+}
+
+// Origins returns the origin of every value in the config sorted by key.
+func Origins() []KeyOrigin {
+	if cfg.conf == nil {
+		return nil
+	}
+	fields := leafFields(reflect.ValueOf(cfg.conf).Elem(), "")
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	origins := make([]KeyOrigin, len(keys))
+	for i, k := range keys {
+		origins[i] = KeyOrigin{Key: k, Value: fields[k].Interface(), Origin: Origin(k)}
+	}
+	return origins
+}
+
+// Override sets a config value that is not saved to the user's config file
+// unless it is changed again. The origin describes where the value came from
+// and is returned by Origin.
+func Override(key, val, origin string) error {
+	key = canonicalKey(key)
+	field, ok := leafFields(reflect.ValueOf(cfg.conf).Elem(), "")[key]
+	if !ok {
+		return fmt.Errorf("cannot find '%s'", key)
+	}
+	if err := setString(field, val); err != nil {
+		return err
+	}
+	if cfg.origins == nil {
+		cfg.origins = map[string]string{}
+	}
+	if cfg.loaded == nil {
+		cfg.loaded = map[string]interface{}{}
+	}
+	cfg.origins[key] = origin
+	cfg.loaded[key] = field.Interface()
+	return nil
+}
+
+// loadLayers will load the system file, the user file, the project file,
+// and then the environment, each one overriding the last.
+func (c *configfile) loadLayers() error {
+	c.origins = map[string]string{}
+	c.files = nil
+	if SystemFile != "" {
+		if err := c.loadFile(SystemFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := c.loadFile(c.file); err != nil {
+		return err
+	}
+	// keep the values from the user's file so that saving will not write
+	// values from the other layers to it
+	c.user = reflect.New(reflect.TypeOf(c.conf).Elem()).Interface()
+	if raw, err := ioutil.ReadFile(c.file); err == nil {
+		unmarshal(c.file, raw, c.user)
+	}
+
+	if ProjectFileName != "" {
+		if file := findProjectFile(ProjectFileName); file != "" {
+			if err := c.loadFile(file); err != nil {
+				return err
+			}
+		}
+	}
+	if EnvPrefix != "" {
+		if err := c.loadEnv(EnvPrefix); err != nil {
+			return err
+		}
+	}
+	c.loaded = snapshot(c.conf)
+	return nil
+}
+
+func (c *configfile) loadFile(file string) error {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err = unmarshal(file, raw, c.conf); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	c.files = append(c.files, file)
+	m, err := rawMap(file, raw)
+	if err != nil {
+		return nil
+	}
+	typ := reflect.TypeOf(c.conf).Elem()
+	for _, path := range mapKeys(m, nil) {
+		if key, ok := resolveKey(typ, path); ok {
+			c.origins[key] = "file:" + file
+		}
+	}
+	return nil
+}
+
+func (c *configfile) loadEnv(prefix string) error {
+	fields := leafFields(reflect.ValueOf(c.conf).Elem(), "")
+	for key, field := range fields {
+		name := EnvName(prefix, key)
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setString(field, val); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		c.origins[key] = "env:" + name
+	}
+	return nil
+}
+
+// userConfig returns a copy of the config that should be written to the
+// user's file. Values that came from other layers and have not been changed
+// are replaced with the values from the user's file.
+func (c *configfile) userConfig() interface{} {
+	if c.user == nil {
+		return c.conf
+	}
+	out := reflect.New(reflect.TypeOf(c.conf).Elem())
+	out.Elem().Set(reflect.ValueOf(c.conf).Elem())
+	current := leafFields(out.Elem(), "")
+	user := leafFields(reflect.ValueOf(c.user).Elem(), "")
+	for key, field := range current {
+		origin, ok := c.origins[key]
+		if !ok || origin == "file:"+c.file {
+			continue
+		}
+		if reflect.DeepEqual(field.Interface(), c.loaded[key]) {
+			field.Set(user[key])
+		}
+	}
+	return out.Interface()
+}
+
+// EnvName returns the environment variable used to override a config key.
+func EnvName(prefix, key string) string {
+	r := strings.NewReplacer(".", "_", "-", "_")
+	return strings.ToUpper(prefix + "_" + r.Replace(key))
+}
+
+func unmarshal(file string, raw []byte, v interface{}) error {
+	if isTOML(file) {
+		return decodeTOML(raw, v)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return yaml.Unmarshal(raw, v)
+	}
+	return nil
+}
+
+// rawMap decodes a config file without using the config struct.
+func rawMap(file string, raw []byte) (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if isTOML(file) {
+		_, err := toml.Decode(string(raw), &m)
+		return m, err
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		if err = yaml.Unmarshal(raw, &m); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func isTOML(file string) bool {
+	return filepath.Ext(file) == ".toml"
+}
+
+func findProjectFile(name string) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// leafFields returns all the fields that are not structs keyed by their
+// config key.
+func leafFields(val reflect.Value, prefix string) map[string]reflect.Value {
+	fields := map[string]reflect.Value{}
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		key := prefix + fieldKey(f)
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			for k, v := range leafFields(val.Field(i), key+".") {
+				fields[k] = v
+			}
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int64,
+			reflect.Float32, reflect.Float64:
+			fields[key] = val.Field(i)
+		}
+	}
+	return fields
+}
+
+func snapshot(conf interface{}) map[string]interface{} {
+	values := map[string]interface{}{}
+	for k, v := range leafFields(reflect.ValueOf(conf).Elem(), "") {
+		values[k] = v.Interface()
+	}
+	return values
+}
+
+// fieldKey is the name of a field used in config keys.
+func fieldKey(f reflect.StructField) string {
+	if tag, ok := f.Tag.Lookup("config"); ok {
+		return strings.Split(tag, ",")[0]
+	}
+	return f.Name
+}
+
+// canonicalKey will convert a key that uses field names to one that uses
+// config tags.
+func canonicalKey(key string) string {
+	if cfg.conf == nil {
+		return key
+	}
+	if k, ok := resolveKey(reflect.TypeOf(cfg.conf).Elem(), strings.Split(key, ".")); ok {
+		return k
+	}
+	return key
+}
+
+// resolveKey finds the config key for a path of names from a config file. The
+// names can be field names or config, json, or yaml tags in any case.
+func resolveKey(typ reflect.Type, path []string) (string, bool) {
+	if len(path) == 0 || typ.Kind() != reflect.Struct {
+		return "", false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !matchesField(f, path[0]) {
+			continue
+		}
+		if len(path) == 1 {
+			return fieldKey(f), f.Type.Kind() != reflect.Struct
+		}
+		rest, ok := resolveKey(f.Type, path[1:])
+		return fieldKey(f) + "." + rest, ok
+	}
+	return "", false
+}
+
+func matchesField(f reflect.StructField, name string) bool {
+	names := []string{f.Name, fieldKey(f)}
+	for _, tag := range []string{"json", "yaml"} {
+		if t, ok := f.Tag.Lookup(tag); ok {
+			names = append(names, strings.Split(t, ",")[0])
+		}
+	}
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapKeys returns the path to every value in a map that is not a map.
+func mapKeys(m map[string]interface{}, prefix []string) [][]string {
+	var keys [][]string
+	for k, v := range m {
+		path := append(append([]string{}, prefix...), k)
+		if inner, ok := v.(map[string]interface{}); ok {
+			keys = append(keys, mapKeys(inner, path)...)
+		} else {
+			keys = append(keys, path)
+		}
+	}
+	return keys
+}
+
+func setString(field reflect.Value, val string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return errWrongType
+	}
+	return nil
+}
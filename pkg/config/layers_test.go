@@ -0,0 +1,87 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+func TestLayers(t *testing.T) {
+	tests.InitHelpers(t)
+	dir := tests.TempDir()
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	tests.Fatal(err)
+	defer os.Chdir(wd)
+	defer func() { EnvPrefix, SystemFile, ProjectFileName = "", "", "" }()
+
+	SystemFile = filepath.Join(dir, "system.yml")
+	tests.Check(ioutil.WriteFile(SystemFile, []byte("test: system\nmsg: system msg\n"), 0644))
+	ProjectFileName = ".project.yml"
+	project := filepath.Join(dir, ProjectFileName)
+	tests.Check(ioutil.WriteFile(project, []byte("more:\n  one: project\n"), 0644))
+	sub := filepath.Join(dir, "sub")
+	tests.Check(os.Mkdir(sub, 0755))
+	tests.Check(os.Chdir(sub))
+	EnvPrefix = "APIZZA_TEST"
+	tests.StrEq(EnvName(EnvPrefix, "more.two"), "APIZZA_TEST_MORE_TWO", "wrong environment variable name")
+	os.Setenv("APIZZA_TEST_MORE_TWO", "env")
+	os.Setenv("APIZZA_TEST_NUMBER2", "7")
+	defer os.Unsetenv("APIZZA_TEST_MORE_TWO")
+	defer os.Unsetenv("APIZZA_TEST_NUMBER2")
+
+	c := &testCnfg{}
+	tests.Fatal(SetConfig(".testconfig-layers", c))
+	defer os.RemoveAll(Folder())
+	tests.Check(ioutil.WriteFile(File(), []byte("msg: user msg\nnumber: 3\n"), 0644))
+	c = &testCnfg{}
+	tests.Fatal(SetConfig(".testconfig-layers", c))
+
+	for _, tc := range []struct {
+		key, val, origin string
+	}{
+		{"test", "system", "file:" + SystemFile},
+		{"msg", "user msg", "file:" + File()},
+		{"more.one", "project", "file:" + project},
+		{"more.two", "env", "env:APIZZA_TEST_MORE_TWO"},
+		{"pi", "0", DefaultOrigin},
+	} {
+		tests.StrEq(Origin(tc.key), tc.origin, "wrong origin for %s", tc.key)
+	}
+	if c.Test != "system" || c.Msg != "user msg" || c.More.One != "project" || c.More.Two != "env" {
+		t.Errorf("layers were not loaded in order: %+v", c)
+	}
+	if c.Number != 3 || c.Number2 != 7 {
+		t.Errorf("wrong numbers: %d, %d", c.Number, c.Number2)
+	}
+	tests.StrEq(Origin("More.Two"), Origin("more.two"), "field names should be converted to config keys")
+	var found bool
+	for _, o := range Origins() {
+		if o.Key == "more.two" && o.Value == "env" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Origins should include every key")
+	}
+
+	tests.Check(SetField(c, "more.two", "changed"))
+	tests.Check(Save())
+	raw, err := ioutil.ReadFile(File())
+	tests.Check(err)
+	saved := string(raw)
+	for _, s := range []string{"system", "project", "7"} {
+		if strings.Contains(saved, s) {
+			t.Errorf("values from other layers should not be saved: %q", saved)
+		}
+	}
+	for _, s := range []string{"user msg", "changed"} {
+		if !strings.Contains(saved, s) {
+			t.Errorf("saved config should contain %q", s)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOML keys are the same as the json keys so that a config can be moved
+// between file types. The encoder in the toml package sorts keys by name and
+// only uses toml tags, so config files are written with encodeTOML which keeps
+// the order of the struct fields.
+
+func decodeTOML(raw []byte, v interface{}) error {
+	var m map[string]interface{}
+	if _, err := toml.Decode(string(raw), &m); err != nil {
+		return err
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func encodeTOML(v interface{}) ([]byte, error) {
+	val := reflect.Indirect(reflect.ValueOf(v))
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot encode %s as a toml table", val.Type())
+	}
+	var buf bytes.Buffer
+	if err := writeTable(&buf, val, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type tomlField struct {
+	key string
+	val reflect.Value
+}
+
+// writeTable writes the values of a struct or map. All the plain values are
+// written before any sub-tables because every key after a table header belongs
+// to that table.
+func writeTable(buf *bytes.Buffer, val reflect.Value, path []string) error {
+	var values, tables []tomlField
+	for _, f := range tableFields(val) {
+		v := f.val
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				break
+			}
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			continue // nil
+		case reflect.Struct, reflect.Map:
+			tables = append(tables, tomlField{f.key, v})
+		default:
+			values = append(values, tomlField{f.key, v})
+		}
+	}
+
+	if len(path) > 0 && (len(values) > 0 || len(tables) == 0) {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(buf, "[%s]\n", tomlPath(path))
+	}
+	for _, f := range values {
+		s, err := tomlValue(f.val)
+		if err != nil {
+			return fmt.Errorf("%s: %v", tomlPath(append(path, f.key)), err)
+		}
+		fmt.Fprintf(buf, "%s = %s\n", tomlKey(f.key), s)
+	}
+	for _, f := range tables {
+		if err := writeTable(buf, f.val, append(path[:len(path):len(path)], f.key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableFields returns the keys and values of a struct using the same names and
+// omitempty rules as encoding/json, or the keys and values of a map sorted by
+// key.
+func tableFields(val reflect.Value) []tomlField {
+	var fields []tomlField
+	if val.Kind() == reflect.Map {
+		keys := val.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, k := range keys {
+			fields = append(fields, tomlField{fmt.Sprint(k), val.MapIndex(k)})
+		}
+		return fields
+	}
+
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		key := f.Name
+		var omitempty bool
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				key = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				omitempty = omitempty || opt == "omitempty"
+			}
+		}
+		v := val.Field(i)
+		if omitempty && isEmptyValue(v) {
+			continue
+		}
+		fields = append(fields, tomlField{key, v})
+	}
+	return fields
+}
+
+func tomlValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return tomlString(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return "nan", nil
+		case math.IsInf(f, 1):
+			return "inf", nil
+		case math.IsInf(f, -1):
+			return "-inf", nil
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eEn") {
+			s += ".0"
+		}
+		return s, nil
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			s, err := tomlValue(reflect.Indirect(v.Index(i)))
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("cannot encode %s as toml", v.Type())
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(k string) string {
+	if bareKey.MatchString(k) {
+		return k
+	}
+	return tomlString(k)
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, k := range path {
+		keys[i] = tomlKey(k)
+	}
+	return strings.Join(keys, ".")
+}
+
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isEmptyValue is the same check that encoding/json uses for omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// withDefaults returns a copy of a config struct where every empty field that
+// has a default tag is set to the default value.
+func withDefaults(conf interface{}) (interface{}, error) {
+	out := reflect.New(reflect.TypeOf(conf).Elem())
+	out.Elem().Set(reflect.ValueOf(conf).Elem())
+	return out.Interface(), setDefaults(out.Elem())
+}
+
+func setDefaults(val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		field := val.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := setDefaults(field); err != nil {
+				return err
+			}
+			continue
+		}
+		deflt, ok := f.Tag.Lookup("default")
+		if !ok || !field.IsZero() {
+			continue
+		}
+		if err := setString(field, deflt); err != nil {
+			return fmt.Errorf("default for %s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/harrybrwn/apizza/pkg/tests"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+type tomlCnfg struct {
+	Name    string  `config:"name" json:"name"`
+	Service string  `config:"service" json:"service" default:"Delivery"`
+	Count   int     `config:"count" json:"count" default:"3"`
+	Ratio   float64 `config:"ratio" json:"ratio"`
+	On      bool    `config:"on" json:"on"`
+	Card    struct {
+		Number string `config:"number" json:"number"`
+	} `config:"card" json:"card"`
+	Others map[string]*struct {
+		Email string `config:"email" json:"email,omitempty"`
+	} `config:"others" json:"others,omitempty"`
+}
+
+func TestTOML(t *testing.T) {
+	tests.InitHelpers(t)
+	home, err := homedir.Dir()
+	tests.Fatal(err)
+	dir := filepath.Join(home, ".testconfig-toml")
+	tests.Fatal(os.MkdirAll(dir, 0700))
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.toml")
+	tests.Fatal(ioutil.WriteFile(file, []byte(`
+name = "bob \"the builder\""
+count = 7
+
+[card]
+number = "1234"
+
+[others.work]
+email = "bob@work.com"
+`), 0644))
+
+	c := &tomlCnfg{}
+	tests.Fatal(SetConfig(".testconfig-toml", c))
+	tests.StrEq(File(), file, "should have found the toml file")
+	if cfg.typ != TOMLType {
+		t.Errorf("wrong file type: %s", cfg.typ)
+	}
+	tests.StrEq(c.Name, `bob "the builder"`, "wrong name")
+	tests.StrEq(c.Card.Number, "1234", "wrong card number")
+	if c.Count != 7 || c.Others["work"] == nil || c.Others["work"].Email != "bob@work.com" {
+		t.Errorf("toml file was not loaded: %+v", c)
+	}
+	tests.StrEq(Origin("card.number"), "file:"+file, "wrong origin")
+
+	c.Ratio = 0.5
+	c.On = true
+	c.Service = "Carryout\n"
+	tests.Check(Save())
+	raw, err := ioutil.ReadFile(file)
+	tests.Check(err)
+	for _, s := range []string{"ratio = 0.5\n", "service = \"Carryout\\n\"\n", "[others.work]\n"} {
+		if !strings.Contains(string(raw), s) {
+			t.Errorf("expected %q in the saved file:\n%s", s, raw)
+		}
+	}
+	saved := &tomlCnfg{}
+	tests.Fatal(SetConfig(".testconfig-toml", saved))
+	if !reflect.DeepEqual(c, saved) {
+		t.Errorf("config did not survive a round trip:\n%+v\n%+v", c, saved)
+	}
+
+	*saved = tomlCnfg{}
+	tests.Check(Reset())
+	raw, err = ioutil.ReadFile(file)
+	tests.Check(err)
+	if !strings.HasPrefix(string(raw), "name = \"\"\nservice = \"Delivery\"\ncount = 3\n") {
+		t.Errorf("new toml files should use defaults in field order:\n%s", raw)
+	}
+	issues, err := ValidateFile(file)
+	tests.Check(err)
+	if len(issues) != 0 {
+		t.Errorf("generated file should be valid: %v", issues)
+	}
+}
@@ -10,11 +10,12 @@ func _() {
 	var x [1]struct{}
 	_ = x[YamlType-0]
 	_ = x[JSONType-1]
+	_ = x[TOMLType-2]
 }
 
-const _Type_name = "YamlTypeJSONType"
+const _Type_name = "YamlTypeJSONTypeTOMLType"
 
-var _Type_index = [...]uint8{0, 8, 16}
+var _Type_index = [...]uint8{0, 8, 16, 24}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {
@@ -0,0 +1,387 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Issue is a problem found when validating a config.
+type Issue struct {
+	// File is the config file the issue was found in. It is empty when the
+	// issue is with the value of a loaded config.
+	File    string
+	Key     string
+	Message string
+	// Missing is true when a required value is not set.
+	Missing bool
+}
+
+func (i Issue) String() string {
+	if i.File == "" {
+		return fmt.Sprintf("%s: %s", i.Key, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.File, i.Key, i.Message)
+}
+
+// Validate checks the config passed to SetConfig. Every config file that was
+// loaded is checked for unknown keys and values with the wrong type, then the
+// values are checked against the rules in the struct's validate tags. An error
+// is returned if a validate tag has a rule that does not exist.
+func Validate() ([]Issue, error) {
+	if cfg.conf == nil {
+		return nil, nil
+	}
+	var issues []Issue
+	for _, file := range cfg.files {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		found, err := checkFile(file, raw, reflect.TypeOf(cfg.conf).Elem())
+		if err != nil {
+			issues = append(issues, Issue{File: file, Message: err.Error()})
+		}
+		issues = append(issues, found...)
+	}
+	found, err := ValidateStruct(cfg.conf)
+	return append(issues, found...), err
+}
+
+// ValidateFile checks a single config file using the type of the config passed
+// to SetConfig. Missing values are not reported because they may be set by
+// another file or environment variable. An error is returned if the file
+// cannot be read or parsed.
+func ValidateFile(file string) ([]Issue, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.TypeOf(cfg.conf).Elem()
+	issues, err := checkFile(file, raw, typ)
+	if err != nil {
+		return nil, err
+	}
+	conf := reflect.New(typ).Interface()
+	unmarshal(file, raw, conf)
+	found, err := ValidateStruct(conf)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range found {
+		if !issue.Missing {
+			issue.File = file
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// ValidateStruct checks the values of a config struct against the rules in
+// its validate tags. Rules are separated by commas:
+//
+//	required        the value cannot be empty
+//	oneof=a|b       the value must be one of the options
+//	email           the value must look like an email address
+//	phone           the value must be a 10 digit phone number
+//	expiration      the value must be a date formatted as mm/yy
+//	complete        all the fields of a struct are set or none of them are
+//
+// Every rule other than required and complete ignores empty values. Structs
+// inside of maps are also validated. An unknown rule is an error.
+func ValidateStruct(conf interface{}) ([]Issue, error) {
+	val := reflect.ValueOf(conf)
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	return validateStruct(val, "")
+}
+
+func validateStruct(val reflect.Value, prefix string) ([]Issue, error) {
+	var issues []Issue
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		key := prefix + fieldKey(f)
+		rules := rulesOf(f)
+		for rule := range rules {
+			if !knownRules[rule] {
+				return nil, fmt.Errorf("config: unknown validation rule '%s' for %s", rule, key)
+			}
+		}
+		field := val.Field(i)
+
+		var (
+			found []Issue
+			err   error
+		)
+		switch field.Kind() {
+		case reflect.Struct:
+			if _, ok := rules["complete"]; ok {
+				issues = append(issues, checkComplete(field, key)...)
+			}
+			found, err = validateStruct(field, key+".")
+		case reflect.Map:
+			keys := field.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+			})
+			for _, k := range keys {
+				elem := reflect.Indirect(field.MapIndex(k))
+				if elem.Kind() != reflect.Struct {
+					continue
+				}
+				var elemIssues []Issue
+				if elemIssues, err = validateStruct(elem, fmt.Sprintf("%s.%v.", key, k)); err != nil {
+					break
+				}
+				found = append(found, elemIssues...)
+			}
+		case reflect.Ptr:
+			if !field.IsNil() && field.Elem().Kind() == reflect.Struct {
+				found, err = validateStruct(field.Elem(), key+".")
+			}
+		default:
+			found = checkValue(field, key, rules)
+		}
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	return issues, nil
+}
+
+var (
+	emailRegex      = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	phoneRegex      = regexp.MustCompile(`^1?[0-9]{10}$`)
+	expirationRegex = regexp.MustCompile(`^(0?[1-9]|1[0-2])/?([0-9]{2}|[0-9]{4})$`)
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "+", "")
+)
+
+var knownRules = map[string]bool{
+	"required": true, "complete": true, "oneof": true, "email": true, "phone": true, "expiration": true,
+}
+
+func checkValue(field reflect.Value, key string, rules map[string]string) []Issue {
+	var issues []Issue
+	if field.IsZero() {
+		if _, ok := rules["required"]; ok {
+			issues = append(issues, Issue{Key: key, Message: "is required", Missing: true})
+		}
+		return issues
+	}
+	s := fmt.Sprint(field.Interface())
+	for rule, arg := range rules {
+		var msg string
+		switch rule {
+		case "required", "complete":
+		case "oneof":
+			options := strings.Split(arg, "|")
+			if !contains(options, s) {
+				msg = fmt.Sprintf("must be one of %s, got '%s'", strings.Join(options, ", "), s)
+			}
+		case "email":
+			if !emailRegex.MatchString(s) {
+				msg = fmt.Sprintf("'%s' is not a valid email address", s)
+			}
+		case "phone":
+			if !phoneRegex.MatchString(phoneSeparators.Replace(s)) {
+				msg = fmt.Sprintf("'%s' is not a valid phone number", s)
+			}
+		case "expiration":
+			if !expirationRegex.MatchString(s) {
+				msg = fmt.Sprintf("'%s' is not a date in the format mm/yy", s)
+			}
+		}
+		if msg != "" {
+			issues = append(issues, Issue{Key: key, Message: msg})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Message < issues[j].Message })
+	return issues
+}
+
+// checkComplete makes sure that all the values of a struct are set if any of
+// them are.
+func checkComplete(val reflect.Value, key string) []Issue {
+	fields := leafFields(val, key+".")
+	var empty []string
+	for k, f := range fields {
+		if f.IsZero() {
+			empty = append(empty, k)
+		}
+	}
+	if len(empty) == 0 || len(empty) == len(fields) {
+		return nil
+	}
+	sort.Strings(empty)
+	issues := make([]Issue, len(empty))
+	for i, k := range empty {
+		issues[i] = Issue{Key: k, Message: fmt.Sprintf("is required when %s is set", key)}
+	}
+	return issues
+}
+
+func rulesOf(f reflect.StructField) map[string]string {
+	rules := map[string]string{}
+	tag, ok := f.Tag.Lookup("validate")
+	if !ok {
+		return rules
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) == 2 {
+			rules[parts[0]] = parts[1]
+		} else {
+			rules[parts[0]] = ""
+		}
+	}
+	return rules
+}
+
+// checkFile finds the keys in a config file that are not in the config struct
+// and the values that have the wrong type.
+func checkFile(file string, raw []byte, typ reflect.Type) ([]Issue, error) {
+	m, err := rawMap(file, raw)
+	if err != nil {
+		return nil, err
+	}
+	issues := checkKeys(typ, m, "")
+	for i := range issues {
+		issues[i].File = file
+	}
+	return issues, nil
+}
+
+func checkKeys(typ reflect.Type, m map[string]interface{}, prefix string) []Issue {
+	var issues []Issue
+	for _, name := range sortedKeys(m) {
+		f, ok := findField(typ, name)
+		if !ok {
+			issues = append(issues, Issue{Key: prefix + name, Message: "unknown key"})
+			continue
+		}
+		issues = append(issues, checkType(f.Type, m[name], prefix+fieldKey(f))...)
+	}
+	return issues
+}
+
+func checkType(typ reflect.Type, val interface{}, key string) []Issue {
+	if val == nil {
+		return nil
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	ok := true
+	switch typ.Kind() {
+	case reflect.Struct:
+		if m, isMap := val.(map[string]interface{}); isMap {
+			return checkKeys(typ, m, key+".")
+		}
+		ok = false
+	case reflect.Map:
+		m, isMap := val.(map[string]interface{})
+		if !isMap {
+			ok = false
+			break
+		}
+		var issues []Issue
+		for _, k := range sortedKeys(m) {
+			issues = append(issues, checkType(typ.Elem(), m[k], key+"."+k)...)
+		}
+		return issues
+	case reflect.String:
+		_, ok = val.(string)
+	case reflect.Bool:
+		_, ok = val.(bool)
+	case reflect.Int, reflect.Int64:
+		switch n := val.(type) {
+		case int, int64:
+		case float64: // json numbers
+			ok = n == float64(int64(n))
+		default:
+			ok = false
+		}
+	case reflect.Float32, reflect.Float64:
+		switch val.(type) {
+		case int, int64, float64:
+		default:
+			ok = false
+		}
+	}
+	if ok {
+		return nil
+	}
+	return []Issue{{
+		Key:     key,
+		Message: fmt.Sprintf("should be %s, got %s", typeName(typ.Kind()), valueTypeName(val)),
+	}}
+}
+
+func findField(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath == "" && matchesField(f, name) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func typeName(k reflect.Kind) string {
+	switch k {
+	case reflect.Struct, reflect.Map:
+		return "a section of keys"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return k.String()
+}
+
+func valueTypeName(val interface{}) string {
+	switch val.(type) {
+	case map[string]interface{}:
+		return "a section of keys"
+	case []interface{}:
+		return "a list"
+	case string:
+		return "a string"
+	case bool:
+		return "true or false"
+	case int, int64, float64:
+		return "a number"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
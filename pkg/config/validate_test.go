@@ -0,0 +1,135 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/harrybrwn/apizza/pkg/tests"
+)
+
+type validCnfg struct {
+	Name    string `config:"name" validate:"required"`
+	Email   string `config:"email" validate:"email"`
+	Phone   string `config:"phone" validate:"phone"`
+	Service string `config:"service" validate:"required,oneof=Delivery|Carryout"`
+	Count   int    `config:"count"`
+	Address struct {
+		Street string `config:"street"`
+		City   string `config:"city"`
+	} `config:"address" validate:"complete"`
+	Card struct {
+		Expiration string `config:"expiration" validate:"expiration"`
+	} `config:"card"`
+	Others map[string]*struct {
+		Email string `config:"email" validate:"email"`
+	} `config:"others"`
+}
+
+func issueMap(issues []Issue) map[string]Issue {
+	m := map[string]Issue{}
+	for _, issue := range issues {
+		m[issue.Key] = issue
+	}
+	return m
+}
+
+func TestValidateStruct(t *testing.T) {
+	tests.InitHelpers(t)
+	c := &validCnfg{
+		Email:   "bob@example.com",
+		Phone:   "(123) 456-7890",
+		Service: "Delivery",
+	}
+	c.Card.Expiration = "01/25"
+	issues, err := ValidateStruct(c)
+	tests.Check(err)
+	if len(issues) != 1 || !issues[0].Missing || issues[0].Key != "name" {
+		t.Errorf("expected only a missing name, got %v", issues)
+	}
+
+	c.Name = "bob"
+	c.Email = "bob"
+	c.Phone = "123"
+	c.Service = "Pickup"
+	c.Address.Street = "1600 Pennsylvania Ave NW"
+	c.Card.Expiration = "13/25"
+	c.Others = map[string]*struct {
+		Email string `config:"email" validate:"email"`
+	}{"work": {Email: "not an email"}}
+	list, err := ValidateStruct(c)
+	tests.Check(err)
+	found := issueMap(list)
+	for _, key := range []string{"email", "phone", "service", "address.city", "card.expiration", "others.work.email"} {
+		issue, ok := found[key]
+		if !ok {
+			t.Errorf("expected an issue for %s", key)
+		} else if issue.Missing {
+			t.Errorf("%s should not be missing", key)
+		}
+	}
+	if len(found) != 6 {
+		t.Errorf("expected 6 issues, got %v", found)
+	}
+
+	bad := &struct {
+		Name string `config:"name" validate:"required,shouty"`
+	}{}
+	if _, err = ValidateStruct(bad); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	tests.InitHelpers(t)
+	c := &validCnfg{}
+	tests.Fatal(SetConfig(".testconfig-validate", c))
+	defer os.RemoveAll(Folder())
+
+	tests.Check(ioutil.WriteFile(File(), []byte(`
+name: bob
+email: bob
+count: three
+unknown: value
+address:
+  street: 1600 Pennsylvania Ave NW
+  city: Washington DC
+  zip: "20500"
+others:
+  work:
+    email: bob@work.com
+    phone: "1231231234"
+`), 0644))
+	issues, err := ValidateFile(File())
+	tests.Check(err)
+	m := issueMap(issues)
+	for key, msg := range map[string]string{
+		"email":             "'bob' is not a valid email address",
+		"count":             "should be a whole number, got a string",
+		"unknown":           "unknown key",
+		"address.zip":       "unknown key",
+		"others.work.phone": "unknown key",
+	} {
+		tests.StrEq(m[key].Message, msg, "wrong message for %s", key)
+		tests.StrEq(m[key].File, File(), "issues should have the file name")
+	}
+	if _, ok := m["service"]; ok {
+		t.Error("ValidateFile should not report missing values")
+	}
+	if len(issues) != 5 {
+		t.Errorf("expected 5 issues, got %v", issues)
+	}
+
+	tests.Check(ioutil.WriteFile(File(), []byte("name: [bob"), 0644))
+	_, err = ValidateFile(File())
+	tests.Exp(err, "expected a parse error")
+
+	tests.Check(ioutil.WriteFile(File(), []byte(`{"name": "bob",	"service": "Carryout", "count": 2}`), 0644))
+	c = &validCnfg{}
+	tests.Fatal(SetConfig(".testconfig-validate", c))
+	issues, err = Validate()
+	tests.Check(err)
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
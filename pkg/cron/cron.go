@@ -0,0 +1,226 @@
+// Package cron parses cron expressions and finds the next time that they
+// match.
+//
+// An expression has five fields separated by spaces:
+//
+//	minute        0-59
+//	hour          0-23
+//	day of month  1-31
+//	month         1-12 or JAN-DEC
+//	day of week   0-6 or SUN-SAT, 7 is also sunday
+//
+// A field can be '*' for every value, a number or name, a range like '1-5',
+// a step like '*/15' or '0-30/10', or a comma separated list of any of these.
+// If both the day of month and the day of week are restricted then a day
+// matches when either of them match, like in crontab.
+//
+// The descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight,
+// and @hourly can be used instead of the five fields.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	spec string
+
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are true if the day fields are '*'.
+	domStar, dowStar bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	expr := spec
+	if strings.HasPrefix(expr, "@") {
+		var ok bool
+		if expr, ok = descriptors[strings.ToLower(expr)]; !ok {
+			return nil, fmt.Errorf("unknown cron descriptor '%s'", spec)
+		}
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression '%s' should have 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &Schedule{spec: spec}
+	var err error
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is sunday
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+// String returns the expression that the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time after t that matches the schedule. Times are
+// matched in t's location. The zero time is returned if nothing matches in
+// the next five years, like for the 30th of February.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+
+	for t.Before(end) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(set uint64, n int) bool {
+	return set&(1<<uint(n)) != 0
+}
+
+// parse turns one field of an expression into a set of bits.
+func (f field) parse(expr string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expr, ",") {
+		bits, err := f.parsePart(part)
+		if err != nil {
+			return 0, fmt.Errorf("bad %s '%s': %v", f.name, expr, err)
+		}
+		set |= bits
+	}
+	return set, nil
+}
+
+func (f field) parsePart(part string) (uint64, error) {
+	rng, step := part, 1
+	if i := strings.Index(part, "/"); i >= 0 {
+		var err error
+		rng = part[:i]
+		if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+			return 0, fmt.Errorf("bad step '%s'", part[i+1:])
+		}
+	}
+
+	var lo, hi int
+	switch {
+	case rng == "*":
+		lo, hi = f.min, f.max
+		if f.max == 7 {
+			hi = 6 // don't count sunday twice
+		}
+	case strings.Contains(rng, "-"):
+		bounds := strings.SplitN(rng, "-", 2)
+		var err error
+		if lo, err = f.value(bounds[0]); err != nil {
+			return 0, err
+		}
+		if hi, err = f.value(bounds[1]); err != nil {
+			return 0, err
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("range '%s' is backwards", rng)
+		}
+	default:
+		var err error
+		if lo, err = f.value(rng); err != nil {
+			return 0, err
+		}
+		hi = lo
+		if step > 1 {
+			hi = f.max // '5/10' means starting at 5
+		}
+	}
+
+	var set uint64
+	for i := lo; i <= hi; i += step {
+		set |= 1 << uint(i)
+	}
+	return set, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if n, ok := f.names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%d is not between %d and %d", n, f.min, f.max)
+	}
+	return n, nil
+}
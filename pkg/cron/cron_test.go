@@ -0,0 +1,84 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02 15:04 Mon", s, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	for _, tt := range []struct {
+		spec, from, next string
+	}{
+		{"0 12 * * FRI", "2020-05-04 09:00 Mon", "2020-05-08 12:00 Fri"},
+		{"0 12 * * fri", "2020-05-08 12:00 Fri", "2020-05-15 12:00 Fri"},
+		{"0 12 * * 5", "2020-05-08 11:59 Fri", "2020-05-08 12:00 Fri"},
+		{"*/15 * * * *", "2020-05-08 11:59 Fri", "2020-05-08 12:00 Fri"},
+		{"*/15 * * * *", "2020-05-08 12:00 Fri", "2020-05-08 12:15 Fri"},
+		{"5/20 * * * *", "2020-05-08 12:30 Fri", "2020-05-08 12:45 Fri"},
+		{"30 9-17/4 * * MON-FRI", "2020-05-08 17:31 Fri", "2020-05-11 09:30 Mon"},
+		{"30 9-17/4 * * MON-FRI", "2020-05-11 09:30 Mon", "2020-05-11 13:30 Mon"},
+		{"0 0 1,15 * *", "2020-05-02 00:00 Sat", "2020-05-15 00:00 Fri"},
+		{"0 0 31 * *", "2020-04-01 00:00 Wed", "2020-05-31 00:00 Sun"},
+		{"0 0 29 feb *", "2020-03-01 00:00 Sun", "2024-02-29 00:00 Thu"},
+		{"0 18 * NOV-DEC 7", "2020-05-08 12:00 Fri", "2020-11-01 18:00 Sun"},
+		// both day fields are restricted so either one can match
+		{"0 0 13 * 5", "2020-05-09 00:00 Sat", "2020-05-13 00:00 Wed"},
+		{"0 0 13 * 5", "2020-05-13 00:00 Wed", "2020-05-15 00:00 Fri"},
+		{"@weekly", "2020-05-08 12:00 Fri", "2020-05-10 00:00 Sun"},
+		{"@hourly", "2020-05-08 12:00 Fri", "2020-05-08 13:00 Fri"},
+		{"@yearly", "2020-05-08 12:00 Fri", "2021-01-01 00:00 Fri"},
+	} {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("%s: %v", tt.spec, err)
+			continue
+		}
+		next := s.Next(date(tt.from))
+		if !next.Equal(date(tt.next)) {
+			t.Errorf("%s after %s: got %s, want %s", tt.spec, tt.from,
+				next.Format("2006-01-02 15:04 Mon"), tt.next)
+		}
+	}
+
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Next(date("2020-01-01 00:00 Wed")).IsZero() {
+		t.Error("the 30th of february should never match")
+	}
+	if s.String() != "0 0 30 2 *" {
+		t.Errorf("wrong string %q", s.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * FRIDAY",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"1,,2 * * * *",
+		"@sometimes",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// CassetteMode decides whether a Cassette sends real requests or replays
+// recorded ones.
+type CassetteMode int
+
+const (
+	// Replay will only use recorded responses and will never send a request.
+	Replay CassetteMode = iota
+	// Record will send every request and save the responses.
+	Record
+)
+
+// RecordEnv is the environment variable that will put all cassettes created
+// with UseCassette into record mode when it is set to "1" or "true".
+const RecordEnv = "APIZZA_TEST_RECORD"
+
+// Cassette is an http.RoundTripper that records http traffic to a file and
+// replays it later so that tests can run without a network connection.
+//
+// Requests are matched by method, path, and request body. JSON bodies are
+// normalized before being compared so that key order does not matter.
+// Card numbers, tokens, passwords, and emails are scrubbed from everything
+// that is saved.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+
+	file  string
+	mode  CassetteMode
+	inner http.RoundTripper
+	mu    sync.Mutex
+	used  map[*Interaction]bool
+	t     *testing.T
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest is a recorded http request.
+type CassetteRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded http response.
+type CassetteResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// NewCassette creates a cassette for a file. In Replay mode the file must
+// already exist. The inner RoundTripper is used to send requests while
+// recording, http.DefaultTransport is used if it is nil.
+func NewCassette(file string, mode CassetteMode, inner http.RoundTripper) (*Cassette, error) {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	c := &Cassette{
+		file:  file,
+		mode:  mode,
+		inner: inner,
+		used:  make(map[*Interaction]bool),
+	}
+	if mode == Record {
+		return c, nil
+	}
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return c, json.Unmarshal(raw, c)
+}
+
+// UseCassette creates a cassette for a test. The cassette will be in Replay
+// mode unless the APIZZA_TEST_RECORD environment variable is set, in which
+// case the cassette is saved when it is ejected.
+func UseCassette(t *testing.T, file string) *Cassette {
+	c, err := NewCassette(file, EnvMode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.t = t
+	c.init(t)
+	return c
+}
+
+// EnvMode returns Record if the APIZZA_TEST_RECORD environment variable is
+// set and Replay if it is not.
+func EnvMode() CassetteMode {
+	if env := os.Getenv(RecordEnv); env == "1" || env == "true" {
+		return Record
+	}
+	return Replay
+}
+
+func (c *Cassette) eject() {
+	if c.mode != Record {
+		return
+	}
+	if err := c.Save(); err != nil {
+		c.t.Error(err)
+	}
+}
+
+// Mode returns the cassette's mode.
+func (c *Cassette) Mode() CassetteMode {
+	return c.mode
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := CassetteRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  Scrub(req.URL.RawQuery),
+		Body:   Scrub(string(body)),
+	}
+	if c.mode == Record {
+		return c.record(req, recorded)
+	}
+
+	in := c.find(recorded)
+	if in == nil {
+		return nil, fmt.Errorf("cassette %s: no recorded response for %s %s", filepath.Base(c.file), req.Method, req.URL.Path)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+		StatusCode:    in.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        in.Response.Header.Clone(),
+		Body:          ioutil.NopCloser(strings.NewReader(in.Response.Body)),
+		ContentLength: int64(len(in.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (c *Cassette) record(req *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	resp, err := c.inner.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	for _, h := range scrubbedHeaders {
+		if header.Get(h) != "" {
+			header.Set(h, scrubbed)
+		}
+	}
+	c.mu.Lock()
+	c.Interactions = append(c.Interactions, &Interaction{
+		Request: recorded,
+		Response: CassetteResponse{
+			Status: resp.StatusCode,
+			Header: header,
+			Body:   Scrub(string(body)),
+		},
+	})
+	c.mu.Unlock()
+	return resp, nil
+}
+
+// find returns the first unused interaction matching the request. If all the
+// matching interactions have been used then the last one is used again.
+func (c *Cassette) find(req CassetteRequest) *Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var last *Interaction
+	body := normalizeBody(req.Body)
+	for _, in := range c.Interactions {
+		if in.Request.Method != req.Method ||
+			in.Request.Path != req.Path ||
+			normalizeBody(in.Request.Body) != body {
+			continue
+		}
+		if !c.used[in] {
+			c.used[in] = true
+			return in
+		}
+		last = in
+	}
+	return last
+}
+
+// Save writes the recorded interactions to the cassette's file.
+func (c *Cassette) Save() error {
+	if c.mode != Record {
+		return errors.New("can only save a cassette in record mode")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	raw, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(c.file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.file, raw, 0644)
+}
+
+const scrubbed = "[scrubbed]"
+
+var (
+	scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+	emailRe = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// secret fields and the payment fields dominos uses for card numbers,
+	// which are sometimes numbers instead of strings and sometimes inside of
+	// an escaped json string
+	jsonFieldRe = regexp.MustCompile(`(?i)(\\?")(access_token|refresh_token|id_token|password|number|cardnumber|securitycode|cvv)\\?"(\s*):(\s*)(\\?"[^"\\]*\\?"|\d+)`)
+	formFieldRe = regexp.MustCompile(`(?i)\b(access_token|refresh_token|password|username)=[^&]*`)
+	emailUser   = "user@example.com"
+)
+
+// Scrub removes card numbers, tokens, passwords, and emails from a string.
+// Card numbers are only removed from payment fields so that other long
+// numbers like timestamps are left alone.
+func Scrub(s string) string {
+	s = jsonFieldRe.ReplaceAllString(s, `$1$2$1$3:$4$1`+scrubbed+`$1`)
+	s = formFieldRe.ReplaceAllString(s, "$1="+scrubbed)
+	return emailRe.ReplaceAllString(s, emailUser)
+}
+
+func normalizeBody(body string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return strings.TrimSpace(body)
+	}
+	// maps are encoded with sorted keys
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return string(raw)
+}
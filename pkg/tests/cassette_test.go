@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScrub(t *testing.T) {
+	for _, tc := range []struct {
+		in, exp string
+	}{
+		{`{"Number":"4929588303328334","Expiration":"0125"}`, `{"Number":"[scrubbed]","Expiration":"0125"}`},
+		{`{"Payments":[{"Number":5536838507150030,"SecurityCode":"123"}]}`, `{"Payments":[{"Number":"[scrubbed]","SecurityCode":"[scrubbed]"}]}`},
+		{`{"body":"{\"Number\":\"4532692223025787\"}"}`, `{"body":"{\"Number\":\"[scrubbed]\"}"}`},
+		// long numbers outside of payment fields are not card numbers
+		{`{"PlaceOrderMs":1592348201553,"ID":"4532692223025787"}`, `{"PlaceOrderMs":1592348201553,"ID":"4532692223025787"}`},
+		{`{"Email":"jdoe+pizza@gmail.com"}`, `{"Email":"user@example.com"}`},
+		{`{"access_token": "abc.def", "expires_in": 3600}`, `{"access_token": "[scrubbed]", "expires_in": 3600}`},
+		{`grant_type=password&username=me%40x.com&password=hunter2`, `grant_type=password&username=[scrubbed]&password=[scrubbed]`},
+		{`{"StoreID":"4336"}`, `{"StoreID":"4336"}`},
+	} {
+		if got := Scrub(tc.in); got != tc.exp {
+			t.Errorf("Scrub(%q):\ngot  %q\nwant %q", tc.in, got, tc.exp)
+		}
+	}
+}
+
+func TestCassette(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		fmt.Fprintf(w, `{"path":%q,"body":%q,"Email":"someone@dominos.com"}`, r.URL.Path, b)
+	}))
+	defer srv.Close()
+	file := filepath.Join(TempDir(), "cassette.json")
+	defer os.RemoveAll(filepath.Dir(file))
+
+	rec, err := NewCassette(file, Record, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &http.Client{Transport: rec}
+	resp, err := cli.Post(srv.URL+"/power/price-order", "application/json",
+		strings.NewReader(`{"Order":{"StoreID":"4336","Payments":[{"Number":"4532692223025787"}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(b), "someone@dominos.com") {
+		t.Error("the response should not be scrubbed while recording")
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"4532692223025787", "someone@dominos.com", "session=secret"} {
+		if strings.Contains(string(raw), secret) {
+			t.Errorf("cassette file should not contain %q", secret)
+		}
+	}
+
+	srv.Close() // replaying should never send a request
+	play, err := NewCassette(file, Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if play.Mode() != Replay {
+		t.Error("wrong cassette mode")
+	}
+	cli.Transport = play
+	// same body with a different key order
+	resp, err = cli.Post(srv.URL+"/power/price-order", "application/json",
+		strings.NewReader(`{"Order":{"Payments":[{"Number":"4532692223025787"}],"StoreID":"4336"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("bad replayed response: %d %v", resp.StatusCode, resp.Header)
+	}
+	if !strings.Contains(string(b), `"path":"/power/price-order"`) {
+		t.Errorf("wrong response body: %s", b)
+	}
+
+	if _, err = cli.Post(srv.URL+"/power/price-order", "application/json", strings.NewReader(`{"Order":{}}`)); err == nil {
+		t.Error("expected an error for a request that was not recorded")
+	}
+	if _, err = cli.Get(srv.URL + "/power/place-order"); err == nil {
+		t.Error("expected an error for a request that was not recorded")
+	}
+	if err = play.Save(); err == nil {
+		t.Error("should not be able to save a cassette that is not recording")
+	}
+}
@@ -17,3 +17,10 @@ func initHelpers(t *testing.T) {
 		t:    t,
 	}
 }
+
+// Eject is a noop for go1.14, the cassette is saved when the test finishes.
+func (c *Cassette) Eject() {}
+
+func (c *Cassette) init(t *testing.T) {
+	t.Cleanup(c.eject)
+}